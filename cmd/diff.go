@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare two cached resources",
+	Long: `Compare two cached OpenStack resources and show what differs between them.
+
+Available resources:
+    secgrp  Compare the rules of two security groups
+
+Examples:
+
+# compare two security groups
+osc diff secgrp web-servers web-servers-v2
+
+# compare the same security group across projects
+osc diff secgrp web-servers --project-a prod --project-b staging`,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Fatal("Diff must be called with a subcommand")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/db"
+	"github.com/marcdicarlo/osc/internal/output"
+	"github.com/marcdicarlo/osc/internal/secgrp"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffProjectA string
+	diffProjectB string
+)
+
+var diffSecGrpCmd = &cobra.Command{
+	Use:   "secgrp <secgrp_a> [secgrp_b]",
+	Short: "Compare the rules of two security groups",
+	Long: `Compare the rules of two security groups, or of the same security group in two projects.
+
+Rules are normalized before comparison: rule IDs are ignored, remote groups are
+compared by name, and rules that reference their own group match each other.
+Each rule is reported as only_in_a, only_in_b or both.
+
+If only one security group name is given, it is compared with itself across
+the projects given by --project-a and --project-b.
+
+Examples:
+
+# compare two security groups
+osc diff secgrp web-servers web-servers-v2
+
+# compare the same security group across projects
+osc diff secgrp web-servers --project-a prod --project-b staging
+
+# output in different formats
+osc diff secgrp web-servers --project-a prod --project-b staging -o json
+osc diff secgrp web-servers web-servers-v2 -o csv`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load("config.yaml")
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		database, err := db.InitDB(cfg)
+		if err != nil {
+			log.Fatalf("Failed to init db: %v", err)
+		}
+		defer database.Close()
+
+		nameA := args[0]
+		nameB := args[0]
+		if len(args) == 2 {
+			nameB = args[1]
+		}
+		if err := DiffSecGrp(database, cfg, nameA, nameB); err != nil {
+			log.Fatalf("Failed to compare security groups: %v", err)
+		}
+	},
+}
+
+func init() {
+	diffCmd.AddCommand(diffSecGrpCmd)
+	diffSecGrpCmd.Flags().StringVar(&diffProjectA, "project-a", "", "Project name of the first security group (shows projects containing this string)")
+	diffSecGrpCmd.Flags().StringVar(&diffProjectB, "project-b", "", "Project name of the second security group (shows projects containing this string)")
+}
+
+// DiffSecGrp compares the rules of two security groups and outputs the result
+func DiffSecGrp(database *sql.DB, cfg *config.Config, nameA, nameB string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
+	defer cancel()
+
+	if nameA == nameB && strings.EqualFold(diffProjectA, diffProjectB) {
+		return fmt.Errorf("comparing %q with itself: give a second security group or different --project-a/--project-b values", nameA)
+	}
+
+	sgA, err := findSecGrp(ctx, database, cfg, nameA, diffProjectA)
+	if err != nil {
+		return err
+	}
+	sgB, err := findSecGrp(ctx, database, cfg, nameB, diffProjectB)
+	if err != nil {
+		return err
+	}
+
+	if err := fetchSecGrpRules(ctx, database, cfg, sgA); err != nil {
+		return err
+	}
+	if err := fetchSecGrpRules(ctx, database, cfg, sgB); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Comparing A: %s (%s) in %s with B: %s (%s) in %s\n\n",
+		sgA.SecGrpName, sgA.SecGrpID, sgA.ProjectName,
+		sgB.SecGrpName, sgB.SecGrpID, sgB.ProjectName)
+
+	diffs := secgrp.DiffRules(normalizeSecGrpRules(sgA), normalizeSecGrpRules(sgB))
+
+	var records []output.Record
+	for _, d := range diffs {
+		records = append(records, output.NewRecord("security-group-rule",
+			output.Field{Key: "presence", Value: string(d.Presence)},
+			output.Field{Key: "direction", Value: d.Rule.Direction},
+			output.Field{Key: "ethertype", Value: d.Rule.Ethertype},
+			output.Field{Key: "protocol", Value: d.Rule.Protocol},
+			output.Field{Key: "port_range", Value: d.Rule.PortRange},
+			output.Field{Key: "remote_ip", Value: d.Rule.RemoteIP},
+			output.Field{Key: "remote_group", Value: d.Rule.RemoteGroup},
+		))
+	}
+
+	formatter, err := output.NewFormatter(outputFormat, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	columns := []output.Column{
+		{Header: "Presence", Key: "presence"},
+		{Header: "Resource Type", Key: "type", Fields: []string{}, Format: func(r output.Record) string { return r.Type }},
+		{Header: "Direction", Key: "direction"},
+		{Header: "Ethertype", Key: "ethertype"},
+		{Header: "Protocol", Key: "protocol"},
+		{Header: "Port Range", Key: "port_range"},
+		{Header: "Remote IP", Key: "remote_ip"},
+		{Header: "Remote Group", Key: "remote_group"},
+	}
+	return formatter.Format(output.NewRecordData(columns, records))
+}
+
+// findSecGrp looks up exactly one security group by name, optionally restricted to matching projects
+func findSecGrp(ctx context.Context, database *sql.DB, cfg *config.Config, name, project string) (*SecGrpDetail, error) {
+	query := `SELECT sg.secgrp_id, sg.secgrp_name, sg.project_id, p.project_name
+              FROM ` + cfg.Tables.SecGrps + ` sg
+              JOIN ` + cfg.Tables.Projects + ` p USING (project_id)
+              WHERE sg.secgrp_name = ?`
+
+	args := []interface{}{name}
+
+	if project != "" {
+		query += " AND LOWER(p.project_name) LIKE ?"
+		args = append(args, "%"+strings.ToLower(project)+"%")
+	}
+
+	rows, err := database.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []SecGrpDetail
+	for rows.Next() {
+		var sg SecGrpDetail
+		if err := rows.Scan(&sg.SecGrpID, &sg.SecGrpName, &sg.ProjectID, &sg.ProjectName); err != nil {
+			return nil, err
+		}
+		matches = append(matches, sg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	switch len(matches) {
+	case 0:
+		if project != "" {
+			return nil, fmt.Errorf("security group %q not found in projects matching %q", name, project)
+		}
+		return nil, fmt.Errorf("security group %q not found", name)
+	case 1:
+		return &matches[0], nil
+	default:
+		var found []string
+		for _, m := range matches {
+			found = append(found, fmt.Sprintf("%s (%s)", m.ProjectName, m.SecGrpID))
+		}
+		return nil, fmt.Errorf("%q matches security groups in multiple projects:\n  - %s\nPlease narrow it down with --project-a/--project-b",
+			name, strings.Join(found, "\n  - "))
+	}
+}
+
+// normalizeSecGrpRules converts the cached rules of a security group into comparable rules
+func normalizeSecGrpRules(sg *SecGrpDetail) []secgrp.Rule {
+	rules := make([]secgrp.Rule, 0, len(sg.Rules))
+	for _, r := range sg.Rules {
+		rules = append(rules, secgrp.Normalize(secgrp.RawRule{
+			Direction:       r.Direction,
+			EtherType:       r.EtherType,
+			Protocol:        r.Protocol,
+			PortRangeMin:    r.PortRangeMin,
+			PortRangeMax:    r.PortRangeMax,
+			RemoteIPPrefix:  r.RemoteIPPrefix,
+			RemoteGroupID:   r.RemoteGroupID,
+			RemoteGroupName: r.RemoteGroupName,
+		}, sg.SecGrpID))
+	}
+	return rules
+}
@@ -17,15 +17,65 @@ The drift command expects a directory structure like:
 
     <path>/
       project1/
-        state/           # Terraform state JSON files (terraform show -json)
+        state/           # Terraform state: terraform show -json output (*.json),
+                         # raw state files or terraform state pull output (*.tfstate)
         truth/           # osc list output files
           servers.json   # Output of: osc list servers -p project1 -r -o json
-          secgrps.json   # Output of: osc list secgrps -p project1 -r -o json
+          secgrps.json   # Output of: osc list secgrps -p project1 -r -f -o json
+          volumes.json   # Written by: osc drift generate
       project2/
         state/
         truth/
       ...
 
+Servers are compared on name, security groups, flavor, image, metadata, power
+state and floating IP; set drift.server_fields in the config file to limit which
+of these count as drift. Security groups attached through ports
+(openstack_networking_port_v2, openstack_networking_port_secgroup_associate_v2)
+and floating IPs from openstack_networking_floatingip_associate_v2 are resolved
+onto the server the port is bound to when both are in the same state. Security
+groups the state only references by ID are matched by name through the truth.
+The floating IP is only compared with truth from the cache. Security group rules
+are matched by ID and their direction, ethertype, protocol, port range, remote
+IP and remote group are compared. Ethertype and remote group are only compared
+when the truth file was produced with -f (full rule details).
+
+Raw state files use state format version 4 (Terraform 0.12 and later). A
+*.tfstate.backup file is only read when the matching *.tfstate file is missing.
+
+Instead of, or as well as, a state/ directory a project can contain a state.yaml
+that points at remote state in a Terraform HTTP backend (e.g. GitLab). The state
+is read with a plain GET and is not locked. An empty response is an empty state,
+while a 404 fails the project. Values can reference environment variables so
+credentials stay out of the file:
+
+    backend: http
+    address: https://gitlab.example.com/api/v4/projects/42/terraform/state/prod
+    username: ci
+    password: ${TF_HTTP_PASSWORD}     # sent as basic auth with username
+    headers:
+      PRIVATE-TOKEN: ${GITLAB_TOKEN}
+    timeout: 30s
+
+By default the project directory name is a substring filter on OpenStack project
+names, so prod also matches preprod. An osc-project.yaml in the project directory
+maps it to exactly one project and can list several state directories, e.g. one
+per Terraform workspace:
+
+    project_id: 0a1b2c3d4e5f       # or project_name: prod (default: directory name)
+    cloud: east                    # checked against OS_CLOUD when set
+    region: RegionOne              # checked against OS_REGION_NAME when set
+    state_dirs: [state/blue, state/green]   # default: state
+
+Several directories can be mapped at once in osc-projects.yaml in the base path;
+a directory's own osc-project.yaml takes precedence:
+
+    projects:
+      web-prod:
+        project_name: prod
+      web-preprod:
+        project_name: preprod
+
 Use subcommands to check for drift or generate truth files.`,
 }
 
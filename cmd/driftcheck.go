@@ -4,9 +4,15 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/db"
 	"github.com/marcdicarlo/osc/internal/drift"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +24,20 @@ var (
 	driftResourceFilter string
 	// driftStatusFilter filters by drift status
 	driftStatusFilter string
+	// driftFromCache builds truth from the cache database instead of truth files
+	driftFromCache bool
+	// driftMatchByName pairs resources recreated with a new ID by name
+	driftMatchByName bool
+	// driftGroupBy groups table output
+	driftGroupBy string
+	// driftBaseline is a saved run ID or "latest" to compare against
+	driftBaseline string
+	// driftNoSave skips saving the run to the drift history
+	driftNoSave bool
+	// driftWorkers is the number of projects processed concurrently
+	driftWorkers int
+	// driftPlanFile is the plan JSON file name read from each project directory
+	driftPlanFile string
 )
 
 // driftCheckCmd represents the drift check command
@@ -30,9 +50,77 @@ This command scans all project directories in the specified path and compares
 the Terraform state (from state/ subdirectory) with the OpenStack truth
 (from truth/ subdirectory).
 
+Expected drift can be suppressed with a .oscdriftignore file in the base path
+(applies to all projects) or in a project directory. Ignored drift is counted
+in the summary but does not cause a non-zero exit code:
+
+    ignore:
+      - type: security-group-rule
+        status: missing_in_state
+        name: default            # glob; rules match on their security group
+        reason: Neutron default egress rules
+      - type: server
+        field: metadata.agent_*  # glob on changed fields
+      - type: server
+        name: autoscale-*
+
+Besides table, json and csv, -o accepts junit (a test case per resource,
+failing on drift), sarif (a result per drift item), markdown (a collapsible
+summary per project for pull request comments), yaml (the json report as YAML)
+and ndjson (a JSON object per drift item and line, for log pipelines). name, id
+and template=... write the resource name, resource ID or template output of
+each drift item, with the fields of its ndjson line; jsonpath=... runs on the
+json report.
+
+With --match-by-name, a resource that is missing from truth and one that is
+missing from state are reported as a single recreated drift when they share a
+type, project, name and parent security group, e.g. a server that Terraform
+replaced while the state file is stale. Names that are not unique on both sides
+are left unpaired. Set drift.match_by_name in the config file to always do this.
+
+When a config file is found, every run is saved to the drift history in the
+cache database (skip with --no-save). If the database cannot be opened or the
+run cannot be saved, a warning is printed and the check continues. --baseline
+compares against a saved run, either a run ID or latest (the last run for the
+same --path), and reports only drift that is new since that run plus drift
+that has been resolved; a further change to a resource that had drifted is new
+drift. The exit code is 1 only for new drift. See osc drift history for counts
+over time.
+
+Projects are processed concurrently, up to openstack.max_workers from the config
+file or --workers at a time. Projects and state or truth files that cannot be
+loaded are listed in the errors section of the report and do not stop the check,
+but the exit code is 2, since drift in them is unknown.
+
+With --plan, each project directory's plan file (terraform show -json of a
+saved plan) is compared with the truth instead of its state, predicting the drift
+that remains after the plan is applied; projects without the file are skipped,
+and the check fails when no project has it.
+Drift on resources the plan creates, updates or deletes is expected to converge
+and is not reported. Planned changes to resources that have drifted, judged from
+the plan's prior state, are listed separately. Predicted runs are not saved to
+the drift history.
+
+    terraform plan -out plan.out && terraform show -json plan.out > plan.json
+
+With --from-cache the truth is read directly from the osc cache database for
+the project an osc-project.yaml or osc-projects.yaml maps the directory to;
+without a mapping the directory name is a substring filter on project names.
+Only the state/ subdirectory is required and osc drift generate does not need
+to be run first.
+
 Example:
     osc drift check --path ./tmp
     osc drift check --path ./tmp -o json
+    osc drift check --path ./tmp --from-cache
+    osc drift check --path ./tmp --match-by-name
+    osc drift check --path ./tmp --group-by module
+    osc drift check --path ./tmp --baseline latest
+    osc drift check --path ./tmp --from-cache --plan plan.json
+    osc drift check --path ./tmp --baseline 42 --no-save
+    osc drift check --path ./tmp -o junit > drift-junit.xml
+    osc drift check --path ./tmp -o sarif > drift.sarif
+    osc drift check --path ./tmp -o markdown > drift.md
     osc drift check --path ./tmp --resource servers
     osc drift check --path ./tmp --status missing_in_truth`,
 	RunE: runDriftCheck,
@@ -44,38 +132,143 @@ func init() {
 	driftCheckCmd.Flags().StringVarP(&driftCheckPath, "path", "p", "", "Path to directory containing project folders (required)")
 	driftCheckCmd.MarkFlagRequired("path")
 
-	driftCheckCmd.Flags().StringVarP(&driftResourceFilter, "resource", "r", "all", "Filter by resource type: servers, secgrps, rules, all")
-	driftCheckCmd.Flags().StringVarP(&driftStatusFilter, "status", "s", "all", "Filter by status: missing_in_truth, missing_in_state, name_changed, all")
+	driftCheckCmd.Flags().StringVarP(&driftResourceFilter, "resource", "r", "all", "Filter by resource type: servers, secgrps, rules, volumes, all")
+	driftCheckCmd.Flags().BoolVar(&driftFromCache, "from-cache", false, "Read truth from the osc cache database instead of truth/ files")
+	driftCheckCmd.Flags().StringVar(&driftBaseline, "baseline", "", "Report only drift that is new or resolved since a saved run (run ID or latest)")
+	driftCheckCmd.Flags().StringVar(&driftPlanFile, "plan", "", "Predict drift after apply from this terraform show -json plan file in each project directory")
+	driftCheckCmd.Flags().IntVar(&driftWorkers, "workers", 0, "Number of projects processed concurrently (default: openstack.max_workers from config, or 10)")
+	driftCheckCmd.Flags().BoolVar(&driftNoSave, "no-save", false, "Do not save this run to the drift history")
+	driftCheckCmd.Flags().StringVar(&driftGroupBy, "group-by", "", "Group table output by: module")
+	driftCheckCmd.Flags().BoolVar(&driftMatchByName, "match-by-name", false, "Report resources recreated with a new ID as recreated instead of missing")
+	driftCheckCmd.Flags().StringVarP(&driftStatusFilter, "status", "s", "all", "Filter by status: missing_in_truth, missing_in_state, name_changed, secgroups_changed, rule_changed, size_changed, volume_type_changed, attachment_changed, flavor_changed, image_changed, metadata_changed, power_state_changed, floating_ip_changed, recreated, all")
 }
 
 func runDriftCheck(cmd *cobra.Command, args []string) error {
+	if driftGroupBy != "" && driftGroupBy != drift.GroupByModule {
+		return fmt.Errorf("invalid --group-by %q (valid: %s)", driftGroupBy, drift.GroupByModule)
+	}
+
+	// Check the output format before any project is loaded
+	formatter, err := drift.NewDriftFormatter(os.Stdout, outputFormat)
+	if err != nil {
+		return err
+	}
+	formatter.GroupBy = driftGroupBy
+
+	opts := drift.DefaultOptions()
+	start := time.Now()
+
+	// The config file is optional unless truth or history is read from the
+	// cache, but one that exists must load
+	cfg, err := config.Load("config.yaml")
+	if err != nil && (driftFromCache || driftBaseline != "" || !errors.Is(err, fs.ErrNotExist)) {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg != nil {
+		opts.Compare, err = drift.NewCompareOptions(cfg.Drift.ServerFields)
+		if err != nil {
+			return fmt.Errorf("invalid drift.server_fields in config: %w", err)
+		}
+		opts.Compare.MatchByName = cfg.Drift.MatchByName
+	}
+	if driftMatchByName {
+		opts.Compare.MatchByName = true
+	}
+
+	opts.Workers = 10
+	if cfg != nil {
+		opts.Workers = cfg.OpenStack.MaxWorkers
+	}
+	if driftWorkers > 0 {
+		opts.Workers = driftWorkers
+	}
+
+	// The plan file is looked up in each project directory
+	if filepath.IsAbs(driftPlanFile) {
+		return fmt.Errorf("invalid --plan %q: must be a file name relative to each project directory", driftPlanFile)
+	}
+	opts.PlanFile = driftPlanFile
+
+	// The database is required for --from-cache and --baseline; saving the run
+	// alone is skipped with a warning when the database cannot be opened
+	var history *drift.HistoryStore
+	requireDB := driftFromCache || driftBaseline != ""
+	if cfg != nil && (requireDB || !driftNoSave) {
+		database, err := db.InitDB(cfg)
+		switch {
+		case err != nil && requireDB:
+			return fmt.Errorf("failed to init db: %w", err)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Warning: drift run not saved: failed to init db: %v\n", err)
+		default:
+			defer database.Close()
+			if driftFromCache {
+				opts.LoadTruth = drift.NewCacheTruthLoader(database, cfg).Load
+			}
+			history = drift.NewHistoryStore(database, cfg)
+		}
+	}
+
+	absPath, err := filepath.Abs(driftCheckPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	// Resolve the baseline before this run is saved so latest means the previous run
+	var baselineRunID int64
+	if driftBaseline != "" {
+		baselineRunID, err = history.ResolveRunID(driftBaseline, absPath)
+		if err != nil {
+			return fmt.Errorf("invalid --baseline: %w", err)
+		}
+	}
+
 	// Process all projects
-	report, err := drift.ProcessAllProjects(driftCheckPath)
+	report, err := drift.ProcessAllProjectsWithOptions(driftCheckPath, opts)
 	if err != nil {
 		return fmt.Errorf("failed to process projects: %w", err)
 	}
 
+	// Predicted drift is not saved to the history
+	if history != nil && !driftNoSave && driftPlanFile == "" {
+		if runID, err := history.SaveRun(absPath, start, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: drift run not saved: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Saved drift run %d\n", runID)
+		}
+	}
+
+	if driftBaseline != "" {
+		items, err := history.LoadRunItems(baselineRunID)
+		if err != nil {
+			return fmt.Errorf("failed to load baseline: %w", err)
+		}
+		report = drift.ApplyBaseline(report, baselineRunID, items)
+	}
+
 	// Apply filters
 	report = filterReport(report, driftResourceFilter, driftStatusFilter)
 
 	// Format and output
-	formatter := drift.NewDriftFormatter(os.Stdout, outputFormat)
-
 	if !report.HasDrift() {
-		formatter.PrintNoDrift(report.Summary.TotalProjects)
-		return nil
+		err = formatter.PrintNoDrift(report)
+	} else {
+		err = formatter.FormatReport(report)
 	}
-
-	if err := formatter.FormatReport(report); err != nil {
+	if err != nil {
 		return fmt.Errorf("failed to format output: %w", err)
 	}
 
-	// Exit with code 1 if drift was detected
-	os.Exit(1)
+	// Exit with code 1 if (new) drift was detected, 2 if anything failed to load
+	if code := report.ExitCode(); code != drift.ExitNoDrift {
+		os.Exit(code)
+	}
 	return nil
 }
 
-// filterReport filters the drift report by resource type and status
+// filterReport filters the drift report by resource type and status. Every
+// project is kept, with its checked resources filtered by resource type, so
+// formats reporting passing checks still list them.
 func filterReport(report *drift.DriftReport, resourceFilter, statusFilter string) *drift.DriftReport {
 	if resourceFilter == "all" && statusFilter == "all" {
 		return report
@@ -84,57 +277,98 @@ func filterReport(report *drift.DriftReport, resourceFilter, statusFilter string
 	filtered := drift.NewDriftReport()
 
 	for _, project := range report.Projects {
-		var filteredDrifts []drift.DiffResult
+		p := project
+		p.Drifts, p.Resolved, p.Checked = nil, nil, nil
+		// Load errors are added to the report below
+		p.Errors = nil
 
 		for _, d := range project.Drifts {
-			// Apply resource filter
-			if resourceFilter != "all" {
-				match := false
-				switch resourceFilter {
-				case "servers":
-					match = d.ResourceType == drift.ResourceTypeServer
-				case "secgrps":
-					match = d.ResourceType == drift.ResourceTypeSecurityGroup
-				case "rules":
-					match = d.ResourceType == drift.ResourceTypeSecurityGroupRule
-				}
-				if !match {
-					continue
-				}
+			if matchesDriftFilter(d, resourceFilter, statusFilter) {
+				p.Drifts = append(p.Drifts, d)
 			}
-
-			// Apply status filter
-			if statusFilter != "all" {
-				match := false
-				switch statusFilter {
-				case "missing_in_truth":
-					match = d.Status == drift.StatusMissingInTruth
-				case "missing_in_state":
-					match = d.Status == drift.StatusMissingInState
-				case "name_changed":
-					match = d.Status == drift.StatusNameChanged
-				case "secgroups_changed":
-					match = d.Status == drift.StatusSecGroupChanged
-				case "rule_changed":
-					match = d.Status == drift.StatusRuleChanged
-				}
-				if !match {
-					continue
-				}
+		}
+		for _, d := range project.Resolved {
+			if matchesDriftFilter(d, resourceFilter, statusFilter) {
+				p.Resolved = append(p.Resolved, d)
 			}
-
-			filteredDrifts = append(filteredDrifts, d)
 		}
-
-		if len(filteredDrifts) > 0 {
-			filtered.AddProject(drift.ProjectDrift{
-				ProjectName: project.ProjectName,
-				Drifts:      filteredDrifts,
-				StateCount:  project.StateCount,
-				TruthCount:  project.TruthCount,
-			})
+		for _, c := range project.Checked {
+			if matchesResourceFilter(c.Type, resourceFilter) {
+				p.Checked = append(p.Checked, c)
+			}
 		}
+		filtered.AddProject(p)
 	}
 
+	// Load errors are reported regardless of filters
+	filtered.Errors = report.Errors
+	filtered.Summary.BaselineRunID = report.Summary.BaselineRunID
+	filtered.Summary.PlanFile = report.Summary.PlanFile
+
 	return filtered
 }
+
+// matchesResourceFilter reports whether a resource type matches the resource filter
+func matchesResourceFilter(resourceType drift.ResourceType, resourceFilter string) bool {
+	switch resourceFilter {
+	case "all":
+		return true
+	case "servers":
+		return resourceType == drift.ResourceTypeServer
+	case "secgrps":
+		return resourceType == drift.ResourceTypeSecurityGroup
+	case "rules":
+		return resourceType == drift.ResourceTypeSecurityGroupRule
+	case "volumes":
+		return resourceType == drift.ResourceTypeVolume || resourceType == drift.ResourceTypeVolumeAttachment
+	}
+	return false
+}
+
+// matchesDriftFilter reports whether a drift item matches the resource type and
+// status filters. A status matches the item's status or any of its field changes.
+func matchesDriftFilter(d drift.DiffResult, resourceFilter, statusFilter string) bool {
+	if !matchesResourceFilter(d.ResourceType, resourceFilter) {
+		return false
+	}
+
+	// Apply status filter
+	if statusFilter != "all" {
+		match := false
+		switch statusFilter {
+		case "missing_in_truth":
+			match = d.HasStatus(drift.StatusMissingInTruth)
+		case "missing_in_state":
+			match = d.HasStatus(drift.StatusMissingInState)
+		case "name_changed":
+			match = d.HasStatus(drift.StatusNameChanged)
+		case "secgroups_changed":
+			match = d.HasStatus(drift.StatusSecGroupChanged)
+		case "rule_changed":
+			match = d.HasStatus(drift.StatusRuleChanged)
+		case "size_changed":
+			match = d.HasStatus(drift.StatusSizeChanged)
+		case "volume_type_changed":
+			match = d.HasStatus(drift.StatusVolumeTypeChanged)
+		case "attachment_changed":
+			match = d.HasStatus(drift.StatusAttachmentChanged)
+		case "flavor_changed":
+			match = d.HasStatus(drift.StatusFlavorChanged)
+		case "image_changed":
+			match = d.HasStatus(drift.StatusImageChanged)
+		case "metadata_changed":
+			match = d.HasStatus(drift.StatusMetadataChanged)
+		case "power_state_changed":
+			match = d.HasStatus(drift.StatusPowerStateChanged)
+		case "floating_ip_changed":
+			match = d.HasStatus(drift.StatusFloatingIPChanged)
+		case "recreated":
+			match = d.HasStatus(drift.StatusRecreated)
+		}
+		if !match {
+			return false
+		}
+	}
+
+	return true
+}
@@ -7,14 +7,17 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/marcdicarlo/osc/internal/config"
 	"github.com/marcdicarlo/osc/internal/db"
 	"github.com/marcdicarlo/osc/internal/drift"
-	"github.com/marcdicarlo/osc/internal/filter"
 	"github.com/marcdicarlo/osc/internal/output"
 	"github.com/spf13/cobra"
 )
@@ -28,19 +31,24 @@ var (
 var driftGenerateCmd = &cobra.Command{
 	Use:   "generate",
 	Short: "Generate truth files from osc cache",
-	Long: `Generate truth files (servers.json, secgrps.json) for each project directory.
+	Long: `Generate truth files (servers.json, secgrps.json, volumes.json) for each project directory.
 
 This command scans all project directories in the specified path and creates
 truth files based on the osc cached data, using the folder name as the project filter.
 
+A folder name such as prod also matches preprod; map the directory to an exact
+project with osc-project.yaml (see osc drift --help) to avoid this.
+
 Example:
     osc drift generate --path ./tmp
 
 This will create:
     ./tmp/project1/truth/servers.json
     ./tmp/project1/truth/secgrps.json
+    ./tmp/project1/truth/volumes.json
     ./tmp/project2/truth/servers.json
     ./tmp/project2/truth/secgrps.json
+    ./tmp/project2/truth/volumes.json
     ...`,
 	RunE: runDriftGenerate,
 }
@@ -67,34 +75,37 @@ func runDriftGenerate(cmd *cobra.Command, args []string) error {
 
 	// Discover project directories
 	projects, err := drift.DiscoverProjects(driftGeneratePath)
-	if err != nil {
+	if errors.Is(err, drift.ErrNoProjects) {
 		// If no projects found, try to use direct subdirectories
 		entries, readErr := os.ReadDir(driftGeneratePath)
 		if readErr != nil {
-			return fmt.Errorf("failed to read path: %w", err)
+			return fmt.Errorf("failed to read path: %w", readErr)
 		}
 
 		for _, entry := range entries {
 			if entry.IsDir() {
-				projectPath := filepath.Join(driftGeneratePath, entry.Name())
-				projects = append(projects, drift.ProjectDir{
-					Name:      entry.Name(),
-					BasePath:  projectPath,
-					StatePath: filepath.Join(projectPath, "state"),
-					TruthPath: filepath.Join(projectPath, "truth"),
-				})
+				projects = append(projects, drift.NewProjectDir(driftGeneratePath, entry.Name(), nil))
 			}
 		}
 
 		if len(projects) == 0 {
 			return fmt.Errorf("no project directories found in %s", driftGeneratePath)
 		}
+	} else if err != nil {
+		return err
 	}
 
 	successCount := 0
 	for _, project := range projects {
 		fmt.Printf("Generating truth files for project: %s\n", project.Name)
 
+		if project.Config != nil {
+			if err := project.Config.CheckCloud(); err != nil {
+				fmt.Printf("  Warning: skipped: %v\n", err)
+				continue
+			}
+		}
+
 		// Ensure truth directory exists
 		if err := drift.EnsureProjectDirs(project.BasePath); err != nil {
 			fmt.Printf("  Warning: failed to create directories: %v\n", err)
@@ -103,7 +114,7 @@ func runDriftGenerate(cmd *cobra.Command, args []string) error {
 
 		// Generate servers.json
 		serversPath := filepath.Join(project.TruthPath, "servers.json")
-		if err := generateServersJSON(database, cfg, project.Name, serversPath); err != nil {
+		if err := generateServersJSON(database, cfg, project, serversPath); err != nil {
 			fmt.Printf("  Warning: failed to generate servers.json: %v\n", err)
 		} else {
 			fmt.Printf("  Created: %s\n", serversPath)
@@ -111,12 +122,20 @@ func runDriftGenerate(cmd *cobra.Command, args []string) error {
 
 		// Generate secgrps.json
 		secgrpsPath := filepath.Join(project.TruthPath, "secgrps.json")
-		if err := generateSecgrpsJSON(database, cfg, project.Name, secgrpsPath); err != nil {
+		if err := generateSecgrpsJSON(database, cfg, project, secgrpsPath); err != nil {
 			fmt.Printf("  Warning: failed to generate secgrps.json: %v\n", err)
 		} else {
 			fmt.Printf("  Created: %s\n", secgrpsPath)
 		}
 
+		// Generate volumes.json
+		volumesPath := filepath.Join(project.TruthPath, "volumes.json")
+		if err := generateVolumesJSON(database, cfg, project, volumesPath); err != nil {
+			fmt.Printf("  Warning: failed to generate volumes.json: %v\n", err)
+		} else {
+			fmt.Printf("  Created: %s\n", volumesPath)
+		}
+
 		successCount++
 	}
 
@@ -125,13 +144,17 @@ func runDriftGenerate(cmd *cobra.Command, args []string) error {
 }
 
 // generateServersJSON generates the servers.json file for a project
-func generateServersJSON(database *sql.DB, cfg *config.Config, projectName, outputPath string) error {
+func generateServersJSON(database *sql.DB, cfg *config.Config, project drift.ProjectDir, outputPath string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
 	defer cancel()
 
-	// Query with security groups (always include for drift detection)
+	// Query with the server details and security groups (always include for
+	// drift detection), each group as "<id>\x1f<name>"
 	query := `SELECT s.server_name, s.server_id, s.project_id, p.project_name, COALESCE(s.ipv4_addr, ''),
-	         COALESCE(GROUP_CONCAT(sg.secgrp_name, ', '), ''), 'server' as resource_type
+	         COALESCE(s.status, ''), COALESCE(s.flavor_id, ''), COALESCE(s.flavor_name, ''),
+	         COALESCE(s.image_id, ''), COALESCE(s.image_name, ''), COALESCE(s.metadata, ''),
+	         COALESCE(s.floating_ip, ''),
+	         COALESCE(GROUP_CONCAT(sg.secgrp_id || char(31) || sg.secgrp_name, char(30)), '')
 	FROM ` + cfg.Tables.Servers + ` s
 	JOIN ` + cfg.Tables.Projects + ` p USING (project_id)
 	LEFT JOIN ` + cfg.Tables.ServerSecGrps + ` ssg ON s.server_id = ssg.server_id
@@ -145,68 +168,100 @@ func generateServersJSON(database *sql.DB, cfg *config.Config, projectName, outp
 	}
 	defer rows.Close()
 
-	var data [][]string
+	// Keep only this project
+	var records []output.Record
+	matchedProjects := make(map[string]bool)
 	for rows.Next() {
-		var name, id, projectID, pname, ipv4, secgrps, resourceType string
-		if err := rows.Scan(&name, &id, &projectID, &pname, &ipv4, &secgrps, &resourceType); err != nil {
+		var name, id, projectID, pname, ipv4, status, flavorID, flavorName, imageID, imageName, metadataJSON, floatingIP, secgrps string
+		if err := rows.Scan(&name, &id, &projectID, &pname, &ipv4, &status, &flavorID, &flavorName,
+			&imageID, &imageName, &metadataJSON, &floatingIP, &secgrps); err != nil {
 			return err
 		}
-		data = append(data, []string{name, id, projectID, pname, ipv4, secgrps, resourceType})
-	}
-
-	if err := rows.Err(); err != nil {
-		return err
-	}
+		if !project.MatchesProject(projectID, pname, cfg) {
+			continue
+		}
+		matchedProjects[pname] = true
 
-	// Filter to only this project
-	pf := filter.New(projectName, cfg)
-	filteredData, matchedProjectsMap := pf.MatchProjects(data, 3) // project_name is at index 3
+		metadata := make(map[string]string)
+		if metadataJSON != "" {
+			if err := json.Unmarshal([]byte(metadataJSON), &metadata); err != nil {
+				return fmt.Errorf("invalid metadata for server %s: %w", id, err)
+			}
+		}
 
-	// If no data matches this project, create empty output
-	if len(filteredData) == 0 {
-		return writeEmptyJSON(outputPath, []string{"name", "id", "project_id", "project_name", "ip_address", "security_groups", "type"})
+		// Security groups with their IDs, as written by osc list servers -f
+		groups := []output.Record{}
+		if secgrps != "" {
+			for _, group := range strings.Split(secgrps, "\x1e") {
+				sgID, sgName, _ := strings.Cut(group, "\x1f")
+				groups = append(groups, output.Record{Fields: []output.Field{
+					{Key: "id", Value: sgID},
+					{Key: "name", Value: sgName},
+				}})
+			}
+		}
+		records = append(records, output.NewRecord("server",
+			output.Field{Key: "name", Value: name},
+			output.Field{Key: "id", Value: id},
+			output.Field{Key: "project_id", Value: projectID},
+			output.Field{Key: "project_name", Value: pname},
+			output.Field{Key: "ip_address", Value: ipv4},
+			output.Field{Key: "status", Value: status},
+			output.Field{Key: "flavor_id", Value: flavorID},
+			output.Field{Key: "flavor_name", Value: flavorName},
+			output.Field{Key: "image_id", Value: imageID},
+			output.Field{Key: "image_name", Value: imageName},
+			output.Field{Key: "metadata", Value: metadata},
+			output.Field{Key: "floating_ip", Value: floatingIP},
+			output.Field{Key: "security_groups", Value: groups},
+		))
 	}
 
-	// Write to file
-	var buf bytes.Buffer
-	formatter, err := output.NewFormatter("json", &buf)
-	if err != nil {
+	if err := rows.Err(); err != nil {
 		return err
 	}
 
-	headers := []string{"name", "id", "project_id", "project_name", "ip_address", "security_groups", "type"}
-	outputData := output.NewOutputData(headers, filteredData)
-
-	var matchedProjects []string
-	for project := range matchedProjectsMap {
-		matchedProjects = append(matchedProjects, project)
+	columns := []output.Column{
+		{Header: "Name", Key: "name"},
+		{Header: "ID", Key: "id"},
+		{Header: "Project ID", Key: "project_id"},
+		{Header: "Project Name", Key: "project_name"},
+		{Header: "IPv4 Address", Key: "ip_address"},
+		{Header: "Status", Key: "status"},
+		{Header: "Flavor ID", Key: "flavor_id"},
+		{Header: "Flavor", Key: "flavor_name"},
+		{Header: "Image ID", Key: "image_id"},
+		{Header: "Image", Key: "image_name"},
+		{Header: "Metadata", Key: "metadata"},
+		{Header: "Floating IP", Key: "floating_ip"},
+		{Header: "Security Groups", Key: "security_groups"},
 	}
-	outputData.WithFilterInfo(matchedProjects)
-
-	if err := formatter.Format(outputData); err != nil {
-		return err
-	}
-
-	return os.WriteFile(outputPath, buf.Bytes(), 0644)
+	return writeTruthJSON(outputPath, columns, records, matchedProjects)
 }
 
 // generateSecgrpsJSON generates the secgrps.json file for a project
-func generateSecgrpsJSON(database *sql.DB, cfg *config.Config, projectName, outputPath string) error {
+func generateSecgrpsJSON(database *sql.DB, cfg *config.Config, project drift.ProjectDir, outputPath string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
 	defer cancel()
 
-	// Query security groups with rules
-	// Note: parent_id is empty for security groups, secgrp_id for rules
+	// Query security groups with rules, with the rule details of osc list
+	// secgrps -r -f so that rule properties can be compared. Rules are nested
+	// in their group by parent_id, as osc list secgrps -r -f -o json does.
 	query := `SELECT
-		sg.secgrp_name, sg.secgrp_id, sg.project_id, p.project_name, 'security-group' as resource_type, '' as parent_id
+		sg.secgrp_name, sg.secgrp_id, sg.project_id, p.project_name, 'security-group' as resource_type, '' as parent_id,
+		NULL as direction, NULL as ethertype, NULL as protocol, NULL as port_range_min, NULL as port_range_max,
+		NULL as remote_ip_prefix, NULL as remote_group_id, NULL as remote_group_name
 	FROM ` + cfg.Tables.SecGrps + ` sg
 	JOIN ` + cfg.Tables.Projects + ` p ON sg.project_id = p.project_id
 	UNION ALL
 	SELECT
-		r.rule_id, r.rule_id, sg.project_id, p.project_name, 'security-group-rule' as resource_type, sg.secgrp_id as parent_id
+		r.rule_id, r.rule_id, sg.project_id, p.project_name, 'security-group-rule' as resource_type, sg.secgrp_id as parent_id,
+		r.direction, r.ethertype, r.protocol, r.port_range_min, r.port_range_max,
+		r.remote_ip_prefix, r.remote_group_id, sg_remote.secgrp_name
 	FROM ` + cfg.Tables.SecGrpRules + ` r
 	JOIN ` + cfg.Tables.SecGrps + ` sg ON r.secgrp_id = sg.secgrp_id
 	JOIN ` + cfg.Tables.Projects + ` p ON sg.project_id = p.project_id
+	LEFT JOIN ` + cfg.Tables.SecGrps + ` sg_remote ON r.remote_group_id = sg_remote.secgrp_id
 	ORDER BY resource_type DESC, 1;`
 
 	rows, err := database.QueryContext(ctx, query)
@@ -215,59 +270,160 @@ func generateSecgrpsJSON(database *sql.DB, cfg *config.Config, projectName, outp
 	}
 	defer rows.Close()
 
-	var data [][]string
+	// Keep only this project
+	var records []output.Record
+	matchedProjects := make(map[string]bool)
 	for rows.Next() {
 		var name, id, projectID, pname, resourceType, parentID string
-		if err := rows.Scan(&name, &id, &projectID, &pname, &resourceType, &parentID); err != nil {
+		var direction, ethertype, protocol, remoteIP, remoteGroupID, remoteGroupName sql.NullString
+		var portMin, portMax sql.NullInt64
+		if err := rows.Scan(&name, &id, &projectID, &pname, &resourceType, &parentID, &direction, &ethertype,
+			&protocol, &portMin, &portMax, &remoteIP, &remoteGroupID, &remoteGroupName); err != nil {
 			return err
 		}
-		data = append(data, []string{name, id, projectID, pname, resourceType, parentID})
+		if !project.MatchesProject(projectID, pname, cfg) {
+			continue
+		}
+		matchedProjects[pname] = true
+
+		record := output.NewRecord(resourceType,
+			output.Field{Key: "name", Value: name},
+			output.Field{Key: "id", Value: id},
+		)
+		if parentID != "" {
+			record.Fields = append(record.Fields, output.Field{Key: "parent_id", Value: parentID})
+		}
+		record.Fields = append(record.Fields,
+			output.Field{Key: "project_id", Value: projectID},
+			output.Field{Key: "project_name", Value: pname},
+		)
+		if resourceType == "security-group-rule" {
+			record.Fields = append(record.Fields,
+				output.Field{Key: "direction", Value: direction.String},
+				output.Field{Key: "ethertype", Value: ethertype.String},
+				output.Field{Key: "protocol", Value: nullStringValue(protocol)},
+				output.Field{Key: "port_range_min", Value: nullIntPtr(portMin)},
+				output.Field{Key: "port_range_max", Value: nullIntPtr(portMax)},
+				output.Field{Key: "remote_ip_prefix", Value: nullStringValue(remoteIP)},
+				output.Field{Key: "remote_group", Value: remoteGroupRecord(remoteGroupID, remoteGroupName)},
+			)
+		}
+		records = append(records, record)
 	}
 
 	if err := rows.Err(); err != nil {
 		return err
 	}
 
-	// Filter to only this project
-	pf := filter.New(projectName, cfg)
-	filteredData, matchedProjectsMap := pf.MatchProjects(data, 3) // project_name is at index 3
-
-	if len(filteredData) == 0 {
-		return writeEmptyJSON(outputPath, []string{"name", "id", "project_id", "project_name", "type", "parent_id"})
+	groupKeys := []string{"name", "id", "project_id", "project_name"}
+	ruleKeys := []string{"name", "id", "direction", "ethertype", "protocol", "port_range_min", "port_range_max",
+		"remote_ip_prefix", "remote_group"}
+	columns := []output.Column{
+		{Header: "Name", Key: "name"},
+		{Header: "ID", Key: "id"},
+		{Header: "Project ID", Key: "project_id"},
+		{Header: "Project Name", Key: "project_name"},
+		{Header: "Rules", Key: "rules"},
 	}
+	return writeTruthJSON(outputPath, columns, nestSecgrpRules(records, groupKeys, ruleKeys), matchedProjects)
+}
 
-	// Write to file
-	var buf bytes.Buffer
-	formatter, err := output.NewFormatter("json", &buf)
+// generateVolumesJSON generates the volumes.json file for a project.
+// Each volume is written as a "volume" record followed by one
+// "volume-attachment" record per server it is attached to, with the ID
+// "<server_id>/<volume_id>".
+func generateVolumesJSON(database *sql.DB, cfg *config.Config, project drift.ProjectDir, outputPath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
+	defer cancel()
+
+	query := `SELECT v.volume_name, v.volume_id, p.project_id, p.project_name, 'volume' as resource_type,
+	         v.size_gb, COALESCE(v.volume_type, ''), '' as server_id, '' as device_path
+	FROM ` + cfg.Tables.Volumes + ` v
+	JOIN ` + cfg.Tables.Projects + ` p ON v.project_id = p.project_id
+	UNION ALL
+	SELECT v.volume_name, sv.server_id || '/' || v.volume_id, p.project_id, p.project_name, 'volume-attachment' as resource_type,
+	         NULL as size_gb, '' as volume_type, sv.server_id, sv.device_path
+	FROM ` + cfg.Tables.ServerVolumes + ` sv
+	JOIN ` + cfg.Tables.Volumes + ` v ON sv.volume_id = v.volume_id
+	JOIN ` + cfg.Tables.Projects + ` p ON v.project_id = p.project_id
+	ORDER BY 1, resource_type;`
+
+	rows, err := database.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	headers := []string{"name", "id", "project_id", "project_name", "type", "parent_id"}
-	outputData := output.NewOutputData(headers, filteredData)
-
-	var matchedProjects []string
-	for project := range matchedProjectsMap {
-		matchedProjects = append(matchedProjects, project)
+	// Keep only this project
+	var records []output.Record
+	matchedProjects := make(map[string]bool)
+	for rows.Next() {
+		var name, id, projectID, pname, resourceType, volumeType, serverID, device string
+		var size sql.NullInt64
+		if err := rows.Scan(&name, &id, &projectID, &pname, &resourceType, &size, &volumeType, &serverID, &device); err != nil {
+			return err
+		}
+		if !project.MatchesProject(projectID, pname, cfg) {
+			continue
+		}
+		matchedProjects[pname] = true
+
+		record := output.NewRecord(resourceType,
+			output.Field{Key: "name", Value: name},
+			output.Field{Key: "id", Value: id},
+			output.Field{Key: "project_id", Value: projectID},
+			output.Field{Key: "project_name", Value: pname},
+		)
+		if resourceType == "volume" {
+			record.Fields = append(record.Fields,
+				output.Field{Key: "size_gb", Value: size.Int64},
+				output.Field{Key: "volume_type", Value: volumeType},
+			)
+		} else {
+			record.Fields = append(record.Fields,
+				output.Field{Key: "server_id", Value: serverID},
+				output.Field{Key: "device", Value: device},
+			)
+		}
+		records = append(records, record)
 	}
-	outputData.WithFilterInfo(matchedProjects)
 
-	if err := formatter.Format(outputData); err != nil {
+	if err := rows.Err(); err != nil {
 		return err
 	}
 
-	return os.WriteFile(outputPath, buf.Bytes(), 0644)
+	columns := []output.Column{
+		{Header: "Name", Key: "name"},
+		{Header: "ID", Key: "id"},
+		{Header: "Project ID", Key: "project_id"},
+		{Header: "Project Name", Key: "project_name"},
+		{Header: "Size GB", Key: "size_gb"},
+		{Header: "Volume Type", Key: "volume_type"},
+		{Header: "Attached Server", Key: "server_id"},
+		{Header: "Device", Key: "device"},
+	}
+	return writeTruthJSON(outputPath, columns, records, matchedProjects)
 }
 
-// writeEmptyJSON writes an empty JSON output file
-func writeEmptyJSON(outputPath string, headers []string) error {
+// writeTruthJSON writes records as osc JSON output. Output with records lists
+// the projects they matched as filtering metadata.
+func writeTruthJSON(outputPath string, columns []output.Column, records []output.Record, matchedProjects map[string]bool) error {
 	var buf bytes.Buffer
 	formatter, err := output.NewFormatter("json", &buf)
 	if err != nil {
 		return err
 	}
 
-	outputData := output.NewOutputData(headers, [][]string{})
+	outputData := output.NewRecordData(columns, records)
+	if len(records) > 0 {
+		var matched []string
+		for project := range matchedProjects {
+			matched = append(matched, project)
+		}
+		sort.Strings(matched)
+		outputData.WithFilterInfo(matched)
+	}
+
 	if err := formatter.Format(outputData); err != nil {
 		return err
 	}
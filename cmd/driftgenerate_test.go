@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"database/sql"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/db"
+	"github.com/marcdicarlo/osc/internal/drift"
+)
+
+// newGenerateTestDB creates a cache database in a temporary directory with
+// the given statements applied
+func newGenerateTestDB(t *testing.T, stmts ...string) (*sql.DB, *config.Config) {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.DBFile = filepath.Join(t.TempDir(), "cache.db")
+	cfg.DBTimeout = 5 * time.Second
+
+	database, err := db.InitDB(cfg)
+	if err != nil {
+		t.Fatalf("Failed to init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, stmt := range stmts {
+		if _, err := database.Exec(stmt); err != nil {
+			t.Fatalf("Failed to insert test data: %v", err)
+		}
+	}
+	return database, cfg
+}
+
+// driftChanges returns the changed fields of each drifted resource, or its
+// status without field changes, by resource ID
+func driftChanges(result *drift.ProjectDrift) map[string][]string {
+	changes := make(map[string][]string)
+	for _, d := range result.Drifts {
+		var fields []string
+		for _, c := range d.Changes {
+			fields = append(fields, c.Field)
+		}
+		if len(fields) == 0 {
+			fields = []string{string(d.Status)}
+		}
+		slices.Sort(fields)
+		changes[d.ResourceID] = fields
+	}
+	return changes
+}
+
+// TestDriftGenerateRoundTrip checks that drift found with truth files written
+// by drift generate is the same as drift found straight from the cache
+func TestDriftGenerateRoundTrip(t *testing.T) {
+	database, cfg := newGenerateTestDB(t,
+		`INSERT INTO os_project_names VALUES ('p1', 'alpha')`,
+		`INSERT INTO os_security_groups VALUES ('sg-1', 'web', 'p1'), ('sg-2', 'lb', 'p1')`,
+		`INSERT INTO os_security_group_rules VALUES
+			('rule-1', 'sg-1', 'ingress', 'IPv4', 'tcp', 22, 22, '0.0.0.0/0', NULL),
+			('rule-2', 'sg-1', 'ingress', 'IPv4', 'tcp', 443, 443, NULL, 'sg-2'),
+			('rule-3', 'sg-1', 'egress', 'IPv6', NULL, NULL, NULL, NULL, NULL)`,
+		`INSERT INTO os_servers VALUES
+			('srv-1', 'app', 'p1', '10.0.0.1', 'SHUTOFF', 'img-1', 'ubuntu', 'fl-2', 'm1.medium', '{"owner":"ops","tier":"web"}', '203.0.113.5'),
+			('srv-2', 'worker', 'p1', '10.0.0.2', 'ACTIVE', 'img-1', 'ubuntu', 'fl-1', 'm1.small', NULL, NULL)`,
+		`INSERT INTO os_server_secgrps VALUES ('srv-1', 'sg-1'), ('srv-2', 'sg-1')`,
+	)
+
+	const state = `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "openstack_compute_instance_v2",
+      "name": "app",
+      "instances": [
+        {"attributes": {"id": "srv-1", "name": "app", "security_groups": ["web"], "flavor_id": "fl-1", "flavor_name": "m1.small",
+          "image_id": "img-1", "image_name": "ubuntu", "metadata": {"owner": "dev", "tier": "web"}, "power_state": "active"}},
+        {"attributes": {"id": "srv-2", "name": "worker", "security_groups": ["web"], "flavor_id": "fl-1", "flavor_name": "m1.small",
+          "image_id": "img-1", "image_name": "ubuntu", "power_state": "active"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "openstack_networking_port_v2",
+      "name": "app",
+      "instances": [
+        {"attributes": {"id": "port-1", "device_id": "srv-1"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "openstack_networking_floatingip_associate_v2",
+      "name": "app",
+      "instances": [
+        {"attributes": {"id": "fip-1", "port_id": "port-1", "floating_ip": "203.0.113.9"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "openstack_networking_secgroup_v2",
+      "name": "web",
+      "instances": [
+        {"attributes": {"id": "sg-1", "name": "web"}},
+        {"attributes": {"id": "sg-2", "name": "lb"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "openstack_networking_secgroup_rule_v2",
+      "name": "rule",
+      "instances": [
+        {"attributes": {"id": "rule-1", "security_group_id": "sg-1", "direction": "ingress", "ethertype": "IPv4",
+          "protocol": "tcp", "port_range_min": 2222, "port_range_max": 2222, "remote_ip_prefix": "10.0.0.0/8"}},
+        {"attributes": {"id": "rule-2", "security_group_id": "sg-1", "direction": "ingress", "ethertype": "IPv4",
+          "protocol": "tcp", "port_range_min": 443, "port_range_max": 443, "remote_group_id": "sg-2"}},
+        {"attributes": {"id": "rule-3", "security_group_id": "sg-1", "direction": "egress", "ethertype": "IPv6"}}
+      ]
+    }
+  ]
+}`
+
+	base := t.TempDir()
+	project := drift.NewProjectDir(base, "alpha", nil)
+	if err := drift.EnsureProjectDirs(project.BasePath); err != nil {
+		t.Fatalf("EnsureProjectDirs() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(project.StatePath, "terraform.tfstate"), []byte(state), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	generators := map[string]func(*sql.DB, *config.Config, drift.ProjectDir, string) error{
+		"servers.json": generateServersJSON,
+		"secgrps.json": generateSecgrpsJSON,
+		"volumes.json": generateVolumesJSON,
+	}
+	for file, generate := range generators {
+		if err := generate(database, cfg, project, filepath.Join(project.TruthPath, file)); err != nil {
+			t.Fatalf("Failed to generate %s: %v", file, err)
+		}
+	}
+
+	fromFiles, err := drift.ProcessProject(project)
+	if err != nil {
+		t.Fatalf("ProcessProject() error = %v", err)
+	}
+	opts := drift.DefaultOptions()
+	opts.LoadTruth = drift.NewCacheTruthLoader(database, cfg).Load
+	fromCache, err := drift.ProcessProjectWithOptions(project, opts)
+	if err != nil {
+		t.Fatalf("ProcessProjectWithOptions() error = %v", err)
+	}
+
+	got := driftChanges(fromFiles)
+	want := map[string][]string{
+		"rule-1": {"port_range", "remote_ip"},
+		"srv-1":  {"flavor", "floating_ip", "metadata.owner", "power_state"},
+	}
+	if !maps.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Drift from generated truth = %v, want %v", got, want)
+	}
+	if cached := driftChanges(fromCache); !maps.EqualFunc(got, cached, slices.Equal) {
+		t.Errorf("Drift from generated truth = %v, from the cache = %v", got, cached)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/db"
+	"github.com/marcdicarlo/osc/internal/drift"
+	"github.com/marcdicarlo/osc/internal/filter"
+	"github.com/marcdicarlo/osc/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// driftHistoryLimit is the number of most recent runs shown
+	driftHistoryLimit int
+)
+
+// driftHistoryCmd represents the drift history command
+var driftHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show drift counts of saved drift check runs",
+	Long: `Show the drift count per project for runs saved by osc drift check,
+oldest first. The Change column is the difference from the previous saved run
+of the same project under the same base path. --limit counts the runs shown,
+after filtering by project.
+
+Example:
+    osc drift history
+    osc drift history -p prod
+    osc drift history -p prod --limit 50 -o csv`,
+	RunE: runDriftHistory,
+}
+
+func init() {
+	driftCmd.AddCommand(driftHistoryCmd)
+
+	driftHistoryCmd.Flags().StringVarP(&projectFilter, "project", "p", "", "Filter by project name (shows projects containing this string)")
+	driftHistoryCmd.Flags().IntVar(&driftHistoryLimit, "limit", 20, "Number of most recent runs of the matching projects to show (0 for all)")
+}
+
+func runDriftHistory(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load("config.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	database, err := db.InitDB(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to init db: %w", err)
+	}
+	defer database.Close()
+
+	// All runs are read so that Change has a previous run to compare with and
+	// --limit counts runs of the matching projects
+	entries, err := drift.NewHistoryStore(database, cfg).LoadHistory(0)
+	if err != nil {
+		return err
+	}
+	pf := filter.New(projectFilter, cfg)
+	filteredRecords, matchedProjectsMap := historyRecords(entries, pf, driftHistoryLimit)
+
+	formatter, err := output.NewFormatter(outputFormat, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	outputData := output.NewRecordData([]output.Column{
+		{Header: "Run ID", Key: "run_id"},
+		{Header: "Started", Key: "started"},
+		{Header: "Base Path", Key: "base_path"},
+		{Header: "Project Name", Key: "project_name"},
+		{Header: "Drift Items", Key: "drift_items"},
+		{Header: "Ignored", Key: "ignored"},
+		{Header: "Change", Key: "change", Format: func(r output.Record) string {
+			if change, ok := r.Get("change").(int); ok {
+				return fmt.Sprintf("%+d", change)
+			}
+			return ""
+		}},
+	}, filteredRecords)
+	// -o name writes the project of each run, -o id the run ID
+	outputData.NameKey, outputData.IDKey = "project_name", "run_id"
+
+	if pf.GetActiveFilter() != "" {
+		var matchedProjects []string
+		for project := range matchedProjectsMap {
+			matchedProjects = append(matchedProjects, project)
+		}
+		outputData.WithFilterInfo(matchedProjects)
+	}
+
+	return formatter.Format(outputData)
+}
+
+// historyRecords returns a record per history entry of the projects pf
+// matches, for the last limit runs when limit is positive, and the matched
+// project names. Change is relative to the previous run of the same project
+// and base path, so it is computed before filtering.
+func historyRecords(entries []drift.HistoryEntry, pf *filter.ProjectFilter, limit int) ([]output.Record, map[string]bool) {
+	previous := make(map[string]int)
+	var records []output.Record
+	for _, e := range entries {
+		key := e.BasePath + "\x00" + e.ProjectName
+		var change any
+		if prev, ok := previous[key]; ok {
+			change = e.DriftCount - prev
+		}
+		previous[key] = e.DriftCount
+
+		records = append(records, output.NewRecord("drift-run",
+			output.Field{Key: "run_id", Value: e.RunID},
+			output.Field{Key: "started", Value: e.StartedAt},
+			output.Field{Key: "base_path", Value: e.BasePath},
+			output.Field{Key: "project_name", Value: e.ProjectName},
+			output.Field{Key: "drift_items", Value: e.DriftCount},
+			output.Field{Key: "ignored", Value: e.Ignored},
+			output.Field{Key: "change", Value: change},
+		))
+	}
+
+	records, _ = filter.MatchItems(pf, records, recordProjectName)
+
+	// Entries are ordered by run, so the last runs are at the end
+	if limit > 0 {
+		runs := 0
+		for i := len(records) - 1; i >= 0; i-- {
+			if i == len(records)-1 || records[i].Get("run_id") != records[i+1].Get("run_id") {
+				runs++
+			}
+			if runs > limit {
+				records = records[i+1:]
+				break
+			}
+		}
+	}
+
+	matchedProjects := make(map[string]bool)
+	for _, r := range records {
+		matchedProjects[recordProjectName(r)] = true
+	}
+	return records, matchedProjects
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/drift"
+	"github.com/marcdicarlo/osc/internal/filter"
+)
+
+func TestHistoryRecords(t *testing.T) {
+	entries := []drift.HistoryEntry{
+		{RunID: 1, BasePath: "/work", ProjectName: "prod", DriftCount: 3},
+		{RunID: 2, BasePath: "/other", ProjectName: "prod", DriftCount: 10},
+		{RunID: 3, BasePath: "/work", ProjectName: "dev", DriftCount: 1},
+		{RunID: 3, BasePath: "/work", ProjectName: "prod", DriftCount: 5},
+		{RunID: 4, BasePath: "/work", ProjectName: "dev", DriftCount: 2},
+		{RunID: 5, BasePath: "/other", ProjectName: "prod", DriftCount: 7},
+	}
+
+	type row struct {
+		runID   int64
+		project string
+		change  any
+	}
+	tests := []struct {
+		name    string
+		project string
+		limit   int
+		want    []row
+	}{
+		{
+			name: "change per base path",
+			want: []row{
+				{1, "prod", nil}, {2, "prod", nil}, {3, "dev", nil}, {3, "prod", 2}, {4, "dev", 1}, {5, "prod", -3},
+			},
+		},
+		{
+			name:  "limit counts runs",
+			limit: 2,
+			want:  []row{{4, "dev", 1}, {5, "prod", -3}},
+		},
+		{
+			name:    "limit after project filter",
+			project: "prod",
+			limit:   2,
+			want:    []row{{3, "prod", 2}, {5, "prod", -3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, matched := historyRecords(entries, filter.New(tt.project, &config.Config{}), tt.limit)
+			if len(records) != len(tt.want) {
+				t.Fatalf("got %d records, want %d", len(records), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := row{records[i].Get("run_id").(int64), records[i].String("project_name"), records[i].Get("change")}
+				if got != want {
+					t.Errorf("record %d = %v, want %v", i, got, want)
+				}
+				if !matched[want.project] {
+					t.Errorf("project %s not in matched projects %v", want.project, matched)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/db"
+	"github.com/marcdicarlo/osc/internal/drift"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// driftImportPath is the path for the import subcommand
+	driftImportPath string
+	// driftImportForce overwrites existing import files
+	driftImportForce bool
+)
+
+// driftImportCmd represents the drift import command
+var driftImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Generate Terraform import blocks for resources missing from state",
+	Long: `Generate Terraform 1.5+ import blocks for servers, security groups and
+security group rules that exist in OpenStack but not in Terraform state.
+
+Truth is read from the osc cache database for the project an osc-project.yaml
+or osc-projects.yaml maps each project directory to; without a mapping the
+directory name is a substring filter on project names. For every
+missing_in_state resource an import block and a skeleton resource block with
+attributes filled from the cache is written to osc_imports.tf in the project
+directory. Rules reference imported security
+groups by address. Drift suppressed by .oscdriftignore is not imported.
+Existing osc_imports.tf files are only overwritten with --force; without it
+nothing is written when any of them exists. Projects that cannot be loaded are
+reported and skipped; the command then fails after writing the other projects.
+
+Review the generated resources, copy them into the Terraform configuration and
+run terraform plan to confirm the import.
+
+Example:
+    osc drift import --path ./tmp
+
+This will create:
+    ./tmp/project1/osc_imports.tf
+    ./tmp/project2/osc_imports.tf
+    ...`,
+	RunE: runDriftImport,
+}
+
+func init() {
+	driftCmd.AddCommand(driftImportCmd)
+
+	driftImportCmd.Flags().StringVarP(&driftImportPath, "path", "p", "", "Path to directory containing project folders (required)")
+	driftImportCmd.MarkFlagRequired("path")
+	driftImportCmd.Flags().BoolVar(&driftImportForce, "force", false, "Overwrite existing osc_imports.tf files")
+}
+
+func runDriftImport(cmd *cobra.Command, args []string) error {
+	// Load config and initialize database
+	cfg, err := config.Load("config.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	database, err := db.InitDB(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to init db: %w", err)
+	}
+	defer database.Close()
+
+	opts := drift.DefaultOptions()
+	opts.LoadTruth = drift.NewCacheTruthLoader(database, cfg).Load
+	opts.Compare, err = drift.NewCompareOptions(cfg.Drift.ServerFields)
+	if err != nil {
+		return fmt.Errorf("invalid drift.server_fields in config: %w", err)
+	}
+	opts.Compare.MatchByName = cfg.Drift.MatchByName
+
+	imports, failed, err := drift.ImportAllProjects(driftImportPath, opts)
+	if err != nil {
+		return fmt.Errorf("failed to process projects: %w", err)
+	}
+
+	// Check every file before writing any, so a refusal leaves no partial output
+	if !driftImportForce {
+		var paths []string
+		for _, projectImports := range imports {
+			if projectImports.Count > 0 {
+				paths = append(paths, filepath.Join(projectImports.Project.BasePath, drift.ImportFileName))
+			}
+		}
+		if err := refuseOverwrite(paths...); err != nil {
+			return err
+		}
+	}
+
+	total := 0
+	for _, projectImports := range imports {
+		for _, e := range projectImports.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: failed to parse %s: %s\n", e.File, e.Error)
+		}
+		if projectImports.Count == 0 {
+			fmt.Printf("No resources to import for project: %s\n", projectImports.Project.Name)
+			continue
+		}
+
+		importPath := filepath.Join(projectImports.Project.BasePath, drift.ImportFileName)
+		if err := writeImportFile(importPath, projectImports); err != nil {
+			return err
+		}
+		fmt.Printf("Created: %s (%d resources)\n", importPath, projectImports.Count)
+		total += projectImports.Count
+	}
+
+	fmt.Printf("\nGenerated import blocks for %d resources across %d projects\n", total, len(imports))
+
+	// Projects that failed to load are reported after the others are written
+	for _, e := range failed {
+		fmt.Fprintf(os.Stderr, "Error: failed to process project %s: %s\n", e.Project, e.Error)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to process %d of %d projects", len(failed), len(imports)+len(failed))
+	}
+	return nil
+}
+
+// refuseOverwrite returns an error for the first path that already exists
+func refuseOverwrite(paths ...string) error {
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		if err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to check %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// writeImportFile writes a project's import configuration to path
+func writeImportFile(path string, projectImports drift.ProjectImports) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := projectImports.File.WriteTo(f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export cached resources in other formats",
+	Long: `Export cached OpenStack resources in formats used by other tools.
+
+Available exports:
+    terraform secgrps  Security groups and rules as Terraform HCL
+
+Examples:
+
+# write a project's security groups as Terraform to stdout
+osc export terraform secgrps -p myproject`,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Fatal("Export must be called with a subcommand")
+	},
+}
+
+// exportTerraformCmd represents the export terraform command
+var exportTerraformCmd = &cobra.Command{
+	Use:   "terraform",
+	Short: "Export cached resources as Terraform HCL",
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Fatal("Export terraform must be called with a subcommand")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportTerraformCmd)
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/db"
+	"github.com/marcdicarlo/osc/internal/filter"
+	"github.com/marcdicarlo/osc/internal/tfgen"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// exportPath is the directory Terraform files are written to
+	exportPath string
+	// exportModule places the resources in a child module with this name
+	exportModule string
+	// exportImports adds import blocks for the exported resources
+	exportImports bool
+	// exportForce overwrites existing Terraform files
+	exportForce bool
+)
+
+var exportSecgrpsCmd = &cobra.Command{
+	Use:   "secgrps",
+	Short: "Export security groups and rules as Terraform HCL",
+	Long: `Export the security groups and rules of a project from the cache as
+openstack_networking_secgroup_v2 and openstack_networking_secgroup_rule_v2
+resources.
+
+Resource names are derived from the security group name and the rule's
+direction, protocol and ports, so repeated exports produce the same output.
+Rules reference their security group, and remote groups that are part of the
+export, by resource address. Import blocks are included unless --imports=false.
+
+Without --path the HCL is written to stdout. With --path it is written to
+secgrps.tf in that directory. With --module the resources are written to
+modules/<name>/main.tf and a main.tf calling the module, with the import
+blocks addressed into the module, is written to the path. Existing files are
+only overwritten with --force; without it nothing is written when any of
+them exists.
+
+Examples:
+
+# print a project's security groups as Terraform
+osc export terraform secgrps -p myproject
+
+# write secgrps.tf without import blocks
+osc export terraform secgrps -p myproject --path ./tf --imports=false
+
+# write a secgrps module and a root main.tf that imports into it
+osc export terraform secgrps -p myproject --path ./tf --module secgrps
+
+# regenerate the files of a previous export
+osc export terraform secgrps -p myproject --path ./tf --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load("config.yaml")
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		database, err := db.InitDB(cfg)
+		if err != nil {
+			log.Fatalf("Failed to init db: %v", err)
+		}
+		defer database.Close()
+		if err := ExportTerraformSecgrps(database, cfg); err != nil {
+			log.Fatalf("Failed to export security groups: %v", err)
+		}
+	},
+}
+
+func init() {
+	exportTerraformCmd.AddCommand(exportSecgrpsCmd)
+	exportSecgrpsCmd.Flags().StringVarP(&projectFilter, "project", "p", "", "Project to export (matches projects containing this string, required)")
+	exportSecgrpsCmd.MarkFlagRequired("project")
+	exportSecgrpsCmd.Flags().StringVar(&exportPath, "path", "", "Directory to write Terraform files to (default stdout)")
+	exportSecgrpsCmd.Flags().StringVar(&exportModule, "module", "", "Write the resources to a child module with this name (requires --path)")
+	exportSecgrpsCmd.Flags().BoolVar(&exportImports, "imports", true, "Include import blocks for the exported resources")
+	exportSecgrpsCmd.Flags().BoolVar(&exportForce, "force", false, "Overwrite existing Terraform files in --path")
+}
+
+// ExportTerraformSecgrps writes the security groups of the matching projects as Terraform
+func ExportTerraformSecgrps(database *sql.DB, cfg *config.Config) error {
+	if exportModule != "" && exportPath == "" {
+		return fmt.Errorf("--module requires --path")
+	}
+	if exportModule != "" && !tfgen.IsIdentifier(exportModule) {
+		return fmt.Errorf("invalid --module %q: must start with a letter or underscore and contain only letters, digits, underscores and dashes", exportModule)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
+	defer cancel()
+
+	secgrps, err := fetchExportSecgrps(ctx, database, cfg)
+	if err != nil {
+		return err
+	}
+	if len(secgrps) == 0 {
+		return fmt.Errorf("no security groups found in projects matching %q", projectFilter)
+	}
+	rules, err := fetchExportRules(ctx, database, cfg, secgrps)
+	if err != nil {
+		return err
+	}
+
+	var projects []string
+	seen := make(map[string]bool)
+	for _, sg := range secgrps {
+		if !seen[sg.projectName] {
+			seen[sg.projectName] = true
+			projects = append(projects, sg.projectName)
+		}
+	}
+	sort.Strings(projects)
+
+	b := tfgen.NewBuilder(exportImports)
+	b.File.Header = fmt.Sprintf("Generated by osc export terraform secgrps for project(s): %s", strings.Join(projects, ", "))
+	if exportModule != "" {
+		b.ModulePrefix = "module." + exportModule + "."
+	}
+	// Add every group before the rules so remote groups resolve to references
+	for _, sg := range secgrps {
+		b.AddSecGroup(sg.SecGroup)
+	}
+	for _, rule := range rules {
+		b.AddSecGroupRule(rule)
+	}
+
+	if exportPath == "" {
+		_, err := b.File.WriteTo(os.Stdout)
+		return err
+	}
+
+	if exportModule == "" {
+		path := filepath.Join(exportPath, "secgrps.tf")
+		if !exportForce {
+			if err := refuseOverwrite(path); err != nil {
+				return err
+			}
+		}
+		return writeTerraformFile(path, &b.File)
+	}
+
+	root := &tfgen.File{
+		Header:  b.File.Header,
+		Modules: []tfgen.Module{{Name: exportModule, Source: "./modules/" + exportModule}},
+		Imports: b.File.Imports,
+	}
+	module := &b.File
+	module.Imports = nil
+	module.RequiredProviders = map[string]string{"openstack": tfgen.ProviderSource}
+
+	modulePath := filepath.Join(exportPath, "modules", exportModule, "main.tf")
+	rootPath := filepath.Join(exportPath, "main.tf")
+	if !exportForce {
+		if err := refuseOverwrite(modulePath, rootPath); err != nil {
+			return err
+		}
+	}
+	if err := writeTerraformFile(modulePath, module); err != nil {
+		return err
+	}
+	return writeTerraformFile(rootPath, root)
+}
+
+// exportSecgrp is a cached security group with its project
+type exportSecgrp struct {
+	tfgen.SecGroup
+	projectName string
+}
+
+// fetchExportSecgrps reads the security groups of the projects matching the project filter
+func fetchExportSecgrps(ctx context.Context, database *sql.DB, cfg *config.Config) ([]exportSecgrp, error) {
+	rows, err := database.QueryContext(ctx, `SELECT sg.secgrp_id, sg.secgrp_name, p.project_name
+	FROM `+cfg.Tables.SecGrps+` sg
+	JOIN `+cfg.Tables.Projects+` p ON sg.project_id = p.project_id
+	ORDER BY p.project_name, sg.secgrp_name, sg.secgrp_id;`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query security groups: %w", err)
+	}
+	defer rows.Close()
+
+	var data [][]string
+	for rows.Next() {
+		var id, name, projectName string
+		if err := rows.Scan(&id, &name, &projectName); err != nil {
+			return nil, fmt.Errorf("failed to scan security group: %w", err)
+		}
+		data = append(data, []string{id, name, projectName})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read security groups: %w", err)
+	}
+
+	pf := filter.New(projectFilter, cfg)
+	filteredData, _ := pf.MatchProjects(data, 2)
+
+	secgrps := make([]exportSecgrp, 0, len(filteredData))
+	for _, row := range filteredData {
+		secgrps = append(secgrps, exportSecgrp{
+			SecGroup:    tfgen.SecGroup{ID: row[0], Name: row[1]},
+			projectName: row[2],
+		})
+	}
+	return secgrps, nil
+}
+
+// fetchExportRules reads the rules of the given security groups in a stable order
+func fetchExportRules(ctx context.Context, database *sql.DB, cfg *config.Config, secgrps []exportSecgrp) ([]tfgen.SecGroupRule, error) {
+	names := make(map[string]string, len(secgrps))
+	for _, sg := range secgrps {
+		names[sg.ID] = sg.Name
+	}
+
+	rows, err := database.QueryContext(ctx, `SELECT r.rule_id, r.secgrp_id, r.direction, r.ethertype,
+	       COALESCE(r.protocol, ''), r.port_range_min, r.port_range_max,
+	       COALESCE(r.remote_ip_prefix, ''), COALESCE(r.remote_group_id, '')
+	FROM `+cfg.Tables.SecGrpRules+` r
+	JOIN `+cfg.Tables.SecGrps+` sg ON r.secgrp_id = sg.secgrp_id
+	ORDER BY sg.secgrp_name, sg.secgrp_id, r.direction, r.ethertype, r.protocol,
+	         r.port_range_min, r.port_range_max, r.remote_ip_prefix, r.remote_group_id, r.rule_id;`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query security group rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []tfgen.SecGroupRule
+	for rows.Next() {
+		var rule tfgen.SecGroupRule
+		var portMin, portMax sql.NullInt64
+		if err := rows.Scan(&rule.ID, &rule.SecGroupID, &rule.Direction, &rule.Ethertype, &rule.Protocol,
+			&portMin, &portMax, &rule.RemoteIPPrefix, &rule.RemoteGroupID); err != nil {
+			return nil, fmt.Errorf("failed to scan security group rule: %w", err)
+		}
+		name, ok := names[rule.SecGroupID]
+		if !ok {
+			continue
+		}
+		rule.SecGroupName = name
+		rule.PortRangeMin = nullIntPtr(portMin)
+		rule.PortRangeMax = nullIntPtr(portMax)
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read security group rules: %w", err)
+	}
+	return rules, nil
+}
+
+// writeTerraformFile writes an HCL file, creating its directory
+func writeTerraformFile(path string, file *tfgen.File) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := file.WriteTo(f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "Created: %s\n", path)
+	return f.Close()
+}
@@ -34,7 +34,10 @@ osc list servers
 oec list servers -p <project_glob>
 
 # list all security groups for a project glob
-osc list secgrps -p <project_glob>`,
+osc list secgrps -p <project_glob>
+
+# choose columns, sort and limit the rows of any list
+osc list servers --columns name,ip,status --sort-by -name --limit 10`,
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Fatal("List must be called with a subcommand")
 	},
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/marcdicarlo/osc/internal/output"
+)
+
+var (
+	// Used by all list commands
+	listColumns   []string
+	listSortBy    []string
+	listLimit     int
+	listOffset    int
+	listNoHeaders bool
+)
+
+// listField is a field of a list command's records that --columns can show
+// and --sort-by can sort on
+type listField struct {
+	// Key is the column's record field, e.g. "ip_address"
+	Key string
+	// Header is the table and CSV header
+	Header string
+	// Aliases are other names --columns and --sort-by accept, e.g. "ip"
+	Aliases []string
+	// Fields are the record fields the column shows (default: Key); a column
+	// rendered from several fields lists them all
+	Fields []string
+	// Sort is the SQL expression the column sorts on; empty when the column
+	// cannot be sorted
+	Sort string
+	// Format renders the column (default: the field flattened)
+	Format func(r output.Record) string
+}
+
+// column returns the output column of the field
+func (f listField) column() output.Column {
+	return output.Column{Header: f.Header, Key: f.Key, Fields: f.Fields, Format: f.Format}
+}
+
+// recordFields returns the record fields the column shows
+func (f listField) recordFields() []string {
+	if f.Fields != nil {
+		return f.Fields
+	}
+	return []string{f.Key}
+}
+
+// listFieldSet is the fields a list command can show and sort on
+type listFieldSet struct {
+	fields []listField
+	// metadata returns the field of a metadata.<key> column; nil when the
+	// command's resources have no metadata
+	metadata func(key string) listField
+}
+
+// lookup finds a field by its key or alias, or a metadata.<key> field
+func (s listFieldSet) lookup(name string) (listField, error) {
+	name = strings.TrimSpace(name)
+	if key, ok := strings.CutPrefix(name, "metadata."); ok && s.metadata != nil && key != "" {
+		return s.metadata(key), nil
+	}
+	for _, f := range s.fields {
+		if strings.EqualFold(name, f.Key) || slices.ContainsFunc(f.Aliases, func(a string) bool { return strings.EqualFold(name, a) }) {
+			return f, nil
+		}
+	}
+	names := make([]string, 0, len(s.fields)+1)
+	for _, f := range s.fields {
+		names = append(names, f.Key)
+	}
+	if s.metadata != nil {
+		names = append(names, "metadata.<key>")
+	}
+	return listField{}, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// selectColumns returns the fields chosen with --columns, or the default fields
+func (s listFieldSet) selectColumns(defaults []string) ([]listField, error) {
+	names := defaults
+	if len(listColumns) > 0 {
+		names = listColumns
+	}
+	fields := make([]listField, 0, len(names))
+	for _, name := range names {
+		f, err := s.lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// orderBy returns the ORDER BY clause for --sort-by, with the default order as
+// the tie-breaker. A field prefixed with "-" sorts in descending order. Without
+// --sort-by the default order is used; an empty default leaves the order to
+// the database.
+func (s listFieldSet) orderBy(defaults ...string) (string, error) {
+	terms := make([]string, 0, len(listSortBy)+len(defaults))
+	for _, spec := range listSortBy {
+		name, desc := strings.CutPrefix(strings.TrimSpace(spec), "-")
+		f, err := s.lookup(name)
+		if err != nil {
+			return "", err
+		}
+		if f.Sort == "" {
+			return "", fmt.Errorf("cannot sort by %s", f.Key)
+		}
+		if desc {
+			terms = append(terms, f.Sort+" DESC")
+		} else {
+			terms = append(terms, f.Sort)
+		}
+	}
+	terms = append(terms, defaults...)
+	if len(terms) == 0 {
+		return "", nil
+	}
+	return " ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+// sqlJSONField returns the SQL expression extracting a key of a JSON column
+func sqlJSONField(column, key string) string {
+	path := `$."` + strings.ReplaceAll(key, `"`, `\"`) + `"`
+	return "json_extract(" + column + ", '" + strings.ReplaceAll(path, "'", "''") + "')"
+}
+
+// validateListWindow checks --limit and --offset
+func validateListWindow() error {
+	if listLimit < 0 || listOffset < 0 {
+		return fmt.Errorf("--limit and --offset must not be negative")
+	}
+	return nil
+}
+
+// sqlLimit returns the LIMIT clause for --limit and --offset. Commands that
+// filter rows after the query cannot limit in SQL and use a listWindow.
+func sqlLimit() string {
+	if listLimit == 0 && listOffset == 0 {
+		return ""
+	}
+	limit := listLimit
+	if limit == 0 {
+		limit = -1 // no limit, only an offset
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, listOffset)
+}
+
+// listWindow applies --offset and --limit to records filtered after the query
+type listWindow struct {
+	skip int
+	// left is the number of records still admitted; negative for no limit
+	left int
+}
+
+// newListWindow returns the window of --offset and --limit, or an open window
+// when the query already applied them
+func newListWindow(limitedInSQL bool) *listWindow {
+	if limitedInSQL {
+		return &listWindow{left: -1}
+	}
+	w := &listWindow{skip: listOffset, left: listLimit}
+	if listLimit == 0 {
+		w.left = -1
+	}
+	return w
+}
+
+// admit reports whether the next record is inside the window
+func (w *listWindow) admit() bool {
+	if w.skip > 0 {
+		w.skip--
+		return false
+	}
+	if w.left == 0 {
+		return false
+	}
+	if w.left > 0 {
+		w.left--
+	}
+	return true
+}
+
+// done reports whether the window admits no more records
+func (w *listWindow) done() bool {
+	return w.left == 0
+}
+
+// listRecord returns a record with only the fields of the --columns columns.
+// Without --columns records keep all their fields.
+func listRecord(columns []listField, r output.Record) output.Record {
+	if len(listColumns) == 0 {
+		return r
+	}
+	var keys []string
+	for _, c := range columns {
+		keys = append(keys, c.recordFields()...)
+	}
+	return r.Select(keys)
+}
+
+// recordProjectName returns the project of a record, for filter.MatchItems
+func recordProjectName(r output.Record) string {
+	return r.String("project_name")
+}
+
+// listRecordData creates the output data of a list command's records
+func listRecordData(columns []listField, records []output.Record) *output.OutputData {
+	outputColumns := make([]output.Column, len(columns))
+	for i, c := range columns {
+		outputColumns[i] = c.column()
+	}
+	selected := make([]output.Record, len(records))
+	for i, r := range records {
+		selected[i] = listRecord(columns, r)
+	}
+	data := output.NewRecordData(outputColumns, selected)
+	data.NoHeaders = listNoHeaders
+	return data
+}
+
+func init() {
+	listCmd.PersistentFlags().StringSliceVar(&listColumns, "columns", nil, "Columns to show, e.g. name,ip,status,metadata.owner (see each command's help)")
+	listCmd.PersistentFlags().StringSliceVar(&listSortBy, "sort-by", nil, "Columns to sort by, \"-\" for descending, e.g. status,-name")
+	listCmd.PersistentFlags().IntVar(&listLimit, "limit", 0, "Show at most this many rows (0 for all)")
+	listCmd.PersistentFlags().IntVar(&listOffset, "offset", 0, "Skip this many rows first")
+	listCmd.PersistentFlags().BoolVar(&listNoHeaders, "no-headers", false, "Omit the header row and filter summary of table and CSV output")
+}
@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// setListFlags sets the list flags for a test and restores them afterwards
+func setListFlags(t *testing.T, columns, sortBy []string, limit, offset int) {
+	t.Helper()
+	oldColumns, oldSortBy, oldLimit, oldOffset := listColumns, listSortBy, listLimit, listOffset
+	t.Cleanup(func() {
+		listColumns, listSortBy, listLimit, listOffset = oldColumns, oldSortBy, oldLimit, oldOffset
+	})
+	listColumns, listSortBy, listLimit, listOffset = columns, sortBy, limit, offset
+}
+
+func TestListFieldSetLookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   listFieldSet
+		column   string
+		wantKey  string
+		wantSort string
+		wantErr  string
+	}{
+		{"key", serverFields, "name", "name", "s.server_name", ""},
+		{"alias", serverFields, "ip", "ip_address", "s.ipv4_addr", ""},
+		{"case insensitive key", serverFields, "Status", "status", "s.status", ""},
+		{"case insensitive alias", serverFields, "SECGRPS", "security_groups", "", ""},
+		{"surrounding spaces", serverFields, " flavor ", "flavor_name", "s.flavor_name", ""},
+		{"metadata", serverFields, "metadata.owner", "metadata.owner", `json_extract(s.metadata, '$."owner"')`, ""},
+		{"metadata with dots", serverFields, "metadata.a.b", "metadata.a.b", `json_extract(s.metadata, '$."a.b"')`, ""},
+		{"metadata without key", serverFields, "metadata.", "", "", `unknown column "metadata."`},
+		{"unknown", serverFields, "bogus", "", "", "available: name, id, project_name, ip_address, status, flavor_name, flavor_id, image_name, image_id, floating_ip, security_groups, metadata.<key>)"},
+		{"project key", projectFields, "project_name", "project_name", "project_name", ""},
+		{"project alias", projectFields, "id", "project_id", "project_id", ""},
+		{"no metadata", projectFields, "metadata.owner", "", "", `unknown column "metadata.owner" (available: project_id, project_name)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.fields.lookup(tt.column)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("lookup(%q) error = %v, want %q", tt.column, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("lookup(%q) error = %v", tt.column, err)
+			}
+			if f.Key != tt.wantKey || f.Sort != tt.wantSort {
+				t.Errorf("lookup(%q) = key %q sort %q, want key %q sort %q", tt.column, f.Key, f.Sort, tt.wantKey, tt.wantSort)
+			}
+		})
+	}
+}
+
+func TestListFieldSetSelectColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		want    []string
+		wantErr bool
+	}{
+		{"defaults", nil, []string{"name", "id"}, false},
+		{"columns", []string{"ip", "metadata.owner"}, []string{"ip_address", "metadata.owner"}, false},
+		{"unknown", []string{"name", "bogus"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setListFlags(t, tt.columns, nil, 0, 0)
+			fields, err := serverFields.selectColumns([]string{"name", "id"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectColumns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var keys []string
+			for _, f := range fields {
+				keys = append(keys, f.Key)
+			}
+			if !slices.Equal(keys, tt.want) {
+				t.Errorf("selectColumns() = %v, want %v", keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFieldSetOrderBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		sortBy   []string
+		defaults []string
+		want     string
+		wantErr  string
+	}{
+		{"database order", nil, nil, "", ""},
+		{"default order", nil, []string{"p.project_name", "s.server_name"}, " ORDER BY p.project_name, s.server_name", ""},
+		{"ascending", []string{"status"}, nil, " ORDER BY s.status", ""},
+		{"descending", []string{"-name"}, nil, " ORDER BY s.server_name DESC", ""},
+		{"alias descending", []string{"-ip"}, nil, " ORDER BY s.ipv4_addr DESC", ""},
+		{"spaces before prefix", []string{" -status"}, nil, " ORDER BY s.status DESC", ""},
+		{"several with defaults", []string{"status", "-name"}, []string{"p.project_name"}, " ORDER BY s.status, s.server_name DESC, p.project_name", ""},
+		{"metadata descending", []string{"-metadata.owner"}, nil, ` ORDER BY json_extract(s.metadata, '$."owner"') DESC`, ""},
+		{"unsortable", []string{"security_groups"}, nil, "", "cannot sort by security_groups"},
+		{"unsortable alias descending", []string{"-secgrps"}, nil, "", "cannot sort by security_groups"},
+		{"unknown", []string{"status", "bogus"}, nil, "", `unknown column "bogus"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setListFlags(t, nil, tt.sortBy, 0, 0)
+			got, err := serverFields.orderBy(tt.defaults...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("orderBy() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("orderBy() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("orderBy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLJSONField(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE servers (metadata TEXT)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	metadata := `{"owner": "alice", "a.b": "dotted", "it's": "single", "say \"hi\"": "double", "both '\"": "mixed"}`
+	if _, err := db.Exec(`INSERT INTO servers (metadata) VALUES (?)`, metadata); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	tests := []struct {
+		key      string
+		wantExpr string
+		want     string
+	}{
+		{"owner", `json_extract(metadata, '$."owner"')`, "alice"},
+		{"a.b", `json_extract(metadata, '$."a.b"')`, "dotted"},
+		{"it's", `json_extract(metadata, '$."it''s"')`, "single"},
+		{`say "hi"`, `json_extract(metadata, '$."say \"hi\""')`, "double"},
+		{`both '"`, `json_extract(metadata, '$."both ''\""')`, "mixed"},
+		{"missing", `json_extract(metadata, '$."missing"')`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			expr := sqlJSONField("metadata", tt.key)
+			if expr != tt.wantExpr {
+				t.Errorf("sqlJSONField(%q) = %s, want %s", tt.key, expr, tt.wantExpr)
+			}
+			var got sql.NullString
+			if err := db.QueryRow(`SELECT ` + expr + ` FROM servers`).Scan(&got); err != nil {
+				t.Fatalf("query %s: %v", expr, err)
+			}
+			if got.String != tt.want {
+				t.Errorf("%s = %q, want %q", expr, got.String, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int
+		offset int
+		want   string
+	}{
+		{"none", 0, 0, ""},
+		{"limit", 10, 0, " LIMIT 10 OFFSET 0"},
+		{"offset only", 0, 5, " LIMIT -1 OFFSET 5"},
+		{"limit and offset", 10, 5, " LIMIT 10 OFFSET 5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setListFlags(t, nil, nil, tt.limit, tt.offset)
+			if got := sqlLimit(); got != tt.want {
+				t.Errorf("sqlLimit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateListWindow(t *testing.T) {
+	tests := []struct {
+		limit, offset int
+		wantErr       bool
+	}{
+		{0, 0, false},
+		{3, 2, false},
+		{-1, 0, true},
+		{0, -1, true},
+	}
+
+	for _, tt := range tests {
+		setListFlags(t, nil, nil, tt.limit, tt.offset)
+		if err := validateListWindow(); (err != nil) != tt.wantErr {
+			t.Errorf("validateListWindow() with --limit %d --offset %d error = %v, wantErr %v", tt.limit, tt.offset, err, tt.wantErr)
+		}
+	}
+}
+
+func TestListWindow(t *testing.T) {
+	tests := []struct {
+		name         string
+		limit        int
+		offset       int
+		limitedInSQL bool
+		want         []int
+	}{
+		{"no limit", 0, 0, false, []int{0, 1, 2, 3, 4}},
+		{"limit", 2, 0, false, []int{0, 1}},
+		{"offset only", 0, 3, false, []int{3, 4}},
+		{"limit and offset", 2, 1, false, []int{1, 2}},
+		{"limit past the end", 10, 3, false, []int{3, 4}},
+		{"offset past the end", 2, 10, false, nil},
+		{"limited in SQL", 2, 1, true, []int{0, 1, 2, 3, 4}},
+		{"offset only in SQL", 0, 3, true, []int{0, 1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setListFlags(t, nil, nil, tt.limit, tt.offset)
+			window := newListWindow(tt.limitedInSQL)
+			var got []int
+			for i := 0; i < 5 && !window.done(); i++ {
+				if window.admit() {
+					got = append(got, i)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("admitted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestListWindowMatchesSQL checks that --limit and --offset select the same
+// rows in SQL as after the query, and that a filter after the query is
+// applied before the window
+func TestListWindowMatchesSQL(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE servers (server_name TEXT, project_name TEXT)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	for _, row := range [][2]string{
+		{"web-1", "prod"}, {"web-2", "dev"}, {"web-3", "prod"}, {"web-4", "dev"}, {"web-5", "prod"},
+	} {
+		if _, err := db.Exec(`INSERT INTO servers VALUES (?, ?)`, row[0], row[1]); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	// list reads the servers the way the list commands do, limiting in SQL
+	// unless a project filter runs after the query
+	list := func(t *testing.T, project string) []string {
+		t.Helper()
+		limitInSQL := project == ""
+		query := `SELECT server_name, project_name FROM servers ORDER BY server_name`
+		if limitInSQL {
+			query += sqlLimit()
+		}
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatalf("query %s: %v", query, err)
+		}
+		defer rows.Close()
+		var names []string
+		window := newListWindow(limitInSQL)
+		for rows.Next() && !window.done() {
+			var name, pname string
+			if err := rows.Scan(&name, &pname); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			if (project != "" && pname != project) || !window.admit() {
+				continue
+			}
+			names = append(names, name)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("rows: %v", err)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name       string
+		limit      int
+		offset     int
+		want       []string
+		wantInProd []string
+	}{
+		{"no limit", 0, 0, []string{"web-1", "web-2", "web-3", "web-4", "web-5"}, []string{"web-1", "web-3", "web-5"}},
+		{"limit", 2, 0, []string{"web-1", "web-2"}, []string{"web-1", "web-3"}},
+		{"offset only", 0, 3, []string{"web-4", "web-5"}, nil},
+		{"offset only in filter", 0, 1, []string{"web-2", "web-3", "web-4", "web-5"}, []string{"web-3", "web-5"}},
+		{"limit and offset", 2, 1, []string{"web-2", "web-3"}, []string{"web-3", "web-5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setListFlags(t, nil, nil, tt.limit, tt.offset)
+			if got := list(t, ""); !slices.Equal(got, tt.want) {
+				t.Errorf("limited in SQL = %v, want %v", got, tt.want)
+			}
+			if got := list(t, "prod"); !slices.Equal(got, tt.wantInProd) {
+				t.Errorf("limited after the prod filter = %v, want %v", got, tt.wantInProd)
+			}
+		})
+	}
+}
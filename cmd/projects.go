@@ -31,6 +31,9 @@ osc list projects -o json
 
 # list projects in CSV format
 osc list projects -o csv
+
+# list the first 10 project names, sorted, without a header
+osc list projects --columns name --sort-by name --limit 10 --no-headers
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Load configuration from YAML
@@ -65,13 +68,39 @@ func init() {
 	// projectsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
+// projectFields are the columns of list projects
+var projectFields = listFieldSet{fields: []listField{
+	{Key: "project_id", Header: "Project ID", Aliases: []string{"id"}, Sort: "project_id"},
+	{Key: "project_name", Header: "Project Name", Aliases: []string{"name"}, Sort: "project_name"},
+}}
+
 // Print reads and outputs project data.
 func Print(db *sql.DB, cfg *config.Config) error {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
 	defer cancel()
 
+	if err := validateListWindow(); err != nil {
+		return err
+	}
+	columns, err := projectFields.selectColumns([]string{"project_id", "project_name"})
+	if err != nil {
+		return err
+	}
+	orderBy, err := projectFields.orderBy()
+	if err != nil {
+		return err
+	}
+
 	// Query all project names and project ids from the database projects table
-	query := `SELECT project_id, project_name FROM ` + cfg.Tables.Projects
+	query := `SELECT project_id, project_name FROM ` + cfg.Tables.Projects + orderBy + sqlLimit()
+
+	// Create the output formatter
+	formatter, err := output.NewFormatter(outputFormat, os.Stdout)
+	if err != nil {
+		return err
+	}
+	// Streaming formats write each project as it is read
+	stream, streaming := formatter.(output.RecordStreamer)
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
@@ -80,30 +109,40 @@ func Print(db *sql.DB, cfg *config.Config) error {
 	defer rows.Close()
 
 	// Collect the data
-	var data [][]string
+	var records []output.Record
 	for rows.Next() {
 		var pid, pname string
 		if err := rows.Scan(&pid, &pname); err != nil {
 			return err
 		}
-		data = append(data, []string{pid, pname})
+		record := output.NewRecord("project",
+			output.Field{Key: "project_id", Value: pid},
+			output.Field{Key: "project_name", Value: pname},
+		)
+		if streaming {
+			if err := stream.WriteRecord(listRecord(columns, record)); err != nil {
+				return err
+			}
+			continue
+		}
+		records = append(records, record)
 	}
 
 	if err := rows.Err(); err != nil {
 		return err
 	}
-
-	// Create the output formatter
-	formatter, err := output.NewFormatter(outputFormat, os.Stdout)
-	if err != nil {
-		return err
+	if streaming {
+		return stream.Flush()
 	}
 
 	// Format and output the data
-	outputData := output.NewOutputData(
-		[]string{"Project ID", "Project Name"},
-		data,
-	)
+	return formatter.Format(projectRecordData(columns, records))
+}
 
-	return formatter.Format(outputData)
+// projectRecordData creates the output data of list projects; -o name and
+// -o id write the project names and IDs
+func projectRecordData(columns []listField, records []output.Record) *output.OutputData {
+	data := listRecordData(columns, records)
+	data.NameKey, data.IDKey = "project_name", "project_id"
+	return data
 }
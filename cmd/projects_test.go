@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/marcdicarlo/osc/internal/output"
+)
+
+func TestProjectRecordDataNameAndID(t *testing.T) {
+	columns, err := projectFields.selectColumns([]string{"project_id", "project_name"})
+	if err != nil {
+		t.Fatalf("selectColumns() error = %v", err)
+	}
+	records := []output.Record{
+		output.NewRecord("project",
+			output.Field{Key: "project_id", Value: "p-1"},
+			output.Field{Key: "project_name", Value: "prod"},
+		),
+		output.NewRecord("project",
+			output.Field{Key: "project_id", Value: "p-2"},
+			output.Field{Key: "project_name", Value: "dev"},
+		),
+	}
+
+	tests := map[string]string{
+		"name": "prod\ndev\n",
+		"id":   "p-1\np-2\n",
+	}
+	for format, want := range tests {
+		var buf bytes.Buffer
+		formatter, err := output.NewFormatter(format, &buf)
+		if err != nil {
+			t.Fatalf("NewFormatter(%s) error = %v", format, err)
+		}
+		if err := formatter.Format(projectRecordData(columns, records)); err != nil {
+			t.Fatalf("Format(%s) error = %v", format, err)
+		}
+		if buf.String() != want {
+			t.Errorf("-o %s = %q, want %q", format, buf.String(), want)
+		}
+	}
+}
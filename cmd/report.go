@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate reports from cached data",
+	Long: `Generate reports that combine several types of cached OpenStack data.
+
+Available reports:
+    exposure  Servers reachable from the internet and on which ports
+
+Examples:
+
+# list every internet-exposed server port
+osc report exposure
+
+# per-project exposure summary
+osc report exposure --summary`,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Fatal("Report must be called with a subcommand")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/db"
+	"github.com/marcdicarlo/osc/internal/filter"
+	"github.com/marcdicarlo/osc/internal/output"
+	"github.com/marcdicarlo/osc/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var exposureSummary bool
+
+var reportExposureCmd = &cobra.Command{
+	Use:   "exposure",
+	Short: "Report servers reachable from the internet",
+	Long: `Report every server reachable from the internet and the ports it is reachable on.
+
+A server is considered reachable when it has a public address (its floating IP,
+or its fixed IPv4 address if that is not a private address) and one of its
+security groups has an ingress rule whose remote is 0.0.0.0/0, ::/0, empty, or
+overlaps one of the CIDRs listed under exposure.public_cidrs in the config file.
+
+Examples:
+
+# list every exposed server port with the granting security group and rule
+osc report exposure
+
+# limit to projects containing a string
+osc report exposure -p prod
+
+# per-project summary
+osc report exposure --summary
+
+# output in different formats
+osc report exposure -o json
+osc report exposure --summary -o csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load("config.yaml")
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		database, err := db.InitDB(cfg)
+		if err != nil {
+			log.Fatalf("Failed to init db: %v", err)
+		}
+		defer database.Close()
+		if err := ReportExposure(database, cfg); err != nil {
+			log.Fatalf("Failed to generate exposure report: %v", err)
+		}
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(reportExposureCmd)
+	reportExposureCmd.Flags().StringVarP(&projectFilter, "project", "p", "", "Filter by project name (shows projects containing this string)")
+	reportExposureCmd.Flags().BoolVar(&exposureSummary, "summary", false, "Show a per-project summary instead of individual exposures")
+}
+
+// ReportExposure reads servers and their ingress rules and outputs the internet exposure report
+func ReportExposure(database *sql.DB, cfg *config.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
+	defer cancel()
+
+	analyzer, invalid := report.NewExposureAnalyzer(cfg.Exposure.PublicCIDRs)
+	if len(invalid) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring invalid exposure.public_cidrs entries: %s\n", strings.Join(invalid, ", "))
+	}
+
+	servers, err := fetchExposureServers(ctx, database, cfg)
+	if err != nil {
+		return err
+	}
+	rulesByServer, err := fetchServerIngressRules(ctx, database, cfg)
+	if err != nil {
+		return err
+	}
+
+	exposures := analyzer.FindExposures(servers, rulesByServer)
+
+	// -o name and -o id write the project of each summary row, or the exposed
+	// server of each detail row
+	var columns []output.Column
+	var records []output.Record
+	nameKey, idKey := "server_name", "server_id"
+	if exposureSummary {
+		nameKey, idKey = "project_name", "project_name"
+		columns = []output.Column{
+			{Header: "Project Name", Key: "project_name"},
+			{Header: "Exposed Servers", Key: "exposed_servers"},
+			{Header: "Exposed Ports", Key: "exposed_ports"},
+			{Header: "Open To All", Key: "open_to_all"},
+		}
+		for _, s := range report.SummarizeByProject(exposures) {
+			records = append(records, output.NewRecord("project-exposure",
+				output.Field{Key: "project_name", Value: s.ProjectName},
+				output.Field{Key: "exposed_servers", Value: s.ExposedServers},
+				output.Field{Key: "exposed_ports", Value: s.ExposedPorts},
+				output.Field{Key: "open_to_all", Value: s.OpenToAll},
+			))
+		}
+	} else {
+		columns = []output.Column{
+			{Header: "Server Name", Key: "server_name"},
+			{Header: "Server ID", Key: "server_id"},
+			{Header: "Project Name", Key: "project_name"},
+			{Header: "Public IP", Key: "public_ip"},
+			{Header: "Protocol", Key: "protocol"},
+			{Header: "Port Range", Key: "port_range"},
+			{Header: "Security Group", Key: "security_group"},
+			{Header: "Rule ID", Key: "rule_id"},
+			{Header: "Remote IP", Key: "remote"},
+		}
+		for _, e := range exposures {
+			records = append(records, output.NewRecord("exposure",
+				output.Field{Key: "server_name", Value: e.ServerName},
+				output.Field{Key: "server_id", Value: e.ServerID},
+				output.Field{Key: "project_name", Value: e.ProjectName},
+				output.Field{Key: "public_ip", Value: e.PublicIP},
+				output.Field{Key: "protocol", Value: e.Protocol},
+				output.Field{Key: "port_range", Value: e.PortRange},
+				output.Field{Key: "security_group", Value: e.SecGrpName},
+				output.Field{Key: "rule_id", Value: e.RuleID},
+				output.Field{Key: "remote", Value: e.Remote},
+			))
+		}
+	}
+
+	// Apply project filtering
+	pf := filter.New(projectFilter, cfg)
+	filteredRecords, matchedProjectsMap := filter.MatchItems(pf, records, recordProjectName)
+
+	formatter, err := output.NewFormatter(outputFormat, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	outputData := output.NewRecordData(columns, filteredRecords)
+	outputData.NameKey, outputData.IDKey = nameKey, idKey
+	if pf.GetActiveFilter() != "" {
+		var matchedProjects []string
+		for project := range matchedProjectsMap {
+			matchedProjects = append(matchedProjects, project)
+		}
+		outputData.WithFilterInfo(matchedProjects)
+	}
+
+	return formatter.Format(outputData)
+}
+
+// fetchExposureServers reads the cached address details of all servers
+func fetchExposureServers(ctx context.Context, database *sql.DB, cfg *config.Config) ([]report.Server, error) {
+	query := `SELECT s.server_id, s.server_name, p.project_name,
+	         COALESCE(s.ipv4_addr, ''), COALESCE(s.floating_ip, '')
+	FROM ` + cfg.Tables.Servers + ` s
+	JOIN ` + cfg.Tables.Projects + ` p USING (project_id)
+	ORDER BY p.project_name, s.server_name;`
+
+	rows, err := database.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var servers []report.Server
+	for rows.Next() {
+		var srv report.Server
+		if err := rows.Scan(&srv.ID, &srv.Name, &srv.ProjectName, &srv.IPv4Addr, &srv.FloatingIP); err != nil {
+			return nil, err
+		}
+		servers = append(servers, srv)
+	}
+	return servers, rows.Err()
+}
+
+// fetchServerIngressRules reads the ingress rules of every security group attached to a server
+func fetchServerIngressRules(ctx context.Context, database *sql.DB, cfg *config.Config) (map[string][]report.IngressRule, error) {
+	query := `SELECT ssg.server_id, r.rule_id, sg.secgrp_id, sg.secgrp_name, r.ethertype,
+	         COALESCE(r.protocol, ''), r.port_range_min, r.port_range_max,
+	         COALESCE(r.remote_ip_prefix, ''), COALESCE(r.remote_group_id, '')
+	FROM ` + cfg.Tables.ServerSecGrps + ` ssg
+	JOIN ` + cfg.Tables.SecGrps + ` sg ON ssg.secgrp_id = sg.secgrp_id
+	JOIN ` + cfg.Tables.SecGrpRules + ` r ON r.secgrp_id = sg.secgrp_id
+	WHERE r.direction = 'ingress';`
+
+	rows, err := database.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rulesByServer := make(map[string][]report.IngressRule)
+	for rows.Next() {
+		var serverID string
+		var rule report.IngressRule
+		var portMin, portMax sql.NullInt64
+		if err := rows.Scan(&serverID, &rule.RuleID, &rule.SecGrpID, &rule.SecGrpName, &rule.Ethertype,
+			&rule.Protocol, &portMin, &portMax, &rule.RemoteIPPrefix, &rule.RemoteGroupID); err != nil {
+			return nil, err
+		}
+		rule.PortRangeMin = nullIntPtr(portMin)
+		rule.PortRangeMax = nullIntPtr(portMax)
+		rulesByServer[serverID] = append(rulesByServer[serverID], rule)
+	}
+	return rulesByServer, rows.Err()
+}
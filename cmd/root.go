@@ -6,8 +6,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/marcdicarlo/osc/internal/logx"
+	"github.com/marcdicarlo/osc/internal/output"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +17,7 @@ var (
 	// Used by multiple commands
 	projectFilter string
 	outputFormat  string
+	templateFile  string
 	debugMode     bool
 )
 
@@ -35,8 +38,14 @@ Use "osc <command> --help" for more information about a given command.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		logx.SetDebug(debugMode)
+		if err := applyTemplateFile(cmd); err != nil {
+			// Execute prints the error; the usage would not help
+			cmd.SilenceUsage, cmd.SilenceErrors = true, true
+			return err
+		}
+		return nil
 	},
 }
 
@@ -49,6 +58,28 @@ func Execute() {
 	}
 }
 
+// applyTemplateFile reads --template-file into the output format, so that
+// -o template or -o jsonpath use the file's template. Without -o the template
+// is a Go template.
+func applyTemplateFile(cmd *cobra.Command) error {
+	if templateFile == "" {
+		return nil
+	}
+	format := outputFormat
+	if !cmd.Flags().Changed("output") {
+		format = string(output.FormatTemplate)
+	}
+	if format != string(output.FormatTemplate) && format != string(output.FormatJSONPath) {
+		return fmt.Errorf("--template-file needs -o template or -o jsonpath, not -o %s", outputFormat)
+	}
+	text, err := os.ReadFile(templateFile)
+	if err != nil {
+		return fmt.Errorf("failed to read template file: %w", err)
+	}
+	outputFormat = format + "=" + string(text)
+	return nil
+}
+
 func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
@@ -57,7 +88,8 @@ func init() {
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.osc.yaml)")
 
 	// Add global output format flag
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "Output format: table, json, or csv")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "Output format: "+strings.Join(output.GetValidFormats(), ", "))
+	rootCmd.PersistentFlags().StringVar(&templateFile, "template-file", "", "Read the template of -o template or -o jsonpath from a file (implies -o template)")
 	rootCmd.PersistentFlags().BoolVar(&debugMode, "debug", false, "Enable detailed debug logs for diagnostics")
 
 	// Cobra also supports local flags, which will only run
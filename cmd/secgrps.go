@@ -8,7 +8,10 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net/netip"
 	"os"
+	"slices"
+	"strconv"
 
 	"github.com/marcdicarlo/osc/internal/config"
 	"github.com/marcdicarlo/osc/internal/db"
@@ -22,11 +25,17 @@ var (
 	rules       bool
 	fullOutput  bool
 	sortGrouped bool
-	secgrpsCmd = &cobra.Command{
+	allowsSpec  string
+	allowsFrom  string
+	secgrpsCmd  = &cobra.Command{
 		Use:   "secgrps",
 		Short: "List all OpenStack security groups and rules",
 		Long: `List all OpenStack security groups and optionally their rules.
 
+With --rules, table and CSV output show a row per security group and per rule.
+json, yaml, ndjson and the other formats that write records list each security
+group with its rules in a rules array; --limit and --offset then count groups.
+
 Examples:
 
 # list all security groups
@@ -55,6 +64,15 @@ osc list secgrps -r -o json
 osc list secgrps -r --full -o json
 osc list secgrps -r -f -o csv
 osc list secgrps -p "prod" -r -o json
+
+# list security groups allowing MySQL from anywhere inside 10.0.0.0/8
+osc list secgrps --allows tcp/3306 --from 10.0.0.0/8
+
+# show only the matching rules
+osc list secgrps -r -f --allows tcp/3306 --from 10.0.0.0/8
+
+# list rule ports and sources sorted by port, without a header
+osc list secgrps -r --columns name,protocol,port_range,remote_ip --sort-by port_range --no-headers
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg, err := config.Load("config.yaml")
@@ -79,6 +97,8 @@ func init() {
 	secgrpsCmd.Flags().BoolVarP(&fullOutput, "full", "f", false, "Show full rule details including ethertype and remote group IDs (requires -r)")
 	secgrpsCmd.Flags().BoolVarP(&sortGrouped, "sort", "s", false, "Group security groups with their rules together (requires -r)")
 	secgrpsCmd.Flags().StringVarP(&projectFilter, "project", "p", "", "Filter security groups by project name (shows projects containing this string)")
+	secgrpsCmd.Flags().StringVar(&allowsSpec, "allows", "", "Only show groups with ingress rules allowing <protocol>[/<port>[-<port>]], e.g. tcp/3306")
+	secgrpsCmd.Flags().StringVar(&allowsFrom, "from", "", "Only show groups with ingress rules allowing traffic from an address inside this CIDR")
 }
 
 // Secgrps reads and outputs security group and rule data.
@@ -93,59 +113,60 @@ func Secgrps(db *sql.DB, cfg *config.Config) error {
 	if sortGrouped && !fullOutput {
 		return fmt.Errorf("--sort flag requires --full flag")
 	}
+	if err := validateListWindow(); err != nil {
+		return err
+	}
 
-	// Build the base query for security groups
-	var query string
-	if rules && fullOutput {
-		// Full output mode: include ethertype and remote_group_id with group name resolution
-		query = `SELECT
-			s.secgrp_name as name,
-			s.secgrp_id as id,
-			s.secgrp_id as parent_id,
-			s.project_id,
-			p.project_name,
-			'security-group' as resource_type,
-			'' as direction,
-			'' as protocol,
-			'' as port_range,
-			'' as remote_ip,
-			'' as ethertype,
-			'' as remote_group_id,
-			'' as remote_group_name
-		FROM ` + cfg.Tables.SecGrps + ` s
-		JOIN ` + cfg.Tables.Projects + ` p USING (project_id)
-		UNION ALL
-		SELECT
-			r.rule_id as name,
-			r.rule_id as id,
-			r.secgrp_id as parent_id,
-			s.project_id,
-			p.project_name,
-			'security-group-rule' as resource_type,
-			r.direction,
-			COALESCE(r.protocol, 'any') as protocol,
-			CASE
-				WHEN r.port_range_min IS NULL AND r.port_range_max IS NULL THEN 'any'
-				WHEN r.port_range_min = r.port_range_max THEN CAST(r.port_range_min AS TEXT)
-				ELSE CAST(r.port_range_min AS TEXT) || '-' || CAST(r.port_range_max AS TEXT)
-			END as port_range,
-			COALESCE(r.remote_ip_prefix, 'any') as remote_ip,
-			r.ethertype,
-			COALESCE(r.remote_group_id, '') as remote_group_id,
-			COALESCE(sg_remote.secgrp_name, '') as remote_group_name
-		FROM ` + cfg.Tables.SecGrpRules + ` r
-		JOIN ` + cfg.Tables.SecGrps + ` s ON r.secgrp_id = s.secgrp_id
-		JOIN ` + cfg.Tables.Projects + ` p ON s.project_id = p.project_id
-		LEFT JOIN ` + cfg.Tables.SecGrps + ` sg_remote ON r.remote_group_id = sg_remote.secgrp_id
-		`
-		// Add ORDER BY clause based on sort flag
+	// Choose the columns and order; rules mode sorts on the union's columns
+	fields := secgrpFields(rules)
+	defaults := []string{"name", "id", "project_id", "project_name", "type"}
+	defaultOrder := []string{"s.secgrp_name"}
+	if rules {
+		defaults = []string{"name", "id", "parent_id", "project_id", "project_name", "type"}
+		defaultOrder = []string{"resource_type DESC", "name"}
 		if sortGrouped {
-			query += "ORDER BY parent_id, resource_type, name;"
-		} else {
-			query += "ORDER BY resource_type DESC, name;"
+			defaultOrder = []string{"parent_id", "resource_type", "name"}
+		}
+	}
+	if fullOutput {
+		for _, f := range secgrpRuleFields {
+			defaults = append(defaults, f.Key)
 		}
-	} else if rules {
-		// Basic rules mode
+	}
+	columns, err := fields.selectColumns(defaults)
+	if err != nil {
+		return err
+	}
+	orderBy, err := fields.orderBy(defaultOrder...)
+	if err != nil {
+		return err
+	}
+	// Rule details are read for --full and for columns chosen with --columns
+	ruleDetails := fullOutput || len(listColumns) > 0
+
+	// Create the output formatter
+	formatter, err := output.NewFormatter(outputFormat, os.Stdout)
+	if err != nil {
+		return err
+	}
+	// Formats that write records list each group with its rules nested in it;
+	// table and CSV show groups and rules as rows
+	nested := rules && writesRecords(formatter)
+
+	pf := filter.New(projectFilter, cfg)
+	filterRules := allowsSpec != "" || allowsFrom != ""
+	// --limit and --offset go into the query unless rows are filtered after it
+	// or, when rules are nested, count groups
+	limitInSQL := !filterRules && !pf.Excludes() && !nested
+	limit := ""
+	if limitInSQL {
+		limit = sqlLimit()
+	}
+
+	// Build the query for security groups and, with --rules, their rules.
+	// Groups have NULL rule columns.
+	var query string
+	if rules {
 		query = `SELECT
 			s.secgrp_name as name,
 			s.secgrp_id as id,
@@ -153,10 +174,14 @@ func Secgrps(db *sql.DB, cfg *config.Config) error {
 			s.project_id,
 			p.project_name,
 			'security-group' as resource_type,
-			'' as direction,
-			'' as protocol,
-			'' as port_range,
-			'' as remote_ip
+			NULL as direction,
+			NULL as ethertype,
+			NULL as protocol,
+			NULL as port_range_min,
+			NULL as port_range_max,
+			NULL as remote_ip_prefix,
+			NULL as remote_group_id,
+			NULL as remote_group_name
 		FROM ` + cfg.Tables.SecGrps + ` s
 		JOIN ` + cfg.Tables.Projects + ` p USING (project_id)
 		UNION ALL
@@ -168,38 +193,62 @@ func Secgrps(db *sql.DB, cfg *config.Config) error {
 			p.project_name,
 			'security-group-rule' as resource_type,
 			r.direction,
-			COALESCE(r.protocol, 'any') as protocol,
-			CASE
-				WHEN r.port_range_min IS NULL AND r.port_range_max IS NULL THEN 'any'
-				WHEN r.port_range_min = r.port_range_max THEN CAST(r.port_range_min AS TEXT)
-				ELSE CAST(r.port_range_min AS TEXT) || '-' || CAST(r.port_range_max AS TEXT)
-			END as port_range,
-			COALESCE(r.remote_ip_prefix, 'any') as remote_ip
+			r.ethertype,
+			r.protocol,
+			r.port_range_min,
+			r.port_range_max,
+			r.remote_ip_prefix,
+			r.remote_group_id,
+			sg_remote.secgrp_name as remote_group_name
 		FROM ` + cfg.Tables.SecGrpRules + ` r
 		JOIN ` + cfg.Tables.SecGrps + ` s ON r.secgrp_id = s.secgrp_id
 		JOIN ` + cfg.Tables.Projects + ` p ON s.project_id = p.project_id
-		`
-		// Add ORDER BY clause based on sort flag
-		if sortGrouped {
-			query += "ORDER BY parent_id, resource_type, name;"
-		} else {
-			query += "ORDER BY resource_type DESC, name;"
-		}
+		LEFT JOIN ` + cfg.Tables.SecGrps + ` sg_remote ON r.remote_group_id = sg_remote.secgrp_id` +
+			orderBy + limit + `;`
 	} else {
 		// Security groups only
 		query = `SELECT
 			s.secgrp_name as name,
 			s.secgrp_id as id,
 			s.project_id,
-			p.project_name,
-			'security-group' as resource_type,
-			'' as direction,
-			'' as protocol,
-			'' as port_range,
-			'' as remote_ip
+			p.project_name
 		FROM ` + cfg.Tables.SecGrps + ` s
-		JOIN ` + cfg.Tables.Projects + ` p USING (project_id)
-		ORDER BY s.secgrp_name;`
+		JOIN ` + cfg.Tables.Projects + ` p USING (project_id)` + orderBy + limit + `;`
+	}
+
+	// Find the rules passing protocol/port and source CIDR filtering
+	var groupIDs, ruleIDs map[string]bool
+	if filterRules {
+		groupIDs, ruleIDs, err = matchIngressRules(ctx, db, cfg, allowsSpec, allowsFrom)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Streaming formats write each group and rule as it is read; others
+	// collect the records. Nested rules are collected with all groups, since
+	// a group's rules may come before it, and the window applies to the groups.
+	var records []output.Record
+	window := newListWindow(limitInSQL)
+	add := func(r output.Record) error {
+		if filterRules && !matchesRules(r, groupIDs, ruleIDs) {
+			return nil
+		}
+		if !pf.Includes(r.String("project_name")) {
+			return nil
+		}
+		if nested {
+			records = append(records, r)
+			return nil
+		}
+		if !window.admit() {
+			return nil
+		}
+		if stream, ok := formatter.(output.RecordStreamer); ok {
+			return stream.WriteRecord(listRecord(columns, r))
+		}
+		records = append(records, r)
+		return nil
 	}
 
 	rows, err := db.QueryContext(ctx, query)
@@ -208,76 +257,80 @@ func Secgrps(db *sql.DB, cfg *config.Config) error {
 	}
 	defer rows.Close()
 
-	// Collect the data
-	var data [][]string
-	for rows.Next() {
-		var name, id, parentID, pid, pname, rtype, direction, protocol, portRange, remoteIP string
-		var ethertype, remoteGroupID, remoteGroupName string
+	for rows.Next() && !window.done() {
+		var name, id, parentID, pid, pname, rtype string
+		var direction, ethertype, protocol, remoteIP, remoteGroupID, remoteGroupName sql.NullString
+		var portMin, portMax sql.NullInt64
 
-		if rules && fullOutput {
-			if err := rows.Scan(&name, &id, &parentID, &pid, &pname, &rtype, &direction, &protocol, &portRange, &remoteIP, &ethertype, &remoteGroupID, &remoteGroupName); err != nil {
-				return err
-			}
-			row := []string{name, id, parentID, pid, pname, rtype}
-			row = append(row, direction, protocol, portRange, remoteIP, ethertype)
-			// Combine remote_group_id and remote_group_name for display
-			if remoteGroupID != "" && remoteGroupName != "" {
-				row = append(row, remoteGroupID+" ("+remoteGroupName+")")
-			} else if remoteGroupID != "" {
-				row = append(row, remoteGroupID)
-			} else {
-				row = append(row, "")
-			}
-			data = append(data, row)
-		} else if rules {
-			if err := rows.Scan(&name, &id, &parentID, &pid, &pname, &rtype, &direction, &protocol, &portRange, &remoteIP); err != nil {
+		if !rules {
+			// Security groups only - no parent_id column
+			if err := rows.Scan(&name, &id, &pid, &pname); err != nil {
 				return err
 			}
-			row := []string{name, id, parentID, pid, pname, rtype}
-			// Rule details are NEVER appended in basic rules mode (-r without --full)
-			// This mode only shows that rules exist (via resource_type), not their details
-			data = append(data, row)
-		} else {
-			// Security groups only - no parent_id column
-			if err := rows.Scan(&name, &id, &pid, &pname, &rtype, &direction, &protocol, &portRange, &remoteIP); err != nil {
+			if err := add(output.NewRecord("security-group",
+				output.Field{Key: "name", Value: name},
+				output.Field{Key: "id", Value: id},
+				output.Field{Key: "project_id", Value: pid},
+				output.Field{Key: "project_name", Value: pname},
+			)); err != nil {
 				return err
 			}
-			row := []string{name, id, pid, pname, rtype}
-			data = append(data, row)
+			continue
+		}
+
+		if err := rows.Scan(&name, &id, &parentID, &pid, &pname, &rtype, &direction, &ethertype, &protocol,
+			&portMin, &portMax, &remoteIP, &remoteGroupID, &remoteGroupName); err != nil {
+			return err
+		}
+		record := output.NewRecord(rtype,
+			output.Field{Key: "name", Value: name},
+			output.Field{Key: "id", Value: id},
+			output.Field{Key: "parent_id", Value: parentID},
+			output.Field{Key: "project_id", Value: pid},
+			output.Field{Key: "project_name", Value: pname},
+		)
+		// Rule details are only included with --full or --columns; basic rules
+		// mode only shows that rules exist (via the resource type), not their details
+		if ruleDetails && rtype == "security-group-rule" {
+			record.Fields = append(record.Fields,
+				output.Field{Key: "direction", Value: direction.String},
+				output.Field{Key: "ethertype", Value: ethertype.String},
+				output.Field{Key: "protocol", Value: nullStringValue(protocol)},
+				output.Field{Key: "port_range_min", Value: nullIntPtr(portMin)},
+				output.Field{Key: "port_range_max", Value: nullIntPtr(portMax)},
+				output.Field{Key: "remote_ip_prefix", Value: nullStringValue(remoteIP)},
+				output.Field{Key: "remote_group", Value: remoteGroupRecord(remoteGroupID, remoteGroupName)},
+			)
+		}
+		if err := add(record); err != nil {
+			return err
 		}
 	}
 
 	if err := rows.Err(); err != nil {
 		return err
 	}
-
-	// Apply project filtering
-	// When rules flag is set, parent_id column is added so project_name is at index 4
-	// Otherwise project_name is at index 3
-	projectNameIndex := 3
-	if rules {
-		projectNameIndex = 4
-	}
-	pf := filter.New(projectFilter, cfg)
-	filteredData, matchedProjectsMap := pf.MatchProjects(data, projectNameIndex)
-
-	// Create the output formatter
-	formatter, err := output.NewFormatter(outputFormat, os.Stdout)
-	if err != nil {
-		return err
+	if stream, ok := formatter.(output.RecordStreamer); ok && !nested {
+		return stream.Flush()
 	}
 
-	// Prepare output data with headers
-	var headers []string
-	if rules {
-		headers = []string{"Name", "ID", "Parent ID", "Project ID", "Project Name", "Resource Type"}
-		if fullOutput {
-			headers = append(headers, "Direction", "Protocol", "Port Range", "Remote IP", "Ethertype", "Remote Group")
+	if nested {
+		groupColumns, ruleColumns := nestedSecgrpColumns(columns)
+		var groups []output.Record
+		for _, g := range nestSecgrpRules(records, listKeys(groupColumns), listKeys(ruleColumns)) {
+			if window.admit() {
+				groups = append(groups, g)
+			}
 		}
-	} else {
-		headers = []string{"Name", "ID", "Project ID", "Project Name", "Resource Type"}
+		records = groups
+		columns = append(groupColumns, listField{Key: "rules", Header: "Rules"})
 	}
-	outputData := output.NewOutputData(headers, filteredData)
+
+	// Apply project filtering
+	filteredRecords, matchedProjectsMap := filter.MatchItems(pf, records, recordProjectName)
+
+	// Prepare output data with the selected columns
+	outputData := listRecordData(columns, filteredRecords)
 
 	// Add filtering metadata if filtering was applied
 	if pf.GetActiveFilter() != "" {
@@ -291,3 +344,249 @@ func Secgrps(db *sql.DB, cfg *config.Config) error {
 
 	return formatter.Format(outputData)
 }
+
+// writesRecords reports whether a formatter writes records rather than rows:
+// every format except table and CSV
+func writesRecords(formatter output.Formatter) bool {
+	switch formatter.(type) {
+	case *output.TableFormatter, *output.CSVFormatter:
+		return false
+	}
+	return true
+}
+
+// nestedSecgrpColumns splits the columns of rules mode into those of security
+// groups and those of the rules nested in them. Rules leave out the parent
+// and project fields of their group; groups leave out the rule details.
+func nestedSecgrpColumns(columns []listField) (groupColumns, ruleColumns []listField) {
+	for _, c := range columns {
+		switch {
+		case c.Key == "parent_id":
+		case slices.ContainsFunc(secgrpRuleFields, func(f listField) bool { return f.Key == c.Key }):
+			ruleColumns = append(ruleColumns, c)
+		case c.Key == "project_id" || c.Key == "project_name":
+			groupColumns = append(groupColumns, c)
+		default:
+			groupColumns = append(groupColumns, c)
+			ruleColumns = append(ruleColumns, c)
+		}
+	}
+	return groupColumns, ruleColumns
+}
+
+// listKeys returns the record fields the columns show
+func listKeys(columns []listField) []string {
+	var keys []string
+	for _, c := range columns {
+		keys = append(keys, c.recordFields()...)
+	}
+	return keys
+}
+
+// nestSecgrpRules returns the security group records, in order, each with
+// groupKeys and a "rules" field listing its rule records with ruleKeys.
+// Rules are matched to their group by parent_id; rules without their group
+// in records are left out.
+func nestSecgrpRules(records []output.Record, groupKeys, ruleKeys []string) []output.Record {
+	rulesByGroup := make(map[string][]output.Record)
+	for _, r := range records {
+		if r.Type == "security-group-rule" {
+			rule := r.Select(ruleKeys)
+			rule.Type = ""
+			parent := r.String("parent_id")
+			rulesByGroup[parent] = append(rulesByGroup[parent], rule)
+		}
+	}
+
+	var groups []output.Record
+	for _, r := range records {
+		if r.Type != "security-group" {
+			continue
+		}
+		group := r.Select(groupKeys)
+		groupRules := rulesByGroup[r.String("id")]
+		if groupRules == nil {
+			groupRules = []output.Record{}
+		}
+		group.Fields = append(group.Fields, output.Field{Key: "rules", Value: groupRules})
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// matchIngressRules finds the ingress rules allowing the given protocol/port spec from
+// the given CIDR, and returns the matching rule IDs and the IDs of their security groups.
+// An empty spec or CIDR matches any protocol/port or source respectively.
+func matchIngressRules(ctx context.Context, db *sql.DB, cfg *config.Config, spec, from string) (map[string]bool, map[string]bool, error) {
+	var portSpec *filter.PortSpec
+	if spec != "" {
+		ps, err := filter.ParsePortSpec(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		portSpec = ps
+	}
+
+	var fromPrefix netip.Prefix
+	if from != "" {
+		prefix, err := filter.ParsePrefix(from)
+		if err != nil {
+			return nil, nil, err
+		}
+		fromPrefix = prefix
+	}
+
+	query := `SELECT r.rule_id, r.secgrp_id, r.ethertype, COALESCE(r.protocol, ''),
+			r.port_range_min, r.port_range_max,
+			COALESCE(r.remote_ip_prefix, ''), COALESCE(r.remote_group_id, '')
+		FROM ` + cfg.Tables.SecGrpRules + ` r
+		WHERE r.direction = 'ingress';`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	groupIDs := make(map[string]bool)
+	ruleIDs := make(map[string]bool)
+	for rows.Next() {
+		var ruleID, secgrpID, ethertype, protocol, remoteIP, remoteGroupID string
+		var portMin, portMax sql.NullInt64
+		if err := rows.Scan(&ruleID, &secgrpID, &ethertype, &protocol, &portMin, &portMax, &remoteIP, &remoteGroupID); err != nil {
+			return nil, nil, err
+		}
+
+		if portSpec != nil && !portSpec.MatchesRule(protocol, nullIntPtr(portMin), nullIntPtr(portMax)) {
+			continue
+		}
+		// Rules with a remote group admit members of that group, not an address range
+		if from != "" && (remoteGroupID != "" || !filter.RuleAllowsFrom(ethertype, remoteIP, fromPrefix)) {
+			continue
+		}
+
+		ruleIDs[ruleID] = true
+		groupIDs[secgrpID] = true
+	}
+
+	return groupIDs, ruleIDs, rows.Err()
+}
+
+// matchesRules reports whether a security group record is in groupIDs or a
+// rule record in ruleIDs
+func matchesRules(r output.Record, groupIDs, ruleIDs map[string]bool) bool {
+	if r.Type == "security-group-rule" {
+		return ruleIDs[r.String("id")]
+	}
+	return groupIDs[r.String("id")]
+}
+
+// secgrpFields returns the columns of list secgrps. Rules mode sorts on the
+// columns of its union query; groups mode has no rule columns.
+func secgrpFields(rules bool) listFieldSet {
+	typeField := listField{Key: "type", Header: "Resource Type", Aliases: []string{"resource_type"}, Fields: []string{},
+		Format: func(r output.Record) string { return r.Type }}
+	if !rules {
+		return listFieldSet{fields: []listField{
+			{Key: "name", Header: "Name", Sort: "s.secgrp_name"},
+			{Key: "id", Header: "ID", Sort: "s.secgrp_id"},
+			{Key: "project_id", Header: "Project ID", Sort: "s.project_id"},
+			{Key: "project_name", Header: "Project Name", Aliases: []string{"project"}, Sort: "p.project_name"},
+			typeField,
+		}}
+	}
+	typeField.Sort = "resource_type"
+	fields := []listField{
+		{Key: "name", Header: "Name", Sort: "name"},
+		{Key: "id", Header: "ID", Sort: "id"},
+		{Key: "parent_id", Header: "Parent ID", Sort: "parent_id"},
+		{Key: "project_id", Header: "Project ID", Sort: "project_id"},
+		{Key: "project_name", Header: "Project Name", Aliases: []string{"project"}, Sort: "project_name"},
+		typeField,
+	}
+	return listFieldSet{fields: append(fields, secgrpRuleFields...)}
+}
+
+// secgrpRuleFields are the rule detail columns of --full. Security group
+// rows leave them empty; rules show "any" for unset protocols, ports and
+// remote IPs.
+var secgrpRuleFields = []listField{
+	{Key: "direction", Header: "Direction", Sort: "direction"},
+	{Key: "protocol", Header: "Protocol", Sort: "protocol",
+		Format: ruleColumn(func(r output.Record) string { return anyIfEmpty(r.String("protocol")) })},
+	{Key: "port_range", Header: "Port Range", Aliases: []string{"ports"}, Fields: []string{"port_range_min", "port_range_max"}, Sort: "port_range_min",
+		Format: ruleColumn(func(r output.Record) string {
+			return formatPortRange(r.Get("port_range_min").(*int), r.Get("port_range_max").(*int))
+		})},
+	{Key: "remote_ip_prefix", Header: "Remote IP", Aliases: []string{"remote_ip"}, Sort: "remote_ip_prefix",
+		Format: ruleColumn(func(r output.Record) string { return anyIfEmpty(r.String("remote_ip_prefix")) })},
+	{Key: "ethertype", Header: "Ethertype", Sort: "ethertype"},
+	{Key: "remote_group", Header: "Remote Group", Sort: "remote_group_name", Format: ruleColumn(func(r output.Record) string {
+		group, ok := r.Get("remote_group").(output.Record)
+		if !ok {
+			return ""
+		}
+		if name := group.String("name"); name != "" {
+			return group.String("id") + " (" + name + ")"
+		}
+		return group.String("id")
+	})},
+}
+
+// ruleColumn formats a column for rule records only
+func ruleColumn(format func(r output.Record) string) func(r output.Record) string {
+	return func(r output.Record) string {
+		if r.Type != "security-group-rule" {
+			return ""
+		}
+		return format(r)
+	}
+}
+
+// anyIfEmpty returns "any" for an unset rule value
+func anyIfEmpty(s string) string {
+	if s == "" {
+		return "any"
+	}
+	return s
+}
+
+// formatPortRange formats a rule port range as "any", a single port or "<min>-<max>"
+func formatPortRange(portMin, portMax *int) string {
+	switch {
+	case portMin == nil && portMax == nil:
+		return "any"
+	case portMin != nil && portMax != nil && *portMin == *portMax:
+		return strconv.Itoa(*portMin)
+	default:
+		return output.FormatValue(portMin) + "-" + output.FormatValue(portMax)
+	}
+}
+
+// remoteGroupRecord returns a rule's remote group as a record, or nil without one
+func remoteGroupRecord(id, name sql.NullString) any {
+	if id.String == "" {
+		return nil
+	}
+	return output.Record{Fields: []output.Field{
+		{Key: "id", Value: id.String},
+		{Key: "name", Value: name.String},
+	}}
+}
+
+// nullStringValue converts a nullable text column into a string, or nil when NULL or empty
+func nullStringValue(s sql.NullString) any {
+	if s.String == "" {
+		return nil
+	}
+	return s.String
+}
+
+// nullIntPtr converts a nullable integer column into an optional int
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
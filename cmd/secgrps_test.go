@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/marcdicarlo/osc/internal/output"
+)
+
+func TestNestedSecgrpColumns(t *testing.T) {
+	var columns []listField
+	for _, key := range []string{"name", "id", "parent_id", "project_id", "project_name", "type", "direction", "port_range"} {
+		f, err := secgrpFields(true).lookup(key)
+		if err != nil {
+			t.Fatalf("lookup(%q) error = %v", key, err)
+		}
+		columns = append(columns, f)
+	}
+
+	groupColumns, ruleColumns := nestedSecgrpColumns(columns)
+	if got, want := listKeys(groupColumns), []string{"name", "id", "project_id", "project_name"}; !slices.Equal(got, want) {
+		t.Errorf("Group keys = %v, want %v", got, want)
+	}
+	if got, want := listKeys(ruleColumns), []string{"name", "id", "direction", "port_range_min", "port_range_max"}; !slices.Equal(got, want) {
+		t.Errorf("Rule keys = %v, want %v", got, want)
+	}
+}
+
+func TestNestSecgrpRules(t *testing.T) {
+	rule := func(id, parent string) output.Record {
+		return output.NewRecord("security-group-rule",
+			output.Field{Key: "id", Value: id},
+			output.Field{Key: "parent_id", Value: parent},
+			output.Field{Key: "protocol", Value: "tcp"},
+		)
+	}
+	group := func(id string) output.Record {
+		return output.NewRecord("security-group",
+			output.Field{Key: "id", Value: id},
+			output.Field{Key: "parent_id", Value: id},
+			output.Field{Key: "project_name", Value: "alpha"},
+		)
+	}
+	// Rules may come before their group; rules of groups not listed are left out
+	records := []output.Record{rule("rule-1", "sg-1"), group("sg-2"), group("sg-1"), rule("rule-2", "sg-1"), rule("rule-3", "sg-9")}
+
+	groups := nestSecgrpRules(records, []string{"id", "project_name"}, []string{"id", "protocol"})
+	if len(groups) != 2 || groups[0].String("id") != "sg-2" || groups[1].String("id") != "sg-1" {
+		t.Fatalf("Expected groups sg-2 and sg-1, got %v", groups)
+	}
+	if groups[1].Get("parent_id") != nil {
+		t.Error("Expected groups without parent_id")
+	}
+	if rules := groups[0].Get("rules").([]output.Record); len(rules) != 0 {
+		t.Errorf("Expected no rules for sg-2, got %v", rules)
+	}
+
+	rules := groups[1].Get("rules").([]output.Record)
+	var ids []string
+	for _, r := range rules {
+		if r.Type != "" || r.Get("parent_id") != nil || r.String("protocol") != "tcp" {
+			t.Errorf("Unexpected nested rule %+v", r)
+		}
+		ids = append(ids, r.String("id"))
+	}
+	if !slices.Equal(ids, []string{"rule-1", "rule-2"}) {
+		t.Errorf("Rules of sg-1 = %v, want [rule-1 rule-2]", ids)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"context"
 	"database/sql"
 	"log"
+	"net/netip"
 	"os"
+	"strings"
 
 	"github.com/marcdicarlo/osc/internal/config"
 	"github.com/marcdicarlo/osc/internal/db"
@@ -18,6 +20,7 @@ import (
 
 var serversFullOutput bool
 var serversShowRules bool
+var serversCIDR string
 
 // serversCmd represents the servers command
 var serversCmd = &cobra.Command{
@@ -44,6 +47,15 @@ osc list servers -p "eta"     # matches: hc_zeta_project, hc_eta_project, hc_bet
 osc list servers -o json
 osc list servers -o csv
 osc list servers --rules -o json  # with security group names in JSON format
+
+# list servers with an IPv4 address inside a CIDR
+osc list servers --cidr 192.168.2.0/24
+
+# choose columns, including server metadata keys, and sort by status then name descending
+osc list servers --columns name,ip,status,flavor,metadata.owner --sort-by status,-name
+
+# list the second page of 20 servers
+osc list servers --limit 20 --offset 20
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.Load("config.yaml")
@@ -66,6 +78,27 @@ func init() {
 	serversCmd.Flags().StringVarP(&projectFilter, "project", "p", "", "Filter servers by project name (shows projects containing this string)")
 	serversCmd.Flags().BoolVarP(&serversShowRules, "rules", "r", false, "Include security group names attached to each server")
 	serversCmd.Flags().BoolVarP(&serversFullOutput, "full", "f", false, "Include security groups with IDs attached to each server")
+	serversCmd.Flags().StringVar(&serversCIDR, "cidr", "", "Only show servers with an IPv4 address inside this CIDR")
+}
+
+// serverFields are the columns of list servers
+var serverFields = listFieldSet{
+	fields: []listField{
+		{Key: "name", Header: "Server Name", Sort: "s.server_name"},
+		{Key: "id", Header: "Server ID", Sort: "s.server_id"},
+		{Key: "project_name", Header: "Project Name", Aliases: []string{"project"}, Sort: "p.project_name"},
+		{Key: "ip_address", Header: "IPv4 Address", Aliases: []string{"ip"}, Sort: "s.ipv4_addr"},
+		{Key: "status", Header: "Status", Sort: "s.status"},
+		{Key: "flavor_name", Header: "Flavor", Aliases: []string{"flavor"}, Sort: "s.flavor_name"},
+		{Key: "flavor_id", Header: "Flavor ID", Sort: "s.flavor_id"},
+		{Key: "image_name", Header: "Image", Aliases: []string{"image"}, Sort: "s.image_name"},
+		{Key: "image_id", Header: "Image ID", Sort: "s.image_id"},
+		{Key: "floating_ip", Header: "Floating IP", Sort: "s.floating_ip"},
+		{Key: "security_groups", Header: "Security Groups", Aliases: []string{"secgrps"}, Format: formatServerSecurityGroups},
+	},
+	metadata: func(key string) listField {
+		return listField{Key: "metadata." + key, Header: "metadata." + key, Sort: sqlJSONField("s.metadata", key)}
+	},
 }
 
 // Servers reads and outputs server/project data.
@@ -73,81 +106,161 @@ func Servers(db *sql.DB, cfg *config.Config) error {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
 	defer cancel()
 
+	if err := validateListWindow(); err != nil {
+		return err
+	}
+	defaults := []string{"name", "id", "project_name", "ip_address"}
+	if serversShowRules || serversFullOutput {
+		defaults = append(defaults, "security_groups")
+	}
+	columns, err := serverFields.selectColumns(defaults)
+	if err != nil {
+		return err
+	}
+	orderBy, err := serverFields.orderBy("s.server_name")
+	if err != nil {
+		return err
+	}
+
+	// The server details and metadata keys are only read for --columns
+	var metadataKeys []string
+	includeSecGroups := false
+	for _, c := range columns {
+		if key, ok := strings.CutPrefix(c.Key, "metadata."); ok {
+			metadataKeys = append(metadataKeys, key)
+		}
+		includeSecGroups = includeSecGroups || c.Key == "security_groups"
+	}
+	includeDetails := len(listColumns) > 0
+	selectColumns := `s.server_name, s.server_id, p.project_name, COALESCE(s.ipv4_addr, '')`
+	if includeDetails {
+		selectColumns += `, COALESCE(s.status, ''), COALESCE(s.flavor_name, ''), COALESCE(s.flavor_id, ''),
+		         COALESCE(s.image_name, ''), COALESCE(s.image_id, ''), COALESCE(s.floating_ip, '')`
+		for _, key := range metadataKeys {
+			selectColumns += ", " + sqlJSONField("s.metadata", key)
+		}
+	}
+
+	pf := filter.New(projectFilter, cfg)
+	// --limit and --offset go into the query unless servers are filtered after it
+	limitInSQL := serversCIDR == "" && !pf.Excludes()
+	limit := ""
+	if limitInSQL {
+		limit = sqlLimit()
+	}
+
 	// Build query based on flags
 	var query string
-	includeSecGroups := serversShowRules || serversFullOutput
 	if includeSecGroups {
-		// Query with security groups using GROUP_CONCAT
-		var secgrpFormat string
-		if serversFullOutput {
-			// Full output: ID (name)
-			secgrpFormat = `sg.secgrp_id || ' (' || sg.secgrp_name || ')'`
-		} else {
-			// Rules output: name only
-			secgrpFormat = `sg.secgrp_name`
-		}
-		query = `SELECT s.server_name, s.server_id, p.project_name, COALESCE(s.ipv4_addr, ''),
-		         COALESCE(GROUP_CONCAT(` + secgrpFormat + `, ', '), '')
+		// Query with security groups using GROUP_CONCAT, each as "<id>\x1f<name>"
+		// and separated by "\x1e"
+		query = `SELECT ` + selectColumns + `,
+		         COALESCE(GROUP_CONCAT(sg.secgrp_id || char(31) || sg.secgrp_name, char(30)), '')
 		FROM ` + cfg.Tables.Servers + ` s
 		JOIN ` + cfg.Tables.Projects + ` p USING (project_id)
 		LEFT JOIN ` + cfg.Tables.ServerSecGrps + ` ssg ON s.server_id = ssg.server_id
 		LEFT JOIN ` + cfg.Tables.SecGrps + ` sg ON ssg.secgrp_id = sg.secgrp_id
-		GROUP BY s.server_id
-		ORDER BY s.server_name;`
+		GROUP BY s.server_id` + orderBy + limit + `;`
 	} else {
 		// Basic query without security groups
-		query = `SELECT s.server_name, s.server_id, p.project_name, COALESCE(s.ipv4_addr, '')
+		query = `SELECT ` + selectColumns + `
 		FROM ` + cfg.Tables.Servers + ` s
-		JOIN ` + cfg.Tables.Projects + ` p USING (project_id)
-		ORDER BY s.server_name;`
+		JOIN ` + cfg.Tables.Projects + ` p USING (project_id)` + orderBy + limit + `;`
 	}
 
+	// Create the output formatter
+	formatter, err := output.NewFormatter(outputFormat, os.Stdout)
+	if err != nil {
+		return err
+	}
+	// Streaming formats write each server as it is read
+	stream, streaming := formatter.(output.RecordStreamer)
+
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
+	// Apply CIDR filtering on the IPv4 address
+	var prefix netip.Prefix
+	if serversCIDR != "" {
+		prefix, err = filter.ParsePrefix(serversCIDR)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Collect the data
-	var data [][]string
-	for rows.Next() {
+	var records []output.Record
+	window := newListWindow(limitInSQL)
+	for rows.Next() && !window.done() {
+		var name, id, pname, ipv4, secgrps string
+		var status, flavorName, flavorID, imageName, imageID, floatingIP string
+		metadata := make([]sql.NullString, len(metadataKeys))
+		dest := []any{&name, &id, &pname, &ipv4}
+		if includeDetails {
+			dest = append(dest, &status, &flavorName, &flavorID, &imageName, &imageID, &floatingIP)
+			for i := range metadata {
+				dest = append(dest, &metadata[i])
+			}
+		}
 		if includeSecGroups {
-			var name, id, pname, ipv4, secgrps string
-			if err := rows.Scan(&name, &id, &pname, &ipv4, &secgrps); err != nil {
-				return err
+			dest = append(dest, &secgrps)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if serversCIDR != "" && !filter.CIDRContainsAddr(prefix, ipv4) {
+			continue
+		}
+		if !pf.Includes(pname) || !window.admit() {
+			continue
+		}
+
+		record := output.NewRecord("server",
+			output.Field{Key: "name", Value: name},
+			output.Field{Key: "id", Value: id},
+			output.Field{Key: "project_name", Value: pname},
+			output.Field{Key: "ip_address", Value: ipv4},
+		)
+		if includeDetails {
+			record.Fields = append(record.Fields,
+				output.Field{Key: "status", Value: status},
+				output.Field{Key: "flavor_name", Value: flavorName},
+				output.Field{Key: "flavor_id", Value: flavorID},
+				output.Field{Key: "image_name", Value: imageName},
+				output.Field{Key: "image_id", Value: imageID},
+				output.Field{Key: "floating_ip", Value: floatingIP},
+			)
+			for i, key := range metadataKeys {
+				record.Fields = append(record.Fields, output.Field{Key: "metadata." + key, Value: nullStringValue(metadata[i])})
 			}
-			data = append(data, []string{name, id, pname, ipv4, secgrps})
-		} else {
-			var name, id, pname, ipv4 string
-			if err := rows.Scan(&name, &id, &pname, &ipv4); err != nil {
+		}
+		if includeSecGroups {
+			record.Fields = append(record.Fields, output.Field{Key: "security_groups", Value: serverSecurityGroups(secgrps)})
+		}
+		if streaming {
+			if err := stream.WriteRecord(listRecord(columns, record)); err != nil {
 				return err
 			}
-			data = append(data, []string{name, id, pname, ipv4})
+			continue
 		}
+		records = append(records, record)
 	}
 
 	if err := rows.Err(); err != nil {
 		return err
 	}
-
-	// Apply project filtering
-	pf := filter.New(projectFilter, cfg)
-	filteredData, matchedProjectsMap := pf.MatchProjects(data, 2) // 2 is the index of project_name in our data
-
-	// Create the output formatter
-	formatter, err := output.NewFormatter(outputFormat, os.Stdout)
-	if err != nil {
-		return err
+	if streaming {
+		return stream.Flush()
 	}
 
-	// Build headers based on flags
-	headers := []string{"Server Name", "Server ID", "Project Name", "IPv4 Address"}
-	if includeSecGroups {
-		headers = append(headers, "Security Groups")
-	}
+	// Apply project filtering
+	filteredRecords, matchedProjectsMap := filter.MatchItems(pf, records, recordProjectName)
 
-	// Prepare output data with headers and filtering info
-	outputData := output.NewOutputData(headers, filteredData)
+	// Prepare output data with the selected columns and filtering info
+	outputData := listRecordData(columns, filteredRecords)
 
 	// Add filtering metadata if filtering was applied
 	if pf.GetActiveFilter() != "" {
@@ -161,3 +274,37 @@ func Servers(db *sql.DB, cfg *config.Config) error {
 
 	return formatter.Format(outputData)
 }
+
+// serverSecurityGroups parses the concatenated security groups of a server.
+// With --full each group is a record with its ID and name, otherwise its name.
+func serverSecurityGroups(concatenated string) any {
+	names := []string{}
+	groups := []output.Record{}
+	if concatenated != "" {
+		for _, group := range strings.Split(concatenated, "\x1e") {
+			id, name, _ := strings.Cut(group, "\x1f")
+			names = append(names, name)
+			groups = append(groups, output.Record{Fields: []output.Field{
+				{Key: "id", Value: id},
+				{Key: "name", Value: name},
+			}})
+		}
+	}
+	if serversFullOutput {
+		return groups
+	}
+	return names
+}
+
+// formatServerSecurityGroups shows security groups by name, or as "<id> (<name>)" with --full
+func formatServerSecurityGroups(r output.Record) string {
+	groups, ok := r.Get("security_groups").([]output.Record)
+	if !ok {
+		return r.String("security_groups")
+	}
+	parts := make([]string, len(groups))
+	for i, g := range groups {
+		parts[i] = g.String("id") + " (" + g.String("name") + ")"
+	}
+	return strings.Join(parts, ", ")
+}
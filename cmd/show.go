@@ -38,3 +38,10 @@ osc show secgrp web-servers -o csv`,
 func init() {
 	rootCmd.AddCommand(showCmd)
 }
+
+// RefJSON is a resource referenced by ID and name in JSON output, such as a
+// server's security group
+type RefJSON struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
@@ -3,15 +3,16 @@ package cmd
 import (
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/marcdicarlo/osc/internal/config"
 	"github.com/marcdicarlo/osc/internal/db"
+	"github.com/marcdicarlo/osc/internal/output"
 	"github.com/spf13/cobra"
 )
 
@@ -218,24 +219,27 @@ func fetchSecGrpServers(ctx context.Context, database *sql.DB, cfg *config.Confi
 }
 
 func outputSecGrpDetails(secgrps []SecGrpDetail) error {
-	switch outputFormat {
-	case "json":
-		return outputSecGrpJSON(secgrps)
-	case "csv":
-		return outputSecGrpCSV(secgrps)
-	default:
-		return outputSecGrpTable(secgrps)
-	}
+	return output.FormatDocument(outputFormat, os.Stdout, &output.Document{
+		Value:   secGrpJSON(secgrps),
+		Data:    secGrpCSVData(secgrps),
+		NameKey: "secgrp_name",
+		IDKey:   "secgrp_id",
+		// show has always escaped HTML characters in its JSON
+		EscapeHTML: true,
+		Renderers: map[output.Format]func(io.Writer) error{
+			output.FormatTable: func(w io.Writer) error { return outputSecGrpTable(w, secgrps) },
+		},
+	})
 }
 
 // SecGrpJSON is the JSON output structure for a security group
 type SecGrpJSON struct {
-	SecGrpName  string        `json:"secgrp_name"`
-	SecGrpID    string        `json:"secgrp_id"`
-	ProjectID   string        `json:"project_id"`
-	ProjectName string        `json:"project_name"`
-	Rules       []RuleJSON    `json:"rules"`
-	Servers     []string      `json:"servers"`
+	SecGrpName  string     `json:"secgrp_name"`
+	SecGrpID    string     `json:"secgrp_id"`
+	ProjectID   string     `json:"project_id"`
+	ProjectName string     `json:"project_name"`
+	Rules       []RuleJSON `json:"rules"`
+	Servers     []string   `json:"servers"`
 }
 
 // RuleJSON is the JSON output structure for a security group rule
@@ -251,8 +255,9 @@ type RuleJSON struct {
 	RemoteGroupName string `json:"remote_group_name,omitempty"`
 }
 
-func outputSecGrpJSON(secgrps []SecGrpDetail) error {
-	var output []SecGrpJSON
+// secGrpJSON converts security groups to their JSON output structure
+func secGrpJSON(secgrps []SecGrpDetail) []SecGrpJSON {
+	var result []SecGrpJSON
 	for _, sg := range secgrps {
 		sj := SecGrpJSON{
 			SecGrpName:  sg.SecGrpName,
@@ -279,23 +284,20 @@ func outputSecGrpJSON(secgrps []SecGrpDetail) error {
 		for _, srv := range sg.Servers {
 			sj.Servers = append(sj.Servers, fmt.Sprintf("%s (%s)", srv.ID, srv.Name))
 		}
-		output = append(output, sj)
+		result = append(result, sj)
 	}
 
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(output)
+	return result
 }
 
-func outputSecGrpCSV(secgrps []SecGrpDetail) error {
-	writer := csv.NewWriter(os.Stdout)
-	defer writer.Flush()
-
-	// Write header
-	if err := writer.Write([]string{"secgrp_name", "secgrp_id", "project_id", "project_name", "rules", "servers"}); err != nil {
-		return err
+// secGrpCSVData flattens security groups to one record each for CSV output
+func secGrpCSVData(secgrps []SecGrpDetail) *output.OutputData {
+	var columns []output.Column
+	for _, key := range []string{"secgrp_name", "secgrp_id", "project_id", "project_name", "rules", "servers"} {
+		columns = append(columns, output.Column{Header: key, Key: key})
 	}
 
+	var records []output.Record
 	for _, sg := range secgrps {
 		// Serialize rules to JSON for CSV
 		rulesJSON := "[]"
@@ -312,32 +314,30 @@ func outputSecGrpCSV(secgrps []SecGrpDetail) error {
 			serverList = append(serverList, fmt.Sprintf("%s (%s)", srv.ID, srv.Name))
 		}
 
-		if err := writer.Write([]string{
-			sg.SecGrpName,
-			sg.SecGrpID,
-			sg.ProjectID,
-			sg.ProjectName,
-			rulesJSON,
-			strings.Join(serverList, ", "),
-		}); err != nil {
-			return err
-		}
+		records = append(records, output.NewRecord("security-group",
+			output.Field{Key: "secgrp_name", Value: sg.SecGrpName},
+			output.Field{Key: "secgrp_id", Value: sg.SecGrpID},
+			output.Field{Key: "project_id", Value: sg.ProjectID},
+			output.Field{Key: "project_name", Value: sg.ProjectName},
+			output.Field{Key: "rules", Value: rulesJSON},
+			output.Field{Key: "servers", Value: serverList},
+		))
 	}
-	return nil
+	return output.NewRecordData(columns, records)
 }
 
-func outputSecGrpTable(secgrps []SecGrpDetail) error {
+func outputSecGrpTable(w io.Writer, secgrps []SecGrpDetail) error {
 	for i, sg := range secgrps {
 		if i > 0 {
-			fmt.Println() // Separator between multiple security groups
+			fmt.Fprintln(w) // Separator between multiple security groups
 		}
-		fmt.Printf("Security Group: %s\n", sg.SecGrpName)
-		fmt.Printf("  ID:      %s\n", sg.SecGrpID)
-		fmt.Printf("  Project: %s (%s)\n", sg.ProjectName, sg.ProjectID)
+		fmt.Fprintf(w, "Security Group: %s\n", sg.SecGrpName)
+		fmt.Fprintf(w, "  ID:      %s\n", sg.SecGrpID)
+		fmt.Fprintf(w, "  Project: %s (%s)\n", sg.ProjectName, sg.ProjectID)
 
-		fmt.Printf("\n  Rules:\n")
+		fmt.Fprintf(w, "\n  Rules:\n")
 		if len(sg.Rules) == 0 {
-			fmt.Printf("    (none)\n")
+			fmt.Fprintf(w, "    (none)\n")
 		} else {
 			for _, rule := range sg.Rules {
 				// Format port range
@@ -371,17 +371,17 @@ func outputSecGrpTable(secgrps []SecGrpDetail) error {
 					preposition = "to"
 				}
 
-				fmt.Printf("    %-7s %-4s %-10s %s %s\n",
+				fmt.Fprintf(w, "    %-7s %-4s %-10s %s %s\n",
 					direction, rule.Protocol, portStr, preposition, remote)
 			}
 		}
 
-		fmt.Printf("\n  Servers Using This Group:\n")
+		fmt.Fprintf(w, "\n  Servers Using This Group:\n")
 		if len(sg.Servers) == 0 {
-			fmt.Printf("    (none)\n")
+			fmt.Fprintf(w, "    (none)\n")
 		} else {
 			for _, srv := range sg.Servers {
-				fmt.Printf("    - %s (%s)\n", srv.Name, srv.ID)
+				fmt.Fprintf(w, "    - %s (%s)\n", srv.Name, srv.ID)
 			}
 		}
 	}
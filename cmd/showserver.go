@@ -3,15 +3,16 @@ package cmd
 import (
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/marcdicarlo/osc/internal/config"
 	"github.com/marcdicarlo/osc/internal/db"
+	"github.com/marcdicarlo/osc/internal/output"
 	"github.com/spf13/cobra"
 )
 
@@ -217,14 +218,17 @@ func fetchServerVolumes(ctx context.Context, database *sql.DB, cfg *config.Confi
 }
 
 func outputServerDetails(servers []ServerDetail) error {
-	switch outputFormat {
-	case "json":
-		return outputServerJSON(servers)
-	case "csv":
-		return outputServerCSV(servers)
-	default:
-		return outputServerTable(servers)
-	}
+	return output.FormatDocument(outputFormat, os.Stdout, &output.Document{
+		Value:   serverJSON(servers),
+		Data:    serverCSVData(servers),
+		NameKey: "server",
+		IDKey:   "server_id",
+		// show has always escaped HTML characters in its JSON
+		EscapeHTML: true,
+		Renderers: map[output.Format]func(io.Writer) error{
+			output.FormatTable: func(w io.Writer) error { return outputServerTable(w, servers) },
+		},
+	})
 }
 
 // ServerJSON is the JSON output structure for a server
@@ -240,11 +244,12 @@ type ServerJSON struct {
 	FlavorID       string            `json:"flavor_id"`
 	FlavorName     string            `json:"flavor_name"`
 	Metadata       map[string]string `json:"metadata,omitempty"`
-	SecurityGroups []string          `json:"security_groups"`
+	SecurityGroups []RefJSON         `json:"security_groups"`
 }
 
-func outputServerJSON(servers []ServerDetail) error {
-	var output []ServerJSON
+// serverJSON converts servers to their JSON output structure
+func serverJSON(servers []ServerDetail) []ServerJSON {
+	var result []ServerJSON
 	for _, srv := range servers {
 		sj := ServerJSON{
 			Server:         srv.ServerName,
@@ -258,29 +263,25 @@ func outputServerJSON(servers []ServerDetail) error {
 			FlavorID:       srv.FlavorID,
 			FlavorName:     srv.FlavorName,
 			Metadata:       srv.Metadata,
-			SecurityGroups: make([]string, 0, len(srv.SecurityGroups)),
+			SecurityGroups: make([]RefJSON, 0, len(srv.SecurityGroups)),
 		}
 		for _, sg := range srv.SecurityGroups {
-			sj.SecurityGroups = append(sj.SecurityGroups, fmt.Sprintf("%s (%s)", sg.ID, sg.Name))
+			sj.SecurityGroups = append(sj.SecurityGroups, RefJSON{ID: sg.ID, Name: sg.Name})
 		}
-		output = append(output, sj)
+		result = append(result, sj)
 	}
-
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(output)
+	return result
 }
 
-func outputServerCSV(servers []ServerDetail) error {
-	writer := csv.NewWriter(os.Stdout)
-	defer writer.Flush()
-
-	// Write header
-	if err := writer.Write([]string{"server", "server_id", "status", "project_id", "project_name",
-		"ipv4_addr", "image_id", "image_name", "flavor_id", "flavor_name", "metadata", "security_groups"}); err != nil {
-		return err
+// serverCSVData flattens servers to one record each for CSV output
+func serverCSVData(servers []ServerDetail) *output.OutputData {
+	var columns []output.Column
+	for _, key := range []string{"server", "server_id", "status", "project_id", "project_name",
+		"ipv4_addr", "image_id", "image_name", "flavor_id", "flavor_name", "metadata", "security_groups"} {
+		columns = append(columns, output.Column{Header: key, Key: key})
 	}
 
+	var records []output.Record
 	for _, srv := range servers {
 		var sgList []string
 		for _, sg := range srv.SecurityGroups {
@@ -296,81 +297,79 @@ func outputServerCSV(servers []ServerDetail) error {
 			}
 		}
 
-		if err := writer.Write([]string{
-			srv.ServerName,
-			srv.ServerID,
-			srv.Status,
-			srv.ProjectID,
-			srv.ProjectName,
-			srv.IPv4Addr,
-			srv.ImageID,
-			srv.ImageName,
-			srv.FlavorID,
-			srv.FlavorName,
-			metadataStr,
-			strings.Join(sgList, ", "),
-		}); err != nil {
-			return err
-		}
+		records = append(records, output.NewRecord("server",
+			output.Field{Key: "server", Value: srv.ServerName},
+			output.Field{Key: "server_id", Value: srv.ServerID},
+			output.Field{Key: "status", Value: srv.Status},
+			output.Field{Key: "project_id", Value: srv.ProjectID},
+			output.Field{Key: "project_name", Value: srv.ProjectName},
+			output.Field{Key: "ipv4_addr", Value: srv.IPv4Addr},
+			output.Field{Key: "image_id", Value: srv.ImageID},
+			output.Field{Key: "image_name", Value: srv.ImageName},
+			output.Field{Key: "flavor_id", Value: srv.FlavorID},
+			output.Field{Key: "flavor_name", Value: srv.FlavorName},
+			output.Field{Key: "metadata", Value: metadataStr},
+			output.Field{Key: "security_groups", Value: sgList},
+		))
 	}
-	return nil
+	return output.NewRecordData(columns, records)
 }
 
-func outputServerTable(servers []ServerDetail) error {
+func outputServerTable(w io.Writer, servers []ServerDetail) error {
 	for i, srv := range servers {
 		if i > 0 {
-			fmt.Println() // Separator between multiple servers
+			fmt.Fprintln(w) // Separator between multiple servers
 		}
-		fmt.Printf("Server: %s\n", srv.ServerName)
-		fmt.Printf("  ID:           %s\n", srv.ServerID)
-		fmt.Printf("  Status:       %s\n", srv.Status)
-		fmt.Printf("  Project:      %s (%s)\n", srv.ProjectName, srv.ProjectID)
-		fmt.Printf("  IPv4 Address: %s\n", srv.IPv4Addr)
+		fmt.Fprintf(w, "Server: %s\n", srv.ServerName)
+		fmt.Fprintf(w, "  ID:           %s\n", srv.ServerID)
+		fmt.Fprintf(w, "  Status:       %s\n", srv.Status)
+		fmt.Fprintf(w, "  Project:      %s (%s)\n", srv.ProjectName, srv.ProjectID)
+		fmt.Fprintf(w, "  IPv4 Address: %s\n", srv.IPv4Addr)
 
 		// Image info
 		if srv.ImageID != "" || srv.ImageName != "" {
 			if srv.ImageName != "" && srv.ImageID != "" {
-				fmt.Printf("  Image:        %s (%s)\n", srv.ImageName, srv.ImageID)
+				fmt.Fprintf(w, "  Image:        %s (%s)\n", srv.ImageName, srv.ImageID)
 			} else if srv.ImageName != "" {
-				fmt.Printf("  Image:        %s\n", srv.ImageName)
+				fmt.Fprintf(w, "  Image:        %s\n", srv.ImageName)
 			} else {
-				fmt.Printf("  Image:        %s\n", srv.ImageID)
+				fmt.Fprintf(w, "  Image:        %s\n", srv.ImageID)
 			}
 		}
 
 		// Flavor info
 		if srv.FlavorID != "" || srv.FlavorName != "" {
 			if srv.FlavorName != "" && srv.FlavorID != "" {
-				fmt.Printf("  Flavor:       %s (%s)\n", srv.FlavorName, srv.FlavorID)
+				fmt.Fprintf(w, "  Flavor:       %s (%s)\n", srv.FlavorName, srv.FlavorID)
 			} else if srv.FlavorName != "" {
-				fmt.Printf("  Flavor:       %s\n", srv.FlavorName)
+				fmt.Fprintf(w, "  Flavor:       %s\n", srv.FlavorName)
 			} else {
-				fmt.Printf("  Flavor:       %s\n", srv.FlavorID)
+				fmt.Fprintf(w, "  Flavor:       %s\n", srv.FlavorID)
 			}
 		}
 
 		// Metadata
-		fmt.Printf("\n  Metadata:\n")
+		fmt.Fprintf(w, "\n  Metadata:\n")
 		if len(srv.Metadata) == 0 {
-			fmt.Printf("    (none)\n")
+			fmt.Fprintf(w, "    (none)\n")
 		} else {
 			for key, value := range srv.Metadata {
-				fmt.Printf("    %s: %s\n", key, value)
+				fmt.Fprintf(w, "    %s: %s\n", key, value)
 			}
 		}
 
-		fmt.Printf("\n  Security Groups:\n")
+		fmt.Fprintf(w, "\n  Security Groups:\n")
 		if len(srv.SecurityGroups) == 0 {
-			fmt.Printf("    (none)\n")
+			fmt.Fprintf(w, "    (none)\n")
 		} else {
 			for _, sg := range srv.SecurityGroups {
-				fmt.Printf("    - %s (%s)\n", sg.Name, sg.ID)
+				fmt.Fprintf(w, "    - %s (%s)\n", sg.Name, sg.ID)
 			}
 		}
 
-		fmt.Printf("\n  Volumes:\n")
+		fmt.Fprintf(w, "\n  Volumes:\n")
 		if len(srv.Volumes) == 0 {
-			fmt.Printf("    (none)\n")
+			fmt.Fprintf(w, "    (none)\n")
 		} else {
 			for _, vol := range srv.Volumes {
 				volType := vol.VolumeType
@@ -378,10 +377,10 @@ func outputServerTable(servers []ServerDetail) error {
 					volType = "default"
 				}
 				if vol.DevicePath != "" {
-					fmt.Printf("    - %s (%s): %dGB %s @ %s\n",
+					fmt.Fprintf(w, "    - %s (%s): %dGB %s @ %s\n",
 						vol.Name, vol.ID, vol.SizeGB, volType, vol.DevicePath)
 				} else {
-					fmt.Printf("    - %s (%s): %dGB %s\n",
+					fmt.Fprintf(w, "    - %s (%s): %dGB %s\n",
 						vol.Name, vol.ID, vol.SizeGB, volType)
 				}
 			}
@@ -13,7 +13,7 @@ type Config struct {
 	ProjectFilter string        `yaml:"project_filter"`
 	DBFile        string        `yaml:"db_file"`
 	DBTimeout     time.Duration `yaml:"db_timeout"`
-	Tables struct {
+	Tables        struct {
 		Projects      string `yaml:"projects_table"`
 		Servers       string `yaml:"servers_table"`
 		SecGrps       string `yaml:"secgrps_table"`
@@ -21,14 +21,25 @@ type Config struct {
 		Volumes       string `yaml:"volumes_table"`
 		ServerSecGrps string `yaml:"server_secgrps_table"`
 		ServerVolumes string `yaml:"server_volumes_table"`
+		// Drift history written by osc drift check; not cleared by sync
+		DriftRuns        string `yaml:"drift_runs_table"`
+		DriftRunProjects string `yaml:"drift_run_projects_table"`
+		DriftItems       string `yaml:"drift_items_table"`
 	} `yaml:"tables"`
 	OpenStack struct {
-		ComputeService  string `yaml:"compute_service"`
-		IdentityService string `yaml:"identity_service"`
-		AllTenants      bool   `yaml:"all_tenants"`
-		MaxWorkers      int    `yaml:"max_workers"`       // Maximum concurrent workers for API calls (default: 10)
+		ComputeService  string        `yaml:"compute_service"`
+		IdentityService string        `yaml:"identity_service"`
+		AllTenants      bool          `yaml:"all_tenants"`
+		MaxWorkers      int           `yaml:"max_workers"`    // Maximum concurrent workers for API calls and drift check projects (default: 10)
 		WorkerTimeout   time.Duration `yaml:"worker_timeout"` // Timeout for individual worker API calls (default: 30s)
 	} `yaml:"openstack"`
+	Exposure struct {
+		PublicCIDRs []string `yaml:"public_cidrs"` // Source ranges treated as public in addition to 0.0.0.0/0 and ::/0
+	} `yaml:"exposure"`
+	Drift struct {
+		ServerFields []string `yaml:"server_fields"` // Server fields compared by drift check (default: all)
+		MatchByName  bool     `yaml:"match_by_name"` // Pair resources recreated with a new ID by name
+	} `yaml:"drift"`
 }
 
 // Load loads the configuration from the given file
@@ -55,6 +66,14 @@ func Load(file string) (*Config, error) {
 	return &cfg, nil
 }
 
+// Default returns the configuration Load returns for a config file that sets
+// nothing, e.g. for tests
+func Default() *Config {
+	var cfg Config
+	cfg.applyDefaults()
+	return &cfg
+}
+
 // applyDefaults sets default values for optional configuration fields
 func (c *Config) applyDefaults() {
 	// Default to 10 concurrent workers if not specified
@@ -67,7 +86,19 @@ func (c *Config) applyDefaults() {
 		c.OpenStack.WorkerTimeout = 30 * time.Second
 	}
 
-	// Default table names for new tables (backward compatibility)
+	// Default table names, also for tables added after a config was written
+	if c.Tables.Projects == "" {
+		c.Tables.Projects = "os_project_names"
+	}
+	if c.Tables.Servers == "" {
+		c.Tables.Servers = "os_servers"
+	}
+	if c.Tables.SecGrps == "" {
+		c.Tables.SecGrps = "os_security_groups"
+	}
+	if c.Tables.SecGrpRules == "" {
+		c.Tables.SecGrpRules = "os_security_group_rules"
+	}
 	if c.Tables.Volumes == "" {
 		c.Tables.Volumes = "os_volumes"
 	}
@@ -77,4 +108,13 @@ func (c *Config) applyDefaults() {
 	if c.Tables.ServerVolumes == "" {
 		c.Tables.ServerVolumes = "os_server_volumes"
 	}
+	if c.Tables.DriftRuns == "" {
+		c.Tables.DriftRuns = "os_drift_runs"
+	}
+	if c.Tables.DriftRunProjects == "" {
+		c.Tables.DriftRunProjects = "os_drift_run_projects"
+	}
+	if c.Tables.DriftItems == "" {
+		c.Tables.DriftItems = "os_drift_items"
+	}
 }
@@ -53,6 +53,7 @@ func MigrateSchema(ctx context.Context, db *sql.DB, cfg *config.Config) error {
 			flavor_id   TEXT,
 			flavor_name TEXT,
 			metadata    TEXT,
+			floating_ip TEXT,
 			FOREIGN KEY(project_id) REFERENCES ` + cfg.Tables.Projects + `(project_id) ON DELETE CASCADE
 		)`,
 		`CREATE TABLE IF NOT EXISTS ` + cfg.Tables.SecGrps + ` (
@@ -96,6 +97,36 @@ func MigrateSchema(ctx context.Context, db *sql.DB, cfg *config.Config) error {
 			FOREIGN KEY(server_id) REFERENCES ` + cfg.Tables.Servers + `(server_id) ON DELETE CASCADE,
 			FOREIGN KEY(volume_id) REFERENCES ` + cfg.Tables.Volumes + `(volume_id) ON DELETE CASCADE
 		)`,
+		`CREATE TABLE IF NOT EXISTS ` + cfg.Tables.DriftRuns + ` (
+			run_id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			started_at     TEXT NOT NULL,
+			base_path      TEXT NOT NULL,
+			total_projects INTEGER NOT NULL,
+			total_drift    INTEGER NOT NULL,
+			ignored        INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + cfg.Tables.DriftRunProjects + ` (
+			run_id       INTEGER NOT NULL,
+			project_name TEXT NOT NULL,
+			drift_count  INTEGER NOT NULL,
+			ignored      INTEGER NOT NULL,
+			PRIMARY KEY (run_id, project_name),
+			FOREIGN KEY(run_id) REFERENCES ` + cfg.Tables.DriftRuns + `(run_id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + cfg.Tables.DriftItems + ` (
+			run_id        INTEGER NOT NULL,
+			project_name  TEXT NOT NULL,
+			resource_type TEXT NOT NULL,
+			resource_id   TEXT NOT NULL,
+			resource_name TEXT,
+			parent_sg     TEXT,
+			status        TEXT NOT NULL,
+			details       TEXT,
+			address       TEXT,
+			changes       TEXT,
+			FOREIGN KEY(run_id) REFERENCES ` + cfg.Tables.DriftRuns + `(run_id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + cfg.Tables.DriftItems + `_run_idx ON ` + cfg.Tables.DriftItems + ` (run_id, project_name)`,
 	}
 	for _, s := range stmts {
 		if _, err := db.ExecContext(ctx, s); err != nil {
@@ -116,6 +147,7 @@ func MigrateSchema(ctx context.Context, db *sql.DB, cfg *config.Config) error {
 		{"flavor_id", "TEXT"},
 		{"flavor_name", "TEXT"},
 		{"metadata", "TEXT"},
+		{"floating_ip", "TEXT"},
 	}
 	for _, col := range serverColumns {
 		if err := addColumnIfNotExists(ctx, db, cfg.Tables.Servers, col.name, col.colType); err != nil {
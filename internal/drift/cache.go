@@ -0,0 +1,336 @@
+package drift
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/secgrp"
+)
+
+// CacheTruthLoader builds truth resources directly from the osc cache database
+// instead of reading truth files. Projects are matched the same way as
+// osc drift generate: exactly the mapped project when the directory has an
+// osc-project.yaml, otherwise the project directory name is the project filter.
+type CacheTruthLoader struct {
+	DB  *sql.DB
+	Cfg *config.Config
+}
+
+// NewCacheTruthLoader creates a CacheTruthLoader for the given database
+func NewCacheTruthLoader(database *sql.DB, cfg *config.Config) *CacheTruthLoader {
+	return &CacheTruthLoader{DB: database, Cfg: cfg}
+}
+
+// Load returns the truth resources for a project directory
+func (l *CacheTruthLoader) Load(project ProjectDir) ([]Resource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.Cfg.DBTimeout)
+	defer cancel()
+
+	if project.Config != nil {
+		if err := project.Config.CheckCloud(); err != nil {
+			return nil, err
+		}
+	}
+
+	projects, err := MatchCachedProjects(ctx, l.DB, l.Cfg, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read projects from cache: %w", err)
+	}
+	if len(projects) == 0 {
+		return nil, nil
+	}
+	projectIDs := newProjectIDFilter(projects)
+
+	var resources []Resource
+
+	servers, err := l.loadServers(ctx, projectIDs, project.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read servers from cache: %w", err)
+	}
+	resources = append(resources, servers...)
+
+	secgrps, err := l.loadSecurityGroups(ctx, projectIDs, project.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read security groups from cache: %w", err)
+	}
+	resources = append(resources, secgrps...)
+
+	rules, err := l.loadSecurityGroupRules(ctx, projectIDs, project.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read security group rules from cache: %w", err)
+	}
+	resources = append(resources, rules...)
+
+	volumes, err := l.loadVolumes(ctx, projectIDs, project.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read volumes from cache: %w", err)
+	}
+	resources = append(resources, volumes...)
+
+	return resources, nil
+}
+
+// MatchCachedProjects returns the names of the cached projects that belong to a
+// project directory, by project ID
+func MatchCachedProjects(ctx context.Context, database *sql.DB, cfg *config.Config, project ProjectDir) (map[string]string, error) {
+	rows, err := database.QueryContext(ctx, `SELECT project_id, project_name FROM `+cfg.Tables.Projects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		if project.MatchesProject(id, name, cfg) {
+			projects[id] = name
+		}
+	}
+	return projects, rows.Err()
+}
+
+// projectIDFilter restricts cache queries to the projects of a project
+// directory with a "project_id IN (...)" condition
+type projectIDFilter struct {
+	placeholders string
+	args         []any
+}
+
+// newProjectIDFilter creates the filter for the given projects, by ID
+func newProjectIDFilter(projects map[string]string) projectIDFilter {
+	ids := make([]string, 0, len(projects))
+	for id := range projects {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	f := projectIDFilter{args: make([]any, len(ids))}
+	for i, id := range ids {
+		f.args[i] = id
+	}
+	f.placeholders = "(" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")"
+	return f
+}
+
+// where returns the condition on a project ID column, e.g. "sg.project_id"
+func (f projectIDFilter) where(column string) string {
+	return " WHERE " + column + " IN " + f.placeholders
+}
+
+// loadServers reads servers and their attached security group names. The
+// group IDs are kept so that state referencing groups by ID can be resolved.
+func (l *CacheTruthLoader) loadServers(ctx context.Context, projectIDs projectIDFilter, projectName string) ([]Resource, error) {
+	secgrpsByServer := make(map[string][]string)
+	secgrpIDsByServer := make(map[string]map[string]string)
+	sgRows, err := l.DB.QueryContext(ctx, `SELECT ssg.server_id, sg.secgrp_id, sg.secgrp_name
+	FROM `+l.Cfg.Tables.ServerSecGrps+` ssg
+	JOIN `+l.Cfg.Tables.Servers+` s ON ssg.server_id = s.server_id
+	JOIN `+l.Cfg.Tables.SecGrps+` sg ON ssg.secgrp_id = sg.secgrp_id`+
+		projectIDs.where("s.project_id")+`
+	ORDER BY sg.secgrp_name;`, projectIDs.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer sgRows.Close()
+	for sgRows.Next() {
+		var serverID, sgID, sgName string
+		if err := sgRows.Scan(&serverID, &sgID, &sgName); err != nil {
+			return nil, err
+		}
+		secgrpsByServer[serverID] = append(secgrpsByServer[serverID], sgName)
+		if secgrpIDsByServer[serverID] == nil {
+			secgrpIDsByServer[serverID] = make(map[string]string)
+		}
+		secgrpIDsByServer[serverID][sgID] = sgName
+	}
+	if err := sgRows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err := l.DB.QueryContext(ctx, `SELECT server_id, server_name, COALESCE(ipv4_addr, ''),
+	       COALESCE(status, ''), COALESCE(flavor_id, ''), COALESCE(flavor_name, ''),
+	       COALESCE(image_id, ''), COALESCE(image_name, ''), COALESCE(metadata, ''),
+	       COALESCE(floating_ip, '')
+	FROM `+l.Cfg.Tables.Servers+
+		projectIDs.where("project_id")+`
+	ORDER BY server_name;`, projectIDs.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resources []Resource
+	for rows.Next() {
+		var id, name, ipv4, status, flavorID, flavorName, imageID, imageName, metadataJSON, floatingIP string
+		if err := rows.Scan(&id, &name, &ipv4, &status, &flavorID, &flavorName, &imageID, &imageName, &metadataJSON, &floatingIP); err != nil {
+			return nil, err
+		}
+
+		metadata := make(map[string]string)
+		if metadataJSON != "" {
+			if err := json.Unmarshal([]byte(metadataJSON), &metadata); err != nil {
+				return nil, fmt.Errorf("invalid metadata for server %s: %w", id, err)
+			}
+		}
+
+		resources = append(resources, Resource{
+			ID:             id,
+			Name:           name,
+			Type:           ResourceTypeServer,
+			ProjectName:    projectName,
+			SecurityGroups: secgrpsByServer[id],
+			Properties: map[string]any{
+				"ip_address":           ipv4,
+				"power_state":          status,
+				"flavor_id":            flavorID,
+				"flavor_name":          flavorName,
+				"image_id":             imageID,
+				"image_name":           imageName,
+				"metadata":             metadata,
+				"floating_ip":          floatingIP,
+				"security_group_names": secgrpIDsByServer[id],
+			},
+		})
+	}
+	return resources, rows.Err()
+}
+
+// loadSecurityGroups reads security groups
+func (l *CacheTruthLoader) loadSecurityGroups(ctx context.Context, projectIDs projectIDFilter, projectName string) ([]Resource, error) {
+	rows, err := l.DB.QueryContext(ctx, `SELECT secgrp_id, secgrp_name
+	FROM `+l.Cfg.Tables.SecGrps+
+		projectIDs.where("project_id")+`
+	ORDER BY secgrp_name;`, projectIDs.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resources []Resource
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		resources = append(resources, Resource{
+			ID:          id,
+			Name:        name,
+			Type:        ResourceTypeSecurityGroup,
+			ProjectName: projectName,
+		})
+	}
+	return resources, rows.Err()
+}
+
+// loadSecurityGroupRules reads security group rules with their full properties
+func (l *CacheTruthLoader) loadSecurityGroupRules(ctx context.Context, projectIDs projectIDFilter, projectName string) ([]Resource, error) {
+	rows, err := l.DB.QueryContext(ctx, `SELECT r.rule_id, sg.secgrp_id, sg.secgrp_name,
+	       r.direction, r.ethertype, COALESCE(r.protocol, ''), r.port_range_min, r.port_range_max,
+	       COALESCE(r.remote_ip_prefix, ''), COALESCE(r.remote_group_id, '')
+	FROM `+l.Cfg.Tables.SecGrpRules+` r
+	JOIN `+l.Cfg.Tables.SecGrps+` sg ON r.secgrp_id = sg.secgrp_id`+
+		projectIDs.where("sg.project_id")+`
+	ORDER BY sg.secgrp_name, r.rule_id;`, projectIDs.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resources []Resource
+	for rows.Next() {
+		var id, parentID, parentName, direction, ethertype, protocol, remoteIP, remoteGroup string
+		var portMin, portMax sql.NullInt64
+		if err := rows.Scan(&id, &parentID, &parentName, &direction, &ethertype, &protocol,
+			&portMin, &portMax, &remoteIP, &remoteGroup); err != nil {
+			return nil, err
+		}
+		resources = append(resources, Resource{
+			ID:          id,
+			Type:        ResourceTypeSecurityGroupRule,
+			ProjectName: projectName,
+			ParentID:    parentID,
+			ParentName:  parentName,
+			Properties: map[string]any{
+				"direction":      direction,
+				"ethertype":      ethertype,
+				"protocol":       protocol,
+				"port_range":     secgrp.FormatPortRange(nullInt(portMin), nullInt(portMax)),
+				"port_range_min": nullInt(portMin),
+				"port_range_max": nullInt(portMax),
+				"remote_ip":      remoteIP,
+				"remote_group":   remoteGroup,
+			},
+		})
+	}
+	return resources, rows.Err()
+}
+
+// loadVolumes reads volumes and their server attachments
+func (l *CacheTruthLoader) loadVolumes(ctx context.Context, projectIDs projectIDFilter, projectName string) ([]Resource, error) {
+	rows, err := l.DB.QueryContext(ctx, `SELECT v.volume_id, v.volume_name, v.size_gb,
+	       COALESCE(v.volume_type, ''), COALESCE(sv.server_id, ''), COALESCE(sv.device_path, '')
+	FROM `+l.Cfg.Tables.Volumes+` v
+	LEFT JOIN `+l.Cfg.Tables.ServerVolumes+` sv ON v.volume_id = sv.volume_id`+
+		projectIDs.where("v.project_id")+`
+	ORDER BY v.volume_name, sv.server_id;`, projectIDs.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resources []Resource
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var id, name, volumeType, serverID, device string
+		var size int
+		if err := rows.Scan(&id, &name, &size, &volumeType, &serverID, &device); err != nil {
+			return nil, err
+		}
+		// Multi-attached volumes appear once per server
+		if !seen[id] {
+			seen[id] = true
+			resources = append(resources, Resource{
+				ID:          id,
+				Name:        name,
+				Type:        ResourceTypeVolume,
+				ProjectName: projectName,
+				Properties: map[string]any{
+					"size":        strconv.Itoa(size),
+					"volume_type": volumeType,
+				},
+			})
+		}
+		if serverID != "" {
+			resources = append(resources, Resource{
+				ID:          volumeAttachmentID(serverID, id),
+				Name:        name,
+				Type:        ResourceTypeVolumeAttachment,
+				ProjectName: projectName,
+				Properties: map[string]any{
+					"server_id": serverID,
+					"volume_id": id,
+					"device":    device,
+				},
+			})
+		}
+	}
+	return resources, rows.Err()
+}
+
+// nullInt converts a nullable database integer to an int pointer
+func nullInt(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
@@ -0,0 +1,96 @@
+package drift
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/db"
+)
+
+// newTestDB creates an empty cache database in a temporary directory
+func newTestDB(t *testing.T) (*sql.DB, *config.Config) {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.DBFile = filepath.Join(t.TempDir(), "cache.db")
+	cfg.DBTimeout = 5 * time.Second
+
+	database, err := db.InitDB(cfg)
+	if err != nil {
+		t.Fatalf("Failed to init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database, cfg
+}
+
+func TestCacheTruthLoader(t *testing.T) {
+	database, cfg := newTestDB(t)
+
+	stmts := []string{
+		`INSERT INTO os_project_names VALUES ('p1', 'alpha'), ('p2', 'beta')`,
+		`INSERT INTO os_servers (server_id, server_name, project_id, ipv4_addr) VALUES ('srv-1', 'web', 'p1', '10.0.0.1'), ('srv-2', 'db', 'p2', '10.0.0.2')`,
+		`INSERT INTO os_security_groups VALUES ('sg-1', 'default', 'p1'), ('sg-2', 'default', 'p2')`,
+		`INSERT INTO os_security_group_rules VALUES ('rule-1', 'sg-1', 'ingress', 'IPv4', 'tcp', 22, 22, '0.0.0.0/0', NULL),
+			('rule-2', 'sg-2', 'ingress', 'IPv4', 'tcp', 443, 443, '0.0.0.0/0', NULL)`,
+		`INSERT INTO os_server_secgrps VALUES ('srv-1', 'sg-1'), ('srv-2', 'sg-2')`,
+		`INSERT INTO os_volumes VALUES ('vol-3', 'logs', 5, NULL, 'p2')`,
+		`INSERT INTO os_volumes VALUES ('vol-1', 'data', 100, 'SSD', 'p1'), ('vol-2', 'spare', 10, NULL, 'p1')`,
+		`INSERT INTO os_server_volumes VALUES ('srv-1', 'vol-1', '/dev/vdb'), ('srv-2', 'vol-1', '/dev/vdb')`,
+	}
+	for _, stmt := range stmts {
+		if _, err := database.Exec(stmt); err != nil {
+			t.Fatalf("Failed to insert test data: %v", err)
+		}
+	}
+
+	truth, err := NewCacheTruthLoader(database, cfg).Load(ProjectDir{Name: "alpha"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	counts := CountResources(truth)
+	if counts.Servers != 1 || counts.SecurityGroups != 1 || counts.SecurityGroupRules != 1 ||
+		counts.Volumes != 2 || counts.VolumeAttachments != 2 {
+		t.Fatalf("Expected 1 server, 1 security group, 1 rule, 2 volumes and 2 attachments, got %+v", counts)
+	}
+
+	for _, res := range truth {
+		if res.ProjectName != "alpha" {
+			t.Errorf("Expected project alpha, got %s", res.ProjectName)
+		}
+		switch res.Type {
+		case ResourceTypeServer:
+			if len(res.SecurityGroups) != 1 || res.SecurityGroups[0] != "default" {
+				t.Errorf("Expected security groups [default], got %v", res.SecurityGroups)
+			}
+		case ResourceTypeVolumeAttachment:
+			server := getPropertyString(res.Properties, "server_id")
+			if res.ID != server+"/vol-1" || (server != "srv-1" && server != "srv-2") {
+				t.Errorf("Unexpected attachment %+v", res)
+			}
+		case ResourceTypeSecurityGroupRule:
+			if res.ParentID != "sg-1" || getPropertyString(res.Properties, "port_range") != "22" {
+				t.Errorf("Unexpected rule %+v", res)
+			}
+		}
+	}
+
+	// The cached rule should match an identical Terraform rule
+	state := []Resource{{
+		ID:       "rule-1",
+		Type:     ResourceTypeSecurityGroupRule,
+		ParentID: "sg-1",
+		Properties: map[string]any{
+			"direction": "ingress", "ethertype": "IPv4", "protocol": "tcp", "port_range": "22:22",
+			"remote_ip_prefix": "0.0.0.0/0", "remote_group_id": "",
+		},
+	}}
+	for _, d := range CompareResources(state, truth) {
+		if d.ResourceID == "rule-1" {
+			t.Errorf("Expected no drift for rule-1, got %s: %s", d.Status, d.Details)
+		}
+	}
+}
@@ -4,21 +4,90 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/marcdicarlo/osc/internal/secgrp"
+)
+
+// Server fields that can be compared for drift
+const (
+	ServerFieldName           = "name"
+	ServerFieldSecurityGroups = "security_groups"
+	ServerFieldFlavor         = "flavor"
+	ServerFieldImage          = "image"
+	ServerFieldMetadata       = "metadata"
+	ServerFieldPowerState     = "power_state"
+	ServerFieldFloatingIP     = "floating_ip"
 )
 
+// AllServerFields lists every server field compared by default
+var AllServerFields = []string{
+	ServerFieldName,
+	ServerFieldSecurityGroups,
+	ServerFieldFlavor,
+	ServerFieldImage,
+	ServerFieldMetadata,
+	ServerFieldPowerState,
+	ServerFieldFloatingIP,
+}
+
+// CompareOptions controls which properties count as drift
+type CompareOptions struct {
+	// ServerFields holds the server fields to compare
+	ServerFields map[string]bool
+	// MatchByName pairs resources missing from state with resources missing
+	// from truth that share a type, project, name and parent security group,
+	// and reports them as a single recreated drift
+	MatchByName bool
+}
+
+// DefaultCompareOptions compares all supported properties
+func DefaultCompareOptions() CompareOptions {
+	opts, _ := NewCompareOptions(nil)
+	return opts
+}
+
+// NewCompareOptions creates CompareOptions for the given server fields.
+// An empty list selects all fields; unknown field names are an error.
+func NewCompareOptions(serverFields []string) (CompareOptions, error) {
+	if len(serverFields) == 0 {
+		serverFields = AllServerFields
+	}
+
+	known := make(map[string]bool, len(AllServerFields))
+	for _, f := range AllServerFields {
+		known[f] = true
+	}
+
+	opts := CompareOptions{ServerFields: make(map[string]bool, len(serverFields))}
+	for _, f := range serverFields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if !known[f] {
+			return CompareOptions{}, fmt.Errorf("unknown server drift field %q (valid: %s)", f, strings.Join(AllServerFields, ", "))
+		}
+		opts.ServerFields[f] = true
+	}
+	return opts, nil
+}
+
 // CompareResources compares state and truth resources and returns drift results
 func CompareResources(state, truth []Resource) []DiffResult {
+	return CompareResourcesWithOptions(state, truth, DefaultCompareOptions())
+}
+
+// CompareResourcesWithOptions compares state and truth resources using opts
+func CompareResourcesWithOptions(state, truth []Resource, opts CompareOptions) []DiffResult {
 	var results []DiffResult
 
 	// Group resources by type for comparison
-	stateByType := groupByType(state)
+	stateByType := groupByType(resolveSecurityGroupIDs(state, truth))
 	truthByType := groupByType(truth)
 
 	// Compare servers
 	serverDiffs := compareResourcesByType(
 		stateByType[ResourceTypeServer],
 		truthByType[ResourceTypeServer],
-		compareServerProperties,
+		opts.compareServerProperties,
+		opts.MatchByName,
 	)
 	results = append(results, serverDiffs...)
 
@@ -27,6 +96,7 @@ func CompareResources(state, truth []Resource) []DiffResult {
 		stateByType[ResourceTypeSecurityGroup],
 		truthByType[ResourceTypeSecurityGroup],
 		compareSecurityGroupProperties,
+		opts.MatchByName,
 	)
 	results = append(results, secGroupDiffs...)
 
@@ -35,17 +105,40 @@ func CompareResources(state, truth []Resource) []DiffResult {
 		stateByType[ResourceTypeSecurityGroupRule],
 		truthByType[ResourceTypeSecurityGroupRule],
 		compareSecurityGroupRuleProperties,
+		opts.MatchByName,
 	)
 	results = append(results, ruleDiffs...)
 
+	// Compare volumes
+	volumeDiffs := compareResourcesByType(
+		stateByType[ResourceTypeVolume],
+		truthByType[ResourceTypeVolume],
+		compareVolumeProperties,
+		opts.MatchByName,
+	)
+	results = append(results, volumeDiffs...)
+
+	// Compare volume attachments
+	attachmentDiffs := compareResourcesByType(
+		stateByType[ResourceTypeVolumeAttachment],
+		truthByType[ResourceTypeVolumeAttachment],
+		compareVolumeAttachmentProperties,
+		opts.MatchByName,
+	)
+	results = append(results, attachmentDiffs...)
+
 	return results
 }
 
-// PropertyComparer is a function that compares two resources and returns diff details
-type PropertyComparer func(stateRes, truthRes *Resource) (DriftStatus, string)
+// PropertyComparer is a function that compares two resources and returns the changed fields.
+// The first change determines the status of the drift, so comparers report the most
+// specific change first.
+type PropertyComparer func(stateRes, truthRes *Resource) []FieldChange
 
-// compareResourcesByType compares resources of a specific type using ID-based matching
-func compareResourcesByType(stateResources, truthResources []Resource, propComparer PropertyComparer) []DiffResult {
+// compareResourcesByType compares resources of a specific type using ID-based matching.
+// With matchByName, resources left unmatched by ID are paired by name; see pairByName.
+// Results follow the order of the input resources.
+func compareResourcesByType(stateResources, truthResources []Resource, propComparer PropertyComparer, matchByName bool) []DiffResult {
 	var results []DiffResult
 
 	// Create maps for O(1) lookup
@@ -59,40 +152,83 @@ func compareResourcesByType(stateResources, truthResources []Resource, propCompa
 		truthByID[truthResources[i].ID] = &truthResources[i]
 	}
 
-	// Find resources in state but not in truth
-	for id, stateRes := range stateByID {
-		if _, exists := truthByID[id]; !exists {
-			results = append(results, DiffResult{
-				ResourceType: stateRes.Type,
-				ResourceName: stateRes.Name,
-				ResourceID:   stateRes.ID,
-				ProjectName:  stateRes.ProjectName,
-				ParentSG:     getParentSG(stateRes),
-				Status:       StatusMissingInTruth,
-				Details:      "Resource exists in Terraform state but not in OpenStack",
-			})
+	var onlyInState, onlyInTruth []*Resource
+	for i := range stateResources {
+		if _, exists := truthByID[stateResources[i].ID]; !exists {
+			onlyInState = append(onlyInState, &stateResources[i])
 		}
 	}
-
-	// Find resources in truth but not in state
-	for id, truthRes := range truthByID {
-		if _, exists := stateByID[id]; !exists {
-			results = append(results, DiffResult{
-				ResourceType: truthRes.Type,
-				ResourceName: truthRes.Name,
-				ResourceID:   truthRes.ID,
-				ProjectName:  truthRes.ProjectName,
-				ParentSG:     getParentSG(truthRes),
-				Status:       StatusMissingInState,
-				Details:      "Resource exists in OpenStack but not in Terraform state",
-			})
+	for i := range truthResources {
+		if _, exists := stateByID[truthResources[i].ID]; !exists {
+			onlyInTruth = append(onlyInTruth, &truthResources[i])
 		}
 	}
 
+	var recreated [][2]*Resource
+	if matchByName {
+		recreated, onlyInState, onlyInTruth = pairByName(onlyInState, onlyInTruth)
+	}
+
+	// Resources in state but not in truth
+	for _, stateRes := range onlyInState {
+		results = append(results, DiffResult{
+			ResourceType: stateRes.Type,
+			ResourceName: stateRes.Name,
+			ResourceID:   stateRes.ID,
+			ProjectName:  stateRes.ProjectName,
+			ParentSG:     getParentSG(stateRes),
+			Status:       StatusMissingInTruth,
+			Details:      "Resource exists in Terraform state but not in OpenStack",
+			Address:      stateRes.Address,
+			ModulePath:   stateRes.ModulePath,
+			Index:        stateRes.Index,
+		})
+	}
+
+	// Resources in truth but not in state
+	for _, truthRes := range onlyInTruth {
+		results = append(results, DiffResult{
+			ResourceType: truthRes.Type,
+			ResourceName: truthRes.Name,
+			ResourceID:   truthRes.ID,
+			ProjectName:  truthRes.ProjectName,
+			ParentSG:     getParentSG(truthRes),
+			Status:       StatusMissingInState,
+			Details:      "Resource exists in OpenStack but not in Terraform state",
+		})
+	}
+
+	// Resources recreated with a new ID; the drift carries the new ID
+	for _, pair := range recreated {
+		stateRes, truthRes := pair[0], pair[1]
+		changes := append([]FieldChange{{
+			Field:  "id",
+			Status: StatusRecreated,
+			Detail: fmt.Sprintf("recreated: ID %s -> %s", stateRes.ID, truthRes.ID),
+		}}, propComparer(stateRes, truthRes)...)
+		status, details := summarizeChanges(changes)
+		results = append(results, DiffResult{
+			ResourceType: truthRes.Type,
+			ResourceName: truthRes.Name,
+			ResourceID:   truthRes.ID,
+			PreviousID:   stateRes.ID,
+			ProjectName:  truthRes.ProjectName,
+			ParentSG:     getParentSG(truthRes),
+			Status:       status,
+			Details:      details,
+			Changes:      changes,
+			Address:      stateRes.Address,
+			ModulePath:   stateRes.ModulePath,
+			Index:        stateRes.Index,
+		})
+	}
+
 	// Compare matching resources for property changes
-	for id, stateRes := range stateByID {
-		if truthRes, exists := truthByID[id]; exists {
-			if status, details := propComparer(stateRes, truthRes); status != "" {
+	for i := range stateResources {
+		stateRes := &stateResources[i]
+		if truthRes, exists := truthByID[stateRes.ID]; exists {
+			if changes := propComparer(stateRes, truthRes); len(changes) > 0 {
+				status, details := summarizeChanges(changes)
 				results = append(results, DiffResult{
 					ResourceType: stateRes.Type,
 					ResourceName: stateRes.Name,
@@ -101,6 +237,10 @@ func compareResourcesByType(stateResources, truthResources []Resource, propCompa
 					ParentSG:     getParentSG(stateRes),
 					Status:       status,
 					Details:      details,
+					Changes:      changes,
+					Address:      stateRes.Address,
+					ModulePath:   stateRes.ModulePath,
+					Index:        stateRes.Index,
 				})
 			}
 		}
@@ -109,20 +249,125 @@ func compareResourcesByType(stateResources, truthResources []Resource, propCompa
 	return results
 }
 
-// compareServerProperties compares server properties between state and truth
-func compareServerProperties(stateRes, truthRes *Resource) (DriftStatus, string) {
-	var changes []string
+// pairByName pairs resources missing from truth with resources missing from state
+// on (project, name, parent security group). Only keys with exactly one resource on
+// each side are paired, so ambiguous names are never guessed at and the result does
+// not depend on input order. Unnamed resources, such as rules, are never paired.
+// It returns the (state, truth) pairs and the resources left unpaired.
+func pairByName(onlyInState, onlyInTruth []*Resource) (pairs [][2]*Resource, state, truth []*Resource) {
+	key := func(r *Resource) string {
+		return r.ProjectName + "\x00" + r.Name + "\x00" + getParentSG(r)
+	}
+
+	stateByKey := make(map[string][]*Resource)
+	for _, r := range onlyInState {
+		if r.Name != "" {
+			stateByKey[key(r)] = append(stateByKey[key(r)], r)
+		}
+	}
+	truthByKey := make(map[string][]*Resource)
+	for _, r := range onlyInTruth {
+		if r.Name != "" {
+			truthByKey[key(r)] = append(truthByKey[key(r)], r)
+		}
+	}
+
+	paired := make(map[*Resource]bool)
+	for _, r := range onlyInState {
+		k := key(r)
+		if r.Name == "" || len(stateByKey[k]) != 1 || len(truthByKey[k]) != 1 {
+			continue
+		}
+		pairs = append(pairs, [2]*Resource{r, truthByKey[k][0]})
+		paired[r] = true
+		paired[truthByKey[k][0]] = true
+	}
+
+	for _, r := range onlyInState {
+		if !paired[r] {
+			state = append(state, r)
+		}
+	}
+	for _, r := range onlyInTruth {
+		if !paired[r] {
+			truth = append(truth, r)
+		}
+	}
+	return pairs, state, truth
+}
+
+// summarizeChanges returns the status of the first change and all change details joined
+func summarizeChanges(changes []FieldChange) (DriftStatus, string) {
+	details := make([]string, len(changes))
+	for i, c := range changes {
+		details[i] = c.Detail
+	}
+	return changes[0].Status, strings.Join(details, "; ")
+}
+
+// compareServerProperties compares the selected server properties between state and truth.
+// Properties the truth source doesn't provide are skipped.
+func (opts CompareOptions) compareServerProperties(stateRes, truthRes *Resource) []FieldChange {
+	var changes []FieldChange
 
 	// Check name change
-	if stateRes.Name != truthRes.Name {
-		changes = append(changes, fmt.Sprintf("name: %q -> %q", stateRes.Name, truthRes.Name))
+	if opts.ServerFields[ServerFieldName] && stateRes.Name != truthRes.Name {
+		changes = append(changes, nameChange(stateRes, truthRes))
+	}
+
+	// Check flavor change, by ID when both sides have one
+	if opts.ServerFields[ServerFieldFlavor] {
+		if detail := compareServerRef(stateRes, truthRes, "flavor", "flavor_id", "flavor_name"); detail != "" {
+			changes = append(changes, FieldChange{Field: ServerFieldFlavor, Status: StatusFlavorChanged, Detail: detail})
+		}
+	}
+
+	// Check image change, by ID when both sides have one
+	if opts.ServerFields[ServerFieldImage] {
+		if detail := compareServerRef(stateRes, truthRes, "image", "image_id", "image_name"); detail != "" {
+			changes = append(changes, FieldChange{Field: ServerFieldImage, Status: StatusImageChanged, Detail: detail})
+		}
+	}
+
+	// Check power state change (Terraform only records it when power_state is set)
+	if opts.ServerFields[ServerFieldPowerState] {
+		statePower := strings.ToLower(getPropertyString(stateRes.Properties, "power_state"))
+		truthPower := strings.ToLower(getPropertyString(truthRes.Properties, "power_state"))
+		if statePower != "" && truthPower != "" && statePower != truthPower {
+			changes = append(changes, FieldChange{
+				Field:  ServerFieldPowerState,
+				Status: StatusPowerStateChanged,
+				Detail: fmt.Sprintf("power_state: %q -> %q", statePower, truthPower),
+			})
+		}
+	}
+
+	// Check floating IP change (only known when the association is in the state)
+	if opts.ServerFields[ServerFieldFloatingIP] {
+		stateFIP := getPropertyString(stateRes.Properties, "floating_ip")
+		truthFIP, ok := truthRes.Properties["floating_ip"].(string)
+		if stateFIP != "" && ok && stateFIP != truthFIP {
+			changes = append(changes, FieldChange{
+				Field:  ServerFieldFloatingIP,
+				Status: StatusFloatingIPChanged,
+				Detail: fmt.Sprintf("floating_ip: %q -> %q", stateFIP, truthFIP),
+			})
+		}
+	}
+
+	// Check metadata key/value changes
+	if opts.ServerFields[ServerFieldMetadata] {
+		if truthMeta, ok := truthRes.Properties["metadata"].(map[string]string); ok {
+			stateMeta, _ := stateRes.Properties["metadata"].(map[string]string)
+			changes = append(changes, diffMetadata(stateMeta, truthMeta)...)
+		}
 	}
 
 	// Check security group changes
 	stateSGs := normalizeSecurityGroups(stateRes.SecurityGroups)
 	truthSGs := normalizeSecurityGroups(truthRes.SecurityGroups)
 
-	if !stringSlicesEqual(stateSGs, truthSGs) {
+	if opts.ServerFields[ServerFieldSecurityGroups] && !stringSlicesEqual(stateSGs, truthSGs) {
 		added, removed := diffStringSlices(stateSGs, truthSGs)
 		if len(added) > 0 || len(removed) > 0 {
 			var sgChanges []string
@@ -132,35 +377,256 @@ func compareServerProperties(stateRes, truthRes *Resource) (DriftStatus, string)
 			if len(removed) > 0 {
 				sgChanges = append(sgChanges, fmt.Sprintf("removed: %v", removed))
 			}
-			changes = append(changes, fmt.Sprintf("security_groups: %s", strings.Join(sgChanges, ", ")))
+			changes = append(changes, FieldChange{
+				Field:  ServerFieldSecurityGroups,
+				Status: StatusSecGroupChanged,
+				Detail: fmt.Sprintf("security_groups: %s", strings.Join(sgChanges, ", ")),
+			})
+		}
+	}
+
+	return changes
+}
+
+// nameChange describes a resource name change
+func nameChange(stateRes, truthRes *Resource) FieldChange {
+	return FieldChange{
+		Field:  "name",
+		Status: StatusNameChanged,
+		Detail: fmt.Sprintf("name: %q -> %q", stateRes.Name, truthRes.Name),
+	}
+}
+
+// compareServerRef compares a flavor or image reference. IDs are compared when
+// both sides have one, otherwise names. Returns an empty string when unchanged
+// or when the truth source has neither.
+func compareServerRef(stateRes, truthRes *Resource, field, idKey, nameKey string) string {
+	stateID := getPropertyString(stateRes.Properties, idKey)
+	truthID := getPropertyString(truthRes.Properties, idKey)
+	stateName := getPropertyString(stateRes.Properties, nameKey)
+	truthName := getPropertyString(truthRes.Properties, nameKey)
+
+	switch {
+	case stateID != "" && truthID != "":
+		if stateID == truthID {
+			return ""
 		}
+		return fmt.Sprintf("%s: %q -> %q", field, displayRef(stateName, stateID), displayRef(truthName, truthID))
+	case stateName != "" && truthName != "":
+		if stateName == truthName {
+			return ""
+		}
+		return fmt.Sprintf("%s: %q -> %q", field, stateName, truthName)
 	}
+	return ""
+}
 
-	if len(changes) == 0 {
-		return "", ""
+// displayRef prefers a name over an ID for display
+func displayRef(name, id string) string {
+	if name != "" {
+		return name
 	}
+	return id
+}
 
-	// Determine the most specific status
-	if stateRes.Name != truthRes.Name {
-		return StatusNameChanged, strings.Join(changes, "; ")
+// diffMetadata returns one change per added, removed or changed metadata key, sorted by key.
+// Fields are named metadata.<key> so that individual keys can be ignored.
+func diffMetadata(state, truth map[string]string) []FieldChange {
+	keys := make(map[string]bool)
+	for k := range state {
+		keys[k] = true
 	}
-	return StatusSecGroupChanged, strings.Join(changes, "; ")
+	for k := range truth {
+		keys[k] = true
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	var changes []FieldChange
+	for _, k := range sortedKeys {
+		sv, inState := state[k]
+		tv, inTruth := truth[k]
+		field := ServerFieldMetadata + "." + k
+
+		var detail string
+		switch {
+		case !inState:
+			detail = fmt.Sprintf("%s: added %q", field, tv)
+		case !inTruth:
+			detail = fmt.Sprintf("%s: removed", field)
+		case sv != tv:
+			detail = fmt.Sprintf("%s: %q -> %q", field, sv, tv)
+		default:
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, Status: StatusMetadataChanged, Detail: detail})
+	}
+	return changes
 }
 
 // compareSecurityGroupProperties compares security group properties
-func compareSecurityGroupProperties(stateRes, truthRes *Resource) (DriftStatus, string) {
+func compareSecurityGroupProperties(stateRes, truthRes *Resource) []FieldChange {
 	if stateRes.Name != truthRes.Name {
-		return StatusNameChanged, fmt.Sprintf("name: %q -> %q", stateRes.Name, truthRes.Name)
+		return []FieldChange{nameChange(stateRes, truthRes)}
 	}
-	return "", ""
+	return nil
 }
 
-// compareSecurityGroupRuleProperties compares security group rule properties
-// Note: We only match rules by ID; we don't compare detailed properties like
-// direction, protocol, port_range, etc. since the truth file doesn't include them.
-func compareSecurityGroupRuleProperties(stateRes, truthRes *Resource) (DriftStatus, string) {
-	// Only match by ID - don't compare rule properties
-	return "", ""
+// compareVolumeProperties compares volume size, volume type and name, in order of significance
+func compareVolumeProperties(stateRes, truthRes *Resource) []FieldChange {
+	var changes []FieldChange
+
+	stateSize := getPropertyString(stateRes.Properties, "size")
+	truthSize := getPropertyString(truthRes.Properties, "size")
+	if stateSize != truthSize {
+		changes = append(changes, FieldChange{
+			Field:  "size",
+			Status: StatusSizeChanged,
+			Detail: fmt.Sprintf("size: %s -> %s GB", stateSize, truthSize),
+		})
+	}
+
+	// Terraform leaves volume_type empty when the default type was used
+	stateType := getPropertyString(stateRes.Properties, "volume_type")
+	truthType := getPropertyString(truthRes.Properties, "volume_type")
+	if stateType != "" && stateType != truthType {
+		changes = append(changes, FieldChange{
+			Field:  "volume_type",
+			Status: StatusVolumeTypeChanged,
+			Detail: fmt.Sprintf("volume_type: %q -> %q", stateType, truthType),
+		})
+	}
+
+	if stateRes.Name != truthRes.Name {
+		changes = append(changes, nameChange(stateRes, truthRes))
+	}
+
+	return changes
+}
+
+// compareVolumeAttachmentProperties compares the device of a volume attachment.
+// The server is part of the attachment ID, so a volume moved to another server
+// is a missing attachment on each side rather than a change.
+func compareVolumeAttachmentProperties(stateRes, truthRes *Resource) []FieldChange {
+	var changes []FieldChange
+
+	// Devices are only compared when Terraform requested a specific one
+	stateDevice := getPropertyString(stateRes.Properties, "device")
+	truthDevice := getPropertyString(truthRes.Properties, "device")
+	if stateDevice != "" && stateDevice != "auto" && stateDevice != truthDevice {
+		changes = append(changes, FieldChange{
+			Field:  "device",
+			Status: StatusAttachmentChanged,
+			Detail: fmt.Sprintf("device: %q -> %q", stateDevice, truthDevice),
+		})
+	}
+
+	return changes
+}
+
+// ruleProperty describes a security group rule property compared between state and truth.
+// keys lists the property names to read, in order of preference, since Terraform and
+// osc use different names for some of them.
+type ruleProperty struct {
+	name      string
+	keys      []string
+	normalize func(string) string
+}
+
+// ruleProperties are the rule properties compared for drift, in report order
+var ruleProperties = []ruleProperty{
+	{name: "direction", keys: []string{"direction"}, normalize: strings.ToLower},
+	{name: "ethertype", keys: []string{"ethertype"}, normalize: strings.ToLower},
+	{name: "protocol", keys: []string{"protocol"}, normalize: secgrp.NormalizeProtocol},
+	{name: "port_range", keys: []string{"port_range"}, normalize: normalizePortRange},
+	{name: "remote_ip", keys: []string{"remote_ip", "remote_ip_prefix"}, normalize: normalizeRemoteIP},
+	{name: "remote_group", keys: []string{"remote_group", "remote_group_id"}, normalize: normalizeRemoteGroup},
+}
+
+// compareSecurityGroupRuleProperties compares security group rule properties.
+// Properties missing from the truth file (e.g. ethertype in non-full osc output)
+// are skipped so that older truth files don't report every rule as changed.
+func compareSecurityGroupRuleProperties(stateRes, truthRes *Resource) []FieldChange {
+	var changes []FieldChange
+
+	for _, prop := range ruleProperties {
+		truthRaw, ok := lookupProperty(truthRes.Properties, prop.keys)
+		if !ok {
+			continue
+		}
+		stateRaw, ok := lookupProperty(stateRes.Properties, prop.keys)
+		if !ok {
+			continue
+		}
+
+		stateVal := normalizeRuleValue(prop.normalize(normalizeRuleValue(stateRaw)))
+		truthVal := normalizeRuleValue(prop.normalize(normalizeRuleValue(truthRaw)))
+		if stateVal != truthVal {
+			changes = append(changes, FieldChange{
+				Field:  prop.name,
+				Status: StatusRuleChanged,
+				Detail: fmt.Sprintf("%s: %q -> %q", prop.name, displayRuleValue(stateVal), displayRuleValue(truthVal)),
+			})
+		}
+	}
+
+	return changes
+}
+
+// lookupProperty returns the first property present under one of keys
+func lookupProperty(props map[string]any, keys []string) (string, bool) {
+	for _, key := range keys {
+		if _, ok := props[key]; ok {
+			return getPropertyString(props, key), true
+		}
+	}
+	return "", false
+}
+
+// displayRuleValue shows normalized empty rule values as "any"
+func displayRuleValue(val string) string {
+	if val == "" {
+		return "any"
+	}
+	return val
+}
+
+// normalizePortRange converts Terraform ("80:443", "0:0") and osc ("80-443", "22")
+// port ranges to the osc form
+func normalizePortRange(val string) string {
+	sep := "-"
+	if strings.Contains(val, ":") {
+		sep = ":"
+	}
+	min, max, isRange := strings.Cut(val, sep)
+	if !isRange {
+		return val
+	}
+	min, max = strings.TrimSpace(min), strings.TrimSpace(max)
+	switch {
+	case (min == "" || min == "0") && (max == "" || max == "0"):
+		return ""
+	case min == max:
+		return min
+	}
+	return min + "-" + max
+}
+
+// normalizeRemoteIP treats the all-addresses prefixes the same as no prefix
+func normalizeRemoteIP(val string) string {
+	if val == "0.0.0.0/0" || val == "::/0" {
+		return ""
+	}
+	return val
+}
+
+// normalizeRemoteGroup strips the " (name)" suffix osc adds to remote group IDs
+func normalizeRemoteGroup(val string) string {
+	id, _, _ := strings.Cut(val, " ")
+	return id
 }
 
 // Helper functions
@@ -182,6 +648,53 @@ func groupByType(resources []Resource) map[ResourceType][]Resource {
 	return result
 }
 
+// resolveSecurityGroupIDs replaces the security group IDs of state servers
+// with the group names known to the truth. Groups defined outside the state
+// are only referenced by ID there, while the truth lists servers' groups by
+// name. The state resources are copied, not modified.
+func resolveSecurityGroupIDs(state, truth []Resource) []Resource {
+	names := make(map[string]string)
+	for _, res := range truth {
+		switch res.Type {
+		case ResourceTypeSecurityGroup:
+			if res.Name != "" {
+				names[res.ID] = res.Name
+			}
+		case ResourceTypeServer:
+			ids, _ := res.Properties["security_group_names"].(map[string]string)
+			for id, name := range ids {
+				names[id] = name
+			}
+		}
+	}
+	if len(names) == 0 {
+		return state
+	}
+
+	resolved := make([]Resource, len(state))
+	copy(resolved, state)
+	for i, res := range resolved {
+		if res.Type != ResourceTypeServer {
+			continue
+		}
+		var groups []string
+		for j, sg := range res.SecurityGroups {
+			name, ok := names[sg]
+			if !ok {
+				continue
+			}
+			if groups == nil {
+				groups = append([]string(nil), res.SecurityGroups...)
+			}
+			groups[j] = name
+		}
+		if groups != nil {
+			resolved[i].SecurityGroups = groups
+		}
+	}
+	return resolved
+}
+
 // normalizeSecurityGroups sorts and deduplicates security group names
 func normalizeSecurityGroups(sgs []string) []string {
 	if len(sgs) == 0 {
@@ -279,6 +792,10 @@ func CountResources(resources []Resource) ResourceCounts {
 			counts.SecurityGroups++
 		case ResourceTypeSecurityGroupRule:
 			counts.SecurityGroupRules++
+		case ResourceTypeVolume:
+			counts.Volumes++
+		case ResourceTypeVolumeAttachment:
+			counts.VolumeAttachments++
 		}
 	}
 	return counts
@@ -1,6 +1,8 @@
 package drift
 
 import (
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -112,6 +114,9 @@ func TestParseTerraformStateWithChildModules(t *testing.T) {
 			if res.Name != "test-server" {
 				t.Errorf("Expected server name test-server, got %s", res.Name)
 			}
+			if res.Address != "module.mymodule.openstack_compute_instance_v2.test" || res.ModulePath != "module.mymodule" {
+				t.Errorf("Expected server address in module.mymodule, got %q (module %q)", res.Address, res.ModulePath)
+			}
 		}
 		if res.Type == ResourceTypeSecurityGroup && res.ID == "sg-id-456" {
 			foundSG = true
@@ -299,6 +304,120 @@ func TestParseOscOutput(t *testing.T) {
 	}
 }
 
+func TestExtractResourcesFromTypedRecords(t *testing.T) {
+	// Typed records as written by osc list servers -f and osc list secgrps -r -f
+	oscJSON := `{
+		"headers": ["name", "id", "parent_id", "project_id", "project_name"],
+		"data": [
+			{
+				"type": "server",
+				"name": "test-server",
+				"id": "server-id-123",
+				"project_name": "test-project",
+				"ip_address": "10.0.0.1",
+				"security_groups": [{"id": "sg-1", "name": "default"}, {"id": "sg-2", "name": ""}]
+			},
+			{
+				"type": "security-group-rule",
+				"name": "rule-1",
+				"id": "rule-1",
+				"parent_id": "sg-1",
+				"project_name": "test-project",
+				"direction": "ingress",
+				"ethertype": "IPv4",
+				"protocol": null,
+				"port_range_min": 80,
+				"port_range_max": 443,
+				"remote_ip_prefix": null,
+				"remote_group": {"id": "sg-2", "name": "web"}
+			}
+		]
+	}`
+
+	output, err := ParseOscOutput(strings.NewReader(oscJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse osc output: %v", err)
+	}
+	resources := ExtractResourcesFromOsc(output, "test-project")
+	if len(resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %d", len(resources))
+	}
+
+	if got := strings.Join(resources[0].SecurityGroups, ","); got != "default,sg-2" {
+		t.Errorf("Expected security groups default,sg-2, got %s", got)
+	}
+	if got := resources[0].Properties["security_group_names"]; !reflect.DeepEqual(got, map[string]string{"sg-1": "default"}) {
+		t.Errorf("Expected security group names by ID {sg-1: default}, got %v", got)
+	}
+
+	want := map[string]any{
+		"direction":    "ingress",
+		"protocol":     "any",
+		"port_range":   "80-443",
+		"remote_ip":    "any",
+		"ethertype":    "IPv4",
+		"remote_group": "sg-2 (web)",
+	}
+	for key, value := range want {
+		if resources[1].Properties[key] != value {
+			t.Errorf("Expected rule %s %q, got %v", key, value, resources[1].Properties[key])
+		}
+	}
+}
+
+func TestExtractResourcesFromNestedRules(t *testing.T) {
+	// Security groups with their rules nested, as written by osc list secgrps -r -f -o json
+	oscJSON := `{
+		"headers": ["name", "id", "project_id", "project_name", "rules"],
+		"data": [
+			{
+				"type": "security-group",
+				"name": "web",
+				"id": "sg-1",
+				"project_name": "test-project",
+				"rules": [
+					{
+						"name": "rule-1",
+						"id": "rule-1",
+						"direction": "ingress",
+						"ethertype": "IPv4",
+						"protocol": "tcp",
+						"port_range_min": 22,
+						"port_range_max": 22,
+						"remote_ip_prefix": "10.0.0.0/8",
+						"remote_group": null
+					}
+				]
+			},
+			{
+				"type": "security-group",
+				"name": "empty",
+				"id": "sg-2",
+				"project_name": "test-project",
+				"rules": []
+			}
+		]
+	}`
+
+	output, err := ParseOscOutput(strings.NewReader(oscJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse osc output: %v", err)
+	}
+	resources := ExtractResourcesFromOsc(output, "test-project")
+	counts := CountResources(resources)
+	if counts.SecurityGroups != 2 || counts.SecurityGroupRules != 1 {
+		t.Fatalf("Expected 2 security groups and 1 rule, got %+v", counts)
+	}
+
+	rule := resources[1]
+	if rule.Type != ResourceTypeSecurityGroupRule || rule.ID != "rule-1" || rule.ParentID != "sg-1" || rule.ParentName != "web" {
+		t.Fatalf("Expected rule-1 of sg-1 (web), got %+v", rule)
+	}
+	if rule.Properties["port_range"] != "22" || rule.Properties["remote_ip"] != "10.0.0.0/8" {
+		t.Errorf("Expected port 22 from 10.0.0.0/8, got %v", rule.Properties)
+	}
+}
+
 func TestExtractResourcesFromNewJsonFormat(t *testing.T) {
 	// Test extraction from the new normalized JSON format
 	oscJSON := `{
@@ -470,6 +589,494 @@ func TestCompareSecurityGroupChanges(t *testing.T) {
 	}
 }
 
+func TestCompareSecurityGroupRuleChanges(t *testing.T) {
+	stateJSON := `{
+		"format_version": "1.0",
+		"values": {
+			"root_module": {
+				"resources": [
+					{
+						"type": "openstack_networking_secgroup_rule_v2",
+						"values": {
+							"id": "rule-1",
+							"security_group_id": "sg-1",
+							"direction": "ingress",
+							"ethertype": "IPv4",
+							"protocol": "tcp",
+							"port_range_min": 22,
+							"port_range_max": 22,
+							"remote_ip_prefix": "0.0.0.0/0",
+							"remote_group_id": ""
+						}
+					},
+					{
+						"type": "openstack_networking_secgroup_rule_v2",
+						"values": {
+							"id": "rule-2",
+							"security_group_id": "sg-1",
+							"direction": "ingress",
+							"ethertype": "IPv4",
+							"protocol": "tcp",
+							"port_range_min": 80,
+							"port_range_max": 443,
+							"remote_ip_prefix": "",
+							"remote_group_id": "sg-2"
+						}
+					}
+				]
+			}
+		}
+	}`
+
+	truthJSON := `{
+		"headers": ["name", "id", "parent_id", "project_id", "project_name", "type", "direction", "protocol", "port_range", "remote_ip", "ethertype", "remote_group"],
+		"data": [
+			{
+				"type": "security-group-rule",
+				"id": "rule-1",
+				"parent_id": "sg-1",
+				"rule_fields": {"direction": "ingress", "protocol": "tcp", "port_range": "22", "remote_ip": "any", "ethertype": "IPv4"}
+			},
+			{
+				"type": "security-group-rule",
+				"id": "rule-2",
+				"parent_id": "sg-1",
+				"rule_fields": {"direction": "ingress", "protocol": "udp", "port_range": "80-8080", "remote_ip": "any", "ethertype": "IPv4", "remote_group": "sg-3 (web)"}
+			}
+		]
+	}`
+
+	state, err := ParseTerraformState(strings.NewReader(stateJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse Terraform state: %v", err)
+	}
+	truth, err := ParseOscOutput(strings.NewReader(truthJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse osc output: %v", err)
+	}
+
+	diffs := CompareResources(ExtractResourcesFromTerraform(state, "project1"), ExtractResourcesFromOsc(truth, "project1"))
+
+	// rule-1 only differs in how "any" is spelled, so only rule-2 should drift
+	if len(diffs) != 1 {
+		t.Fatalf("Expected 1 diff, got %d: %+v", len(diffs), diffs)
+	}
+	if diffs[0].ResourceID != "rule-2" || diffs[0].Status != StatusRuleChanged {
+		t.Fatalf("Expected rule_changed for rule-2, got %s for %s", diffs[0].Status, diffs[0].ResourceID)
+	}
+
+	want := `protocol: "tcp" -> "udp"; port_range: "80-443" -> "80-8080"; remote_group: "sg-2" -> "sg-3"`
+	if diffs[0].Details != want {
+		t.Errorf("Expected details %q, got %q", want, diffs[0].Details)
+	}
+}
+
+func TestCompareSecurityGroupRuleWithoutTruthFields(t *testing.T) {
+	// Truth files without rule_fields only allow ID matching
+	state := []Resource{{
+		ID:         "rule-1",
+		Type:       ResourceTypeSecurityGroupRule,
+		Properties: map[string]any{"direction": "ingress", "protocol": "tcp", "port_range": "22:22"},
+	}}
+	truth := []Resource{{
+		ID:         "rule-1",
+		Type:       ResourceTypeSecurityGroupRule,
+		Properties: map[string]any{},
+	}}
+
+	if diffs := CompareResources(state, truth); len(diffs) != 0 {
+		t.Errorf("Expected no diffs, got %+v", diffs)
+	}
+}
+
+func TestCompareVolumeChanges(t *testing.T) {
+	stateJSON := `{
+		"format_version": "1.0",
+		"values": {
+			"root_module": {
+				"resources": [
+					{"type": "openstack_blockstorage_volume_v3", "values": {"id": "vol-1", "name": "data", "size": 100, "volume_type": "SSD"}},
+					{"type": "openstack_blockstorage_volume_v3", "values": {"id": "vol-2", "name": "logs", "size": 50, "volume_type": ""}},
+					{"type": "openstack_compute_volume_attach_v2", "values": {"id": "srv-1/vol-1", "instance_id": "srv-1", "volume_id": "vol-1", "device": "/dev/vdb"}},
+					{"type": "openstack_compute_volume_attach_v2", "values": {"id": "srv-1/vol-2", "instance_id": "srv-1", "volume_id": "vol-2", "device": ""}}
+				]
+			}
+		}
+	}`
+
+	// Typed records as osc drift generate writes them to volumes.json, and
+	// the fields object of older truth files
+	truthFiles := map[string]string{
+		"records": `{
+		"headers": ["name", "id", "project_id", "project_name", "size_gb", "volume_type", "server_id", "device"],
+		"data": [
+			{"type": "volume", "name": "data", "id": "vol-1", "size_gb": 200, "volume_type": "SSD"},
+			{"type": "volume-attachment", "name": "data", "id": "srv-1/vol-1", "server_id": "srv-1", "device": "/dev/vdd"},
+			{"type": "volume", "name": "logs", "id": "vol-2", "size_gb": 50, "volume_type": "HDD"},
+			{"type": "volume-attachment", "name": "logs", "id": "srv-2/vol-2", "server_id": "srv-2", "device": "/dev/vdc"}
+		]
+	}`,
+		"legacy": `{
+		"headers": ["name", "id", "project_name", "type", "size_gb", "volume_type", "attached_server", "device"],
+		"data": [
+			{"type": "volume", "id": "vol-1", "name": "data", "fields": {"size_gb": "200", "volume_type": "SSD"}},
+			{"type": "volume-attachment", "id": "srv-1/vol-1", "name": "data", "fields": {"attached_server": "srv-1", "device": "/dev/vdd"}},
+			{"type": "volume", "id": "vol-2", "name": "logs", "fields": {"size_gb": "50", "volume_type": "HDD"}},
+			{"type": "volume-attachment", "id": "srv-2/vol-2", "name": "logs", "fields": {"attached_server": "srv-2", "device": "/dev/vdc"}}
+		]
+	}`,
+	}
+
+	state, err := ParseTerraformState(strings.NewReader(stateJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse Terraform state: %v", err)
+	}
+
+	stateResources := ExtractResourcesFromTerraform(state, "project1")
+	counts := CountResources(stateResources)
+	if counts.Volumes != 2 || counts.VolumeAttachments != 2 {
+		t.Fatalf("Expected 2 volumes and 2 attachments, got %+v", counts)
+	}
+
+	for layout, truthJSON := range truthFiles {
+		t.Run(layout, func(t *testing.T) {
+			truth, err := ParseOscOutput(strings.NewReader(truthJSON))
+			if err != nil {
+				t.Fatalf("Failed to parse osc output: %v", err)
+			}
+
+			diffs := CompareResources(stateResources, ExtractResourcesFromOsc(truth, "project1"))
+
+			// vol-1 was resized and attached as another device, vol-2 was moved to
+			// another server; an empty volume_type in Terraform means the default type
+			// and is not drift
+			want := map[DriftStatus]string{
+				StatusSizeChanged:       "vol-1",
+				StatusAttachmentChanged: "srv-1/vol-1",
+				StatusMissingInTruth:    "srv-1/vol-2",
+				StatusMissingInState:    "srv-2/vol-2",
+			}
+			if len(diffs) != len(want) {
+				t.Fatalf("Expected %d diffs, got %d: %+v", len(want), len(diffs), diffs)
+			}
+			for _, d := range diffs {
+				if want[d.Status] != d.ResourceID {
+					t.Errorf("Unexpected diff %s for %s: %s", d.Status, d.ResourceID, d.Details)
+				}
+				if d.Status == StatusMissingInTruth && d.ResourceName != "logs" {
+					t.Errorf("Expected attachment to be named after its volume, got %q", d.ResourceName)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareMultiAttachVolume(t *testing.T) {
+	stateJSON := `{
+		"format_version": "1.0",
+		"values": {
+			"root_module": {
+				"resources": [
+					{"type": "openstack_blockstorage_volume_v3", "values": {"id": "vol-1", "name": "shared", "size": 10, "volume_type": "multiattach"}},
+					{"type": "openstack_compute_volume_attach_v2", "values": {"id": "srv-1/vol-1", "instance_id": "srv-1", "volume_id": "vol-1", "device": "/dev/vdb"}},
+					{"type": "openstack_compute_volume_attach_v2", "values": {"id": "srv-2/vol-1", "instance_id": "srv-2", "volume_id": "vol-1", "device": "/dev/vdb"}}
+				]
+			}
+		}
+	}`
+
+	// Truth files written before attachments had their own ID use the volume ID
+	truthJSON := `{
+		"headers": ["name", "id", "project_name", "type", "size_gb", "volume_type", "attached_server", "device"],
+		"data": [
+			{"type": "volume", "id": "vol-1", "name": "shared", "fields": {"size_gb": "10", "volume_type": "multiattach"}},
+			{"type": "volume-attachment", "id": "srv-1/vol-1", "name": "shared", "fields": {"attached_server": "srv-1", "device": "/dev/vdb"}},
+			{"type": "volume-attachment", "id": "vol-1", "name": "shared", "fields": {"attached_server": "srv-2", "device": "/dev/vdb"}}
+		]
+	}`
+
+	state, err := ParseTerraformState(strings.NewReader(stateJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse Terraform state: %v", err)
+	}
+	truth, err := ParseOscOutput(strings.NewReader(truthJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse osc output: %v", err)
+	}
+
+	stateResources := ExtractResourcesFromTerraform(state, "project1")
+	truthResources := ExtractResourcesFromOsc(truth, "project1")
+	for _, resources := range [][]Resource{stateResources, truthResources} {
+		if counts := CountResources(resources); counts.Volumes != 1 || counts.VolumeAttachments != 2 {
+			t.Fatalf("Expected 1 volume and 2 attachments, got %+v", counts)
+		}
+	}
+
+	if diffs := CompareResources(stateResources, truthResources); len(diffs) != 0 {
+		t.Errorf("Expected no diffs for a volume attached to two servers, got %+v", diffs)
+	}
+
+	// Detaching it from one server only reports that attachment
+	diffs := CompareResources(stateResources, truthResources[:2])
+	if len(diffs) != 1 || diffs[0].Status != StatusMissingInTruth || diffs[0].ResourceID != "srv-2/vol-1" {
+		t.Errorf("Expected srv-2/vol-1 missing in truth, got %+v", diffs)
+	}
+}
+
+func TestCompareServerProperties(t *testing.T) {
+	stateJSON := `{
+		"format_version": "1.0",
+		"values": {
+			"root_module": {
+				"resources": [
+					{
+						"type": "openstack_compute_instance_v2",
+						"values": {
+							"id": "server-1",
+							"name": "web-1",
+							"flavor_id": "flv-small",
+							"flavor_name": "m1.small",
+							"image_id": "img-1",
+							"image_name": "ubuntu-22.04",
+							"power_state": "active",
+							"metadata": {"role": "web", "owner": "ops"},
+							"security_groups": ["default"]
+						}
+					}
+				]
+			}
+		}
+	}`
+
+	state, err := ParseTerraformState(strings.NewReader(stateJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse Terraform state: %v", err)
+	}
+	stateResources := ExtractResourcesFromTerraform(state, "project1")
+
+	truthResources := []Resource{{
+		ID:             "server-1",
+		Name:           "web-1",
+		Type:           ResourceTypeServer,
+		SecurityGroups: []string{"default"},
+		Properties: map[string]any{
+			"flavor_id":   "flv-large",
+			"flavor_name": "m1.large",
+			"image_id":    "img-1",
+			"power_state": "SHUTOFF",
+			"metadata":    map[string]string{"role": "db", "owner": "ops", "ticket": "OPS-1"},
+		},
+	}}
+
+	diffs := CompareResources(stateResources, truthResources)
+	if len(diffs) != 1 {
+		t.Fatalf("Expected 1 diff, got %d: %+v", len(diffs), diffs)
+	}
+	if diffs[0].Status != StatusFlavorChanged {
+		t.Errorf("Expected status flavor_changed, got %s", diffs[0].Status)
+	}
+	want := `flavor: "m1.small" -> "m1.large"; power_state: "active" -> "shutoff"; metadata.role: "web" -> "db"; metadata.ticket: added "OPS-1"`
+	if diffs[0].Details != want {
+		t.Errorf("Expected details %q, got %q", want, diffs[0].Details)
+	}
+
+	// Only the selected fields count as drift
+	opts, err := NewCompareOptions([]string{"metadata"})
+	if err != nil {
+		t.Fatalf("NewCompareOptions() error = %v", err)
+	}
+	diffs = CompareResourcesWithOptions(stateResources, truthResources, opts)
+	if len(diffs) != 1 || diffs[0].Status != StatusMetadataChanged {
+		t.Errorf("Expected a single metadata_changed diff, got %+v", diffs)
+	}
+
+	if _, err := NewCompareOptions([]string{"flavour"}); err == nil {
+		t.Error("Expected an error for an unknown server field")
+	}
+}
+
+func TestResolvePortAttachments(t *testing.T) {
+	stateJSON := `{
+		"format_version": "1.0",
+		"values": {
+			"root_module": {
+				"resources": [
+					{"type": "openstack_networking_secgroup_v2", "values": {"id": "sg-web", "name": "web"}},
+					{"type": "openstack_networking_secgroup_v2", "values": {"id": "sg-ssh", "name": "ssh"}},
+					{
+						"type": "openstack_compute_instance_v2",
+						"values": {"id": "server-1", "name": "web-1", "security_groups": [], "network": [{"port": "port-1"}]}
+					},
+					{
+						"type": "openstack_compute_instance_v2",
+						"values": {"id": "server-2", "name": "web-2", "security_groups": []}
+					}
+				],
+				"child_modules": [
+					{
+						"address": "module.net",
+						"resources": [
+							{"type": "openstack_networking_port_v2", "values": {"id": "port-1", "security_group_ids": ["sg-web"]}},
+							{"type": "openstack_networking_port_v2", "values": {"id": "port-2", "device_id": "server-2", "security_group_ids": ["sg-web"]}},
+							{"type": "openstack_networking_port_secgroup_associate_v2", "values": {"port_id": "port-2", "security_group_ids": ["sg-ssh", "sg-external"]}},
+							{"type": "openstack_networking_floatingip_associate_v2", "values": {"port_id": "port-1", "floating_ip": "203.0.113.10"}}
+						]
+					}
+				]
+			}
+		}
+	}`
+
+	state, err := ParseTerraformState(strings.NewReader(stateJSON))
+	if err != nil {
+		t.Fatalf("Failed to parse Terraform state: %v", err)
+	}
+	servers := make(map[string]Resource)
+	for _, res := range ExtractResourcesFromTerraform(state, "project1") {
+		if res.Type == ResourceTypeServer {
+			servers[res.ID] = res
+		}
+	}
+
+	if got := normalizeSecurityGroups(servers["server-1"].SecurityGroups); !stringSlicesEqual(got, []string{"web"}) {
+		t.Errorf("server-1: expected security groups [web] from its port, got %v", got)
+	}
+	// The association replaces the port's own groups; unknown IDs are kept
+	if got := normalizeSecurityGroups(servers["server-2"].SecurityGroups); !stringSlicesEqual(got, []string{"sg-external", "ssh"}) {
+		t.Errorf("server-2: expected security groups [sg-external ssh] from the association, got %v", got)
+	}
+	if got := servers["server-1"].Properties["floating_ip"]; got != "203.0.113.10" {
+		t.Errorf("server-1: expected floating IP 203.0.113.10, got %v", got)
+	}
+
+	truth := []Resource{{
+		ID:             "server-1",
+		Name:           "web-1",
+		Type:           ResourceTypeServer,
+		SecurityGroups: []string{"web"},
+		Properties:     map[string]any{"floating_ip": "203.0.113.20"},
+	}}
+	diffs := CompareResources([]Resource{servers["server-1"]}, truth)
+	if len(diffs) != 1 || diffs[0].Status != StatusFloatingIPChanged {
+		t.Errorf("Expected a single floating_ip_changed diff, got %+v", diffs)
+	}
+}
+
+func TestResolveSecurityGroupIDs(t *testing.T) {
+	// sg-shared is defined outside the state, so the state only has its ID
+	state := []Resource{{
+		ID:             "server-1",
+		Name:           "web-1",
+		Type:           ResourceTypeServer,
+		SecurityGroups: []string{"web", "sg-shared"},
+	}}
+
+	tests := []struct {
+		name  string
+		truth []Resource
+		want  int // server diffs
+	}{
+		{
+			name: "unresolved ID",
+			truth: []Resource{
+				{ID: "server-1", Name: "web-1", Type: ResourceTypeServer, SecurityGroups: []string{"shared", "web"}},
+			},
+			want: 1,
+		},
+		{
+			name: "truth security group",
+			truth: []Resource{
+				{ID: "server-1", Name: "web-1", Type: ResourceTypeServer, SecurityGroups: []string{"shared", "web"}},
+				{ID: "sg-shared", Name: "shared", Type: ResourceTypeSecurityGroup},
+			},
+		},
+		{
+			name: "truth server group IDs",
+			truth: []Resource{{
+				ID:             "server-1",
+				Name:           "web-1",
+				Type:           ResourceTypeServer,
+				SecurityGroups: []string{"shared", "web"},
+				Properties:     map[string]any{"security_group_names": map[string]string{"sg-shared": "shared"}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diffs []DiffResult
+			for _, d := range CompareResources(state, tt.truth) {
+				if d.ResourceType == ResourceTypeServer {
+					diffs = append(diffs, d)
+				}
+			}
+			if len(diffs) != tt.want {
+				t.Errorf("Expected %d diffs, got %+v", tt.want, diffs)
+			}
+			if len(diffs) == 1 && diffs[0].Status != StatusSecGroupChanged {
+				t.Errorf("Expected secgroups_changed, got %s", diffs[0].Status)
+			}
+		})
+	}
+	if state[0].SecurityGroups[1] != "sg-shared" {
+		t.Errorf("Expected the state to be left unchanged, got %v", state[0].SecurityGroups)
+	}
+}
+
+func TestCompareMatchByName(t *testing.T) {
+	state := []Resource{
+		{ID: "srv-old", Name: "web-1", Type: ResourceTypeServer, ProjectName: "p1", Properties: map[string]any{"flavor_name": "m1.small"}},
+		{ID: "srv-a1", Name: "worker", Type: ResourceTypeServer, ProjectName: "p1"},
+		{ID: "srv-a2", Name: "worker", Type: ResourceTypeServer, ProjectName: "p1"},
+		{ID: "rule-old", Type: ResourceTypeSecurityGroupRule, ProjectName: "p1", ParentName: "web"},
+	}
+	truth := []Resource{
+		{ID: "srv-new", Name: "web-1", Type: ResourceTypeServer, ProjectName: "p1", Properties: map[string]any{"flavor_name": "m1.large"}},
+		{ID: "srv-b1", Name: "worker", Type: ResourceTypeServer, ProjectName: "p1"},
+		{ID: "rule-new", Type: ResourceTypeSecurityGroupRule, ProjectName: "p1", ParentName: "web"},
+	}
+
+	// Without name matching nothing is paired
+	if got := CompareResources(state, truth); len(got) != 7 {
+		t.Fatalf("expected 7 drift items without name matching, got %d: %+v", len(got), got)
+	}
+
+	opts := DefaultCompareOptions()
+	opts.MatchByName = true
+	results := CompareResourcesWithOptions(state, truth, opts)
+
+	byStatus := make(map[DriftStatus][]DiffResult)
+	for _, r := range results {
+		byStatus[r.Status] = append(byStatus[r.Status], r)
+	}
+
+	recreated := byStatus[StatusRecreated]
+	if len(recreated) != 1 {
+		t.Fatalf("expected 1 recreated drift, got %+v", results)
+	}
+	r := recreated[0]
+	if r.ResourceID != "srv-new" || r.PreviousID != "srv-old" {
+		t.Errorf("recreated IDs = %s (previous %s), want srv-new (previous srv-old)", r.ResourceID, r.PreviousID)
+	}
+	if len(r.Changes) != 2 || r.Changes[1].Status != StatusFlavorChanged {
+		t.Errorf("expected the id change followed by the flavor change, got %+v", r.Changes)
+	}
+
+	// The ambiguous worker servers and the unnamed rules stay unpaired
+	if len(byStatus[StatusMissingInTruth]) != 3 || len(byStatus[StatusMissingInState]) != 2 {
+		t.Errorf("expected 3 missing_in_truth and 2 missing_in_state, got %+v", results)
+	}
+
+	// Results are stable between runs
+	for i := 0; i < 10; i++ {
+		again := CompareResourcesWithOptions(state, truth, opts)
+		for j := range results {
+			if again[j].ResourceID != results[j].ResourceID || again[j].Status != results[j].Status {
+				t.Fatalf("run %d differs at %d: %+v vs %+v", i, j, again[j], results[j])
+			}
+		}
+	}
+}
+
 func TestCountResources(t *testing.T) {
 	resources := []Resource{
 		{Type: ResourceTypeServer},
@@ -527,3 +1134,43 @@ func TestDriftReport(t *testing.T) {
 		t.Errorf("Expected 1 missing_in_truth, got %d", report.Summary.ByStatus[StatusMissingInTruth])
 	}
 }
+
+func TestDiffResultHasStatus(t *testing.T) {
+	d := DiffResult{
+		Status: StatusFlavorChanged,
+		Changes: []FieldChange{
+			{Field: "flavor", Status: StatusFlavorChanged},
+			{Field: "image", Status: StatusImageChanged},
+		},
+	}
+	if !d.HasStatus(StatusFlavorChanged) || !d.HasStatus(StatusImageChanged) {
+		t.Errorf("Expected the item status and every change status to match")
+	}
+	if d.HasStatus(StatusMetadataChanged) {
+		t.Errorf("Expected a status of no change not to match")
+	}
+}
+
+func TestDriftReportExitCode(t *testing.T) {
+	report := NewDriftReport()
+	report.AddProject(ProjectDrift{ProjectName: "alpha"})
+	if code := report.ExitCode(); code != ExitNoDrift {
+		t.Errorf("Expected exit code %d without drift, got %d", ExitNoDrift, code)
+	}
+
+	report.AddProject(ProjectDrift{ProjectName: "beta", Drifts: []DiffResult{{Status: StatusMissingInState}}})
+	if code := report.ExitCode(); code != ExitDrift {
+		t.Errorf("Expected exit code %d with drift, got %d", ExitDrift, code)
+	}
+
+	// A project that failed to load fails the check even when nothing drifted
+	failed := NewDriftReport()
+	failed.AddError("gamma", fmt.Errorf("unreadable state"))
+	if code := failed.ExitCode(); code != ExitLoadErrors {
+		t.Errorf("Expected exit code %d with only load errors, got %d", ExitLoadErrors, code)
+	}
+	report.AddError("gamma", fmt.Errorf("unreadable state"))
+	if code := report.ExitCode(); code != ExitLoadErrors {
+		t.Errorf("Expected exit code %d with drift and load errors, got %d", ExitLoadErrors, code)
+	}
+}
@@ -0,0 +1,242 @@
+package drift
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcdicarlo/osc/internal/config"
+)
+
+// BaselineLatest selects the most recent saved run for the same base path
+const BaselineLatest = "latest"
+
+// HistoryStore saves drift runs to the cache database and reads them back
+type HistoryStore struct {
+	DB  *sql.DB
+	Cfg *config.Config
+}
+
+// NewHistoryStore creates a HistoryStore for the given database
+func NewHistoryStore(database *sql.DB, cfg *config.Config) *HistoryStore {
+	return &HistoryStore{DB: database, Cfg: cfg}
+}
+
+// SaveRun stores a drift report for basePath and returns its run ID
+func (h *HistoryStore) SaveRun(basePath string, startedAt time.Time, report *DriftReport) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Cfg.DBTimeout)
+	defer cancel()
+
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO `+h.Cfg.Tables.DriftRuns+`
+		(started_at, base_path, total_projects, total_drift, ignored) VALUES (?, ?, ?, ?, ?)`,
+		startedAt.UTC().Format(time.RFC3339), basePath,
+		report.Summary.TotalProjects, report.Summary.TotalDrift, report.Summary.Ignored)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save drift run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read drift run ID: %w", err)
+	}
+
+	for _, project := range report.Projects {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO `+h.Cfg.Tables.DriftRunProjects+`
+			(run_id, project_name, drift_count, ignored) VALUES (?, ?, ?, ?)`,
+			runID, project.ProjectName, len(project.Drifts), project.Ignored); err != nil {
+			return 0, fmt.Errorf("failed to save drift run project: %w", err)
+		}
+		for _, d := range project.Drifts {
+			var changes sql.NullString
+			if len(d.Changes) > 0 {
+				data, err := json.Marshal(d.Changes)
+				if err != nil {
+					return 0, fmt.Errorf("failed to encode drift item changes: %w", err)
+				}
+				changes = sql.NullString{String: string(data), Valid: true}
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO `+h.Cfg.Tables.DriftItems+`
+				(run_id, project_name, resource_type, resource_id, resource_name, parent_sg, status, details, address, changes)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				runID, project.ProjectName, d.ResourceType, d.ResourceID, d.ResourceName,
+				d.ParentSG, d.Status, d.Details, d.Address, changes); err != nil {
+				return 0, fmt.Errorf("failed to save drift item: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit drift run: %w", err)
+	}
+	return runID, nil
+}
+
+// ResolveRunID resolves a run ID or "latest" to a saved run. latest is the most
+// recent run for basePath.
+func (h *HistoryStore) ResolveRunID(ref, basePath string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Cfg.DBTimeout)
+	defer cancel()
+
+	var row *sql.Row
+	if ref == BaselineLatest {
+		row = h.DB.QueryRowContext(ctx, `SELECT run_id FROM `+h.Cfg.Tables.DriftRuns+`
+			WHERE base_path = ? ORDER BY run_id DESC LIMIT 1`, basePath)
+	} else {
+		id, err := strconv.ParseInt(ref, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid run ID %q (expected a number or %q)", ref, BaselineLatest)
+		}
+		row = h.DB.QueryRowContext(ctx, `SELECT run_id FROM `+h.Cfg.Tables.DriftRuns+` WHERE run_id = ?`, id)
+	}
+
+	var runID int64
+	if err := row.Scan(&runID); errors.Is(err, sql.ErrNoRows) {
+		if ref == BaselineLatest {
+			return 0, fmt.Errorf("no saved drift run for %s", basePath)
+		}
+		return 0, fmt.Errorf("drift run %s not found", ref)
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to read drift runs: %w", err)
+	}
+	return runID, nil
+}
+
+// LoadRunItems returns the drift items saved for a run, by project
+func (h *HistoryStore) LoadRunItems(runID int64) (map[string][]DiffResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Cfg.DBTimeout)
+	defer cancel()
+
+	rows, err := h.DB.QueryContext(ctx, `SELECT project_name, resource_type, resource_id,
+		COALESCE(resource_name, ''), COALESCE(parent_sg, ''), status, COALESCE(details, ''), COALESCE(address, ''),
+		COALESCE(changes, '')
+		FROM `+h.Cfg.Tables.DriftItems+` WHERE run_id = ? ORDER BY rowid`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read drift items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make(map[string][]DiffResult)
+	for rows.Next() {
+		var d DiffResult
+		var changes string
+		if err := rows.Scan(&d.ProjectName, &d.ResourceType, &d.ResourceID, &d.ResourceName,
+			&d.ParentSG, &d.Status, &d.Details, &d.Address, &changes); err != nil {
+			return nil, fmt.Errorf("failed to scan drift item: %w", err)
+		}
+		if changes != "" {
+			if err := json.Unmarshal([]byte(changes), &d.Changes); err != nil {
+				return nil, fmt.Errorf("failed to decode drift item changes: %w", err)
+			}
+		}
+		items[d.ProjectName] = append(items[d.ProjectName], d)
+	}
+	return items, rows.Err()
+}
+
+// baselineKey identifies a drift item across runs by its resource, status and
+// changed fields
+func baselineKey(d DiffResult) string {
+	fields := make([]string, 0, len(d.Changes))
+	for _, c := range d.Changes {
+		fields = append(fields, c.Field)
+	}
+	slices.Sort(fields)
+	fields = slices.Compact(fields)
+	return string(d.ResourceType) + "/" + d.ResourceID + "/" + string(d.Status) + "/" + strings.Join(fields, ",")
+}
+
+// HistoryEntry is the drift count of a project in one saved run
+type HistoryEntry struct {
+	RunID       int64
+	StartedAt   string
+	BasePath    string
+	ProjectName string
+	DriftCount  int
+	Ignored     int
+}
+
+// LoadHistory returns the per-project drift counts of saved runs, oldest first.
+// limit keeps only the most recent runs when positive.
+func (h *HistoryStore) LoadHistory(limit int) ([]HistoryEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Cfg.DBTimeout)
+	defer cancel()
+
+	query := `SELECT r.run_id, r.started_at, r.base_path, p.project_name, p.drift_count, p.ignored
+		FROM ` + h.Cfg.Tables.DriftRunProjects + ` p
+		JOIN ` + h.Cfg.Tables.DriftRuns + ` r ON p.run_id = r.run_id`
+	var args []any
+	if limit > 0 {
+		query += ` WHERE r.run_id IN (SELECT run_id FROM ` + h.Cfg.Tables.DriftRuns + ` ORDER BY run_id DESC LIMIT ?)`
+		args = append(args, limit)
+	}
+	query += ` ORDER BY r.run_id, p.project_name`
+
+	rows, err := h.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read drift history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		if err := rows.Scan(&e.RunID, &e.StartedAt, &e.BasePath, &e.ProjectName, &e.DriftCount, &e.Ignored); err != nil {
+			return nil, fmt.Errorf("failed to scan drift history: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// ApplyBaseline returns a report holding only drift that is new since the
+// baseline, with drift that has since been resolved listed per project. Drift
+// items are the same when their project, resource type, resource ID, status
+// and set of changed fields match, so a further change to a resource that
+// drifted in the baseline is new drift.
+func ApplyBaseline(report *DriftReport, baselineRunID int64, baseline map[string][]DiffResult) *DriftReport {
+	result := NewDriftReport()
+	result.Summary.BaselineRunID = baselineRunID
+	result.Summary.PlanFile = report.Summary.PlanFile
+
+	// Projects that are no longer checked are left out; their drift is
+	// unknown rather than resolved
+	for _, project := range report.Projects {
+		before := make(map[string]bool)
+		for _, d := range baseline[project.ProjectName] {
+			before[baselineKey(d)] = true
+		}
+		now := make(map[string]bool)
+		for _, d := range project.Drifts {
+			now[baselineKey(d)] = true
+		}
+
+		filtered := project
+		filtered.Drifts = nil
+		filtered.Errors = nil
+		for _, d := range project.Drifts {
+			if !before[baselineKey(d)] {
+				filtered.Drifts = append(filtered.Drifts, d)
+			}
+		}
+		for _, d := range baseline[project.ProjectName] {
+			if !now[baselineKey(d)] {
+				filtered.Resolved = append(filtered.Resolved, d)
+			}
+		}
+		result.AddProject(filtered)
+	}
+	result.Errors = report.Errors
+
+	return result
+}
@@ -0,0 +1,131 @@
+package drift
+
+import (
+	"testing"
+	"time"
+)
+
+func historyReport(drifts ...DiffResult) *DriftReport {
+	report := NewDriftReport()
+	report.AddProject(ProjectDrift{ProjectName: "alpha", Drifts: drifts, Ignored: 1})
+	return report
+}
+
+func TestHistoryStore(t *testing.T) {
+	database, cfg := newTestDB(t)
+	store := NewHistoryStore(database, cfg)
+
+	if _, err := store.ResolveRunID(BaselineLatest, "/work"); err == nil {
+		t.Fatal("Expected error resolving latest with no saved runs")
+	}
+
+	webMissing := DiffResult{ResourceType: ResourceTypeServer, ResourceID: "srv-1", ResourceName: "web", ProjectName: "alpha", Status: StatusMissingInState}
+	sgMissing := DiffResult{ResourceType: ResourceTypeSecurityGroup, ResourceID: "sg-1", ResourceName: "default", ProjectName: "alpha", Status: StatusMissingInTruth, Address: "openstack_networking_secgroup_v2.default"}
+	dbFlavor := DiffResult{ResourceType: ResourceTypeServer, ResourceID: "srv-2", ResourceName: "db", ProjectName: "alpha", Status: StatusFlavorChanged}
+
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	first, err := store.SaveRun("/work", start, historyReport(webMissing, sgMissing))
+	if err != nil {
+		t.Fatalf("SaveRun failed: %v", err)
+	}
+	second, err := store.SaveRun("/work", start.Add(time.Hour), historyReport(sgMissing))
+	if err != nil {
+		t.Fatalf("SaveRun failed: %v", err)
+	}
+	if _, err := store.SaveRun("/other", start.Add(2*time.Hour), historyReport()); err != nil {
+		t.Fatalf("SaveRun failed: %v", err)
+	}
+
+	latest, err := store.ResolveRunID(BaselineLatest, "/work")
+	if err != nil {
+		t.Fatalf("ResolveRunID failed: %v", err)
+	}
+	if latest != second {
+		t.Errorf("Expected latest run %d for /work, got %d", second, latest)
+	}
+	if id, err := store.ResolveRunID("1", "/work"); err != nil || id != first {
+		t.Errorf("Expected run %d, got %d (err %v)", first, id, err)
+	}
+	if _, err := store.ResolveRunID("99", "/work"); err == nil {
+		t.Error("Expected error for unknown run ID")
+	}
+	if _, err := store.ResolveRunID("yesterday", "/work"); err == nil {
+		t.Error("Expected error for invalid run ID")
+	}
+
+	items, err := store.LoadRunItems(first)
+	if err != nil {
+		t.Fatalf("LoadRunItems failed: %v", err)
+	}
+	if len(items["alpha"]) != 2 {
+		t.Fatalf("Expected 2 saved items, got %d", len(items["alpha"]))
+	}
+	if got := items["alpha"][1]; got.Address != sgMissing.Address || got.Status != StatusMissingInTruth {
+		t.Errorf("Saved item not read back: %+v", got)
+	}
+
+	// web is resolved and db is new since the first run; sg drift is unchanged
+	report := ApplyBaseline(historyReport(sgMissing, dbFlavor), first, items)
+	if report.Summary.BaselineRunID != first {
+		t.Errorf("Expected baseline run %d, got %d", first, report.Summary.BaselineRunID)
+	}
+	project := report.Projects[0]
+	if len(project.Drifts) != 1 || project.Drifts[0].ResourceID != "srv-2" {
+		t.Errorf("Expected only srv-2 as new drift, got %+v", project.Drifts)
+	}
+	if len(project.Resolved) != 1 || project.Resolved[0].ResourceID != "srv-1" {
+		t.Errorf("Expected srv-1 as resolved, got %+v", project.Resolved)
+	}
+	if report.Summary.TotalDrift != 1 || report.Summary.Resolved != 1 || report.Summary.Ignored != 1 {
+		t.Errorf("Unexpected summary: %+v", report.Summary)
+	}
+
+	history, err := store.LoadHistory(2)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(history))
+	}
+	if history[0].RunID != second || history[0].DriftCount != 1 || history[0].Ignored != 1 {
+		t.Errorf("Unexpected first history entry: %+v", history[0])
+	}
+	if history[1].BasePath != "/other" || history[1].DriftCount != 0 {
+		t.Errorf("Unexpected second history entry: %+v", history[1])
+	}
+}
+
+func TestApplyBaselineChangedFields(t *testing.T) {
+	database, cfg := newTestDB(t)
+	store := NewHistoryStore(database, cfg)
+
+	flavor := FieldChange{Field: "flavor", Status: StatusFlavorChanged, Detail: "flavor: m1.small -> m1.large"}
+	image := FieldChange{Field: "image", Status: StatusImageChanged, Detail: "image: ubuntu -> debian"}
+	server := func(changes ...FieldChange) DiffResult {
+		return DiffResult{ResourceType: ResourceTypeServer, ResourceID: "srv-1", ResourceName: "web", ProjectName: "alpha",
+			Status: changes[0].Status, Changes: changes}
+	}
+
+	runID, err := store.SaveRun("/work", time.Now(), historyReport(server(flavor)))
+	if err != nil {
+		t.Fatalf("SaveRun failed: %v", err)
+	}
+	items, err := store.LoadRunItems(runID)
+	if err != nil {
+		t.Fatalf("LoadRunItems failed: %v", err)
+	}
+	if got := items["alpha"]; len(got) != 1 || len(got[0].Changes) != 1 || got[0].Changes[0] != flavor {
+		t.Fatalf("Expected the saved field changes to be read back, got %+v", got)
+	}
+
+	// The same change is not new
+	if report := ApplyBaseline(historyReport(server(flavor)), runID, items); report.Summary.TotalDrift != 0 || report.Summary.Resolved != 0 {
+		t.Errorf("Expected unchanged drift to be baselined, got %+v", report.Summary)
+	}
+
+	// A further change to the same resource is new, though its first change is not
+	report := ApplyBaseline(historyReport(server(flavor, image)), runID, items)
+	if report.Summary.TotalDrift != 1 || !report.Projects[0].Drifts[0].HasStatus(StatusImageChanged) {
+		t.Errorf("Expected the image change to be new drift, got %+v", report.Projects[0].Drifts)
+	}
+}
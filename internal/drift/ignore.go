@@ -0,0 +1,168 @@
+package drift
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v2"
+)
+
+// IgnoreFileName is the ignore file read from the base path and from each project directory
+const IgnoreFileName = ".oscdriftignore"
+
+// IgnoreRule suppresses expected drift. Empty matchers match anything, but a rule
+// must set at least one of them.
+type IgnoreRule struct {
+	// Type is the resource type, e.g. security-group-rule
+	Type ResourceType `yaml:"type"`
+	// Name is a glob matched against the resource name, or the parent
+	// security group for rules, which have no name
+	Name string `yaml:"name"`
+	// ID is a glob matched against the resource ID
+	ID string `yaml:"id"`
+	// Status is the drift status, or the change status when Field is set
+	Status DriftStatus `yaml:"status"`
+	// Field is a glob matched against changed fields, e.g. metadata.agent_*.
+	// Only the matching changes are suppressed; the drift is ignored once
+	// none of its changes are left.
+	Field string `yaml:"field"`
+	// Reason documents why the drift is expected
+	Reason string `yaml:"reason"`
+}
+
+// IgnoreFile is the structure of an .oscdriftignore file
+type IgnoreFile struct {
+	Ignore []IgnoreRule `yaml:"ignore"`
+}
+
+// LoadIgnoreFile reads ignore rules from path. A missing file yields no rules.
+func LoadIgnoreFile(filePath string) ([]IgnoreRule, error) {
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+
+	var file IgnoreFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse ignore file %s: %w", filePath, err)
+	}
+
+	for i, rule := range file.Ignore {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d in %s: %w", i+1, filePath, err)
+		}
+	}
+	return file.Ignore, nil
+}
+
+// validate checks that the rule matches something and its globs are well formed
+func (r IgnoreRule) validate() error {
+	if r.Type == "" && r.Name == "" && r.ID == "" && r.Status == "" && r.Field == "" {
+		return fmt.Errorf("rule must set at least one of type, name, id, status or field")
+	}
+	for _, pattern := range []string{r.Name, r.ID, r.Field} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchesResource reports whether the rule's type, name and ID matchers match the drift
+func (r IgnoreRule) matchesResource(d DiffResult) bool {
+	if r.Type != "" && r.Type != d.ResourceType {
+		return false
+	}
+	name := d.ResourceName
+	if name == "" {
+		name = d.ParentSG
+	}
+	return globMatch(r.Name, name) && globMatch(r.ID, d.ResourceID)
+}
+
+// matchesChange reports whether a field rule matches a single change
+func (r IgnoreRule) matchesChange(c FieldChange) bool {
+	if r.Status != "" && r.Status != c.Status {
+		return false
+	}
+	return globMatch(r.Field, c.Field)
+}
+
+// ApplyIgnoreRules removes drift matched by rules and returns the remaining drift
+// and the number of drift items that were suppressed entirely. Drift that only
+// loses some of its changes has its status and details recomputed.
+func ApplyIgnoreRules(drifts []DiffResult, rules []IgnoreRule) ([]DiffResult, int) {
+	if len(rules) == 0 {
+		return drifts, 0
+	}
+
+	var kept []DiffResult
+	ignored := 0
+
+	for _, d := range drifts {
+		if d, ok := applyIgnoreRulesTo(d, rules); ok {
+			kept = append(kept, d)
+		} else {
+			ignored++
+		}
+	}
+	return kept, ignored
+}
+
+// applyIgnoreRulesTo applies rules to a single drift, returning false when it is ignored
+func applyIgnoreRulesTo(d DiffResult, rules []IgnoreRule) (DiffResult, bool) {
+	var fieldRules []IgnoreRule
+	for _, rule := range rules {
+		if !rule.matchesResource(d) {
+			continue
+		}
+		if rule.Field == "" {
+			if rule.Status == "" || rule.Status == d.Status {
+				return d, false
+			}
+			continue
+		}
+		fieldRules = append(fieldRules, rule)
+	}
+
+	if len(fieldRules) == 0 || len(d.Changes) == 0 {
+		return d, true
+	}
+
+	var remaining []FieldChange
+	for _, c := range d.Changes {
+		suppressed := false
+		for _, rule := range fieldRules {
+			if rule.matchesChange(c) {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			remaining = append(remaining, c)
+		}
+	}
+
+	if len(remaining) == 0 {
+		return d, false
+	}
+	if len(remaining) < len(d.Changes) {
+		d.Changes = remaining
+		d.Status, d.Details = summarizeChanges(remaining)
+	}
+	return d, true
+}
+
+// globMatch matches value against pattern; an empty pattern matches anything
+func globMatch(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, _ := path.Match(pattern, value)
+	return matched
+}
@@ -0,0 +1,106 @@
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyIgnoreRules(t *testing.T) {
+	drifts := []DiffResult{
+		{ResourceType: ResourceTypeSecurityGroupRule, ResourceID: "rule-1", ParentSG: "default", Status: StatusMissingInState},
+		{ResourceType: ResourceTypeSecurityGroupRule, ResourceID: "rule-2", ParentSG: "web", Status: StatusMissingInState},
+		{ResourceType: ResourceTypeServer, ResourceName: "autoscale-7", ResourceID: "srv-7", Status: StatusMissingInState},
+		{
+			ResourceType: ResourceTypeServer,
+			ResourceName: "web-1",
+			ResourceID:   "srv-1",
+			Status:       StatusMetadataChanged,
+			Changes: []FieldChange{
+				{Field: "metadata.agent_version", Status: StatusMetadataChanged, Detail: `metadata.agent_version: added "2.1"`},
+				{Field: "metadata.role", Status: StatusMetadataChanged, Detail: `metadata.role: "web" -> "db"`},
+			},
+		},
+		{
+			ResourceType: ResourceTypeServer,
+			ResourceName: "web-2",
+			ResourceID:   "srv-2",
+			Status:       StatusMetadataChanged,
+			Changes: []FieldChange{
+				{Field: "metadata.agent_version", Status: StatusMetadataChanged, Detail: `metadata.agent_version: added "2.1"`},
+			},
+		},
+	}
+
+	rules := []IgnoreRule{
+		{Type: ResourceTypeSecurityGroupRule, Name: "default", Status: StatusMissingInState},
+		{Type: ResourceTypeServer, Name: "autoscale-*"},
+		{Type: ResourceTypeServer, Field: "metadata.agent_*"},
+	}
+
+	kept, ignored := ApplyIgnoreRules(drifts, rules)
+
+	// rule-1, autoscale-7 and web-2 are suppressed entirely
+	if ignored != 3 {
+		t.Errorf("Expected 3 ignored drifts, got %d", ignored)
+	}
+	if len(kept) != 2 {
+		t.Fatalf("Expected 2 remaining drifts, got %d: %+v", len(kept), kept)
+	}
+	if kept[0].ResourceID != "rule-2" {
+		t.Errorf("Expected rule-2 to remain, got %s", kept[0].ResourceID)
+	}
+
+	// web-1 only loses the agent metadata change
+	web := kept[1]
+	if web.ResourceID != "srv-1" || len(web.Changes) != 1 {
+		t.Fatalf("Expected srv-1 with 1 remaining change, got %+v", web)
+	}
+	if web.Details != `metadata.role: "web" -> "db"` {
+		t.Errorf("Expected details to be recomputed, got %q", web.Details)
+	}
+}
+
+func TestLoadIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+
+	rules, err := LoadIgnoreFile(filepath.Join(dir, IgnoreFileName))
+	if err != nil || rules != nil {
+		t.Fatalf("Expected no rules and no error for a missing file, got %v, %v", rules, err)
+	}
+
+	valid := filepath.Join(dir, "valid")
+	content := `ignore:
+  - type: security-group-rule
+    status: missing_in_state
+    reason: Neutron default egress rules
+  - field: metadata.agent_*
+`
+	if err := os.WriteFile(valid, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err = LoadIgnoreFile(valid)
+	if err != nil {
+		t.Fatalf("LoadIgnoreFile() error = %v", err)
+	}
+	if len(rules) != 2 || rules[0].Type != ResourceTypeSecurityGroupRule || rules[1].Field != "metadata.agent_*" {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+
+	tests := map[string]string{
+		"empty rule":    "ignore:\n  - reason: matches everything\n",
+		"bad pattern":   "ignore:\n  - name: \"[web\"\n",
+		"unknown field": "ignore:\n  - kind: server\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(dir, "invalid")
+			if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadIgnoreFile(file); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package drift
+
+import (
+	"path/filepath"
+
+	"github.com/marcdicarlo/osc/internal/tfgen"
+)
+
+// ImportFileName is the file written to each project directory by osc drift import
+const ImportFileName = "osc_imports.tf"
+
+// ProjectImports holds the generated Terraform import configuration for a project
+type ProjectImports struct {
+	Project ProjectDir
+	File    *tfgen.File
+	// Count is the number of resources with an import block
+	Count int
+	// Errors lists state and truth files that were skipped
+	Errors []LoadError
+}
+
+// ImportProject builds import and skeleton resource blocks for the servers,
+// security groups and rules in a project that are missing from Terraform state.
+// Resource attributes are taken from the truth, so the truth loader should
+// provide full properties, e.g. CacheTruthLoader.
+func ImportProject(project ProjectDir, opts Options) (*ProjectImports, error) {
+	compared, err := compareProject(project, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProjectImports{
+		Project: project,
+		File:    BuildImportFile(compared.diffs, compared.truth),
+		Count:   countMissingInState(compared.diffs),
+		Errors:  compared.skipped,
+	}, nil
+}
+
+// ImportAllProjects builds import configuration for every project in the base
+// path. Projects that cannot be loaded are returned as errors and skipped, as
+// drift check does, so the other projects can still be imported.
+func ImportAllProjects(basePath string, opts Options) ([]ProjectImports, []LoadError, error) {
+	projects, err := DiscoverProjects(basePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	baseRules, err := LoadIgnoreFile(filepath.Join(basePath, IgnoreFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.IgnoreRules = append(append([]IgnoreRule{}, opts.IgnoreRules...), baseRules...)
+
+	var imports []ProjectImports
+	var errs []LoadError
+	for _, project := range projects {
+		projectImports, err := ImportProject(project, opts)
+		if err != nil {
+			errs = append(errs, LoadError{Project: project.Name, Error: err.Error()})
+			continue
+		}
+		imports = append(imports, *projectImports)
+	}
+	return imports, errs, nil
+}
+
+// BuildImportFile generates import blocks and skeleton resources for drift that
+// is missing from state. Security groups are written before their rules so the
+// rules reference them by address, and resources keep the truth order so the
+// output is stable between runs.
+func BuildImportFile(diffs []DiffResult, truth []Resource) *tfgen.File {
+	missing := make(map[string]bool)
+	for _, d := range diffs {
+		if d.Status == StatusMissingInState {
+			missing[string(d.ResourceType)+"/"+d.ResourceID] = true
+		}
+	}
+
+	byType := make(map[ResourceType][]Resource)
+	for _, r := range truth {
+		if missing[string(r.Type)+"/"+r.ID] {
+			byType[r.Type] = append(byType[r.Type], r)
+		}
+	}
+
+	b := tfgen.NewBuilder(true)
+	b.File.Header = "Generated by osc drift import. Review the attributes before running terraform plan."
+
+	for _, r := range byType[ResourceTypeSecurityGroup] {
+		b.AddSecGroup(tfgen.SecGroup{ID: r.ID, Name: r.Name})
+	}
+	for _, r := range byType[ResourceTypeSecurityGroupRule] {
+		b.AddSecGroupRule(tfgen.SecGroupRule{
+			ID:             r.ID,
+			SecGroupID:     r.ParentID,
+			SecGroupName:   r.ParentName,
+			Direction:      getPropertyString(r.Properties, "direction"),
+			Ethertype:      getPropertyString(r.Properties, "ethertype"),
+			Protocol:       getPropertyString(r.Properties, "protocol"),
+			PortRangeMin:   getPropertyInt(r.Properties, "port_range_min"),
+			PortRangeMax:   getPropertyInt(r.Properties, "port_range_max"),
+			RemoteIPPrefix: getPropertyString(r.Properties, "remote_ip"),
+			RemoteGroupID:  getPropertyString(r.Properties, "remote_group"),
+		})
+	}
+	for _, r := range byType[ResourceTypeServer] {
+		metadata, _ := r.Properties["metadata"].(map[string]string)
+		b.AddServer(tfgen.Server{
+			ID:             r.ID,
+			Name:           r.Name,
+			FlavorID:       getPropertyString(r.Properties, "flavor_id"),
+			FlavorName:     getPropertyString(r.Properties, "flavor_name"),
+			ImageID:        getPropertyString(r.Properties, "image_id"),
+			ImageName:      getPropertyString(r.Properties, "image_name"),
+			SecurityGroups: r.SecurityGroups,
+			Metadata:       metadata,
+		})
+	}
+
+	return &b.File
+}
+
+// countMissingInState counts importable drift
+func countMissingInState(diffs []DiffResult) int {
+	count := 0
+	for _, d := range diffs {
+		if d.Status != StatusMissingInState {
+			continue
+		}
+		switch d.ResourceType {
+		case ResourceTypeServer, ResourceTypeSecurityGroup, ResourceTypeSecurityGroupRule:
+			count++
+		}
+	}
+	return count
+}
+
+// getPropertyInt returns an optional integer property
+func getPropertyInt(props map[string]any, key string) *int {
+	switch v := props[key].(type) {
+	case *int:
+		return v
+	case int:
+		return &v
+	case float64:
+		i := int(v)
+		return &i
+	}
+	return nil
+}
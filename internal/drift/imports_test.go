@@ -0,0 +1,100 @@
+package drift
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildImportFile(t *testing.T) {
+	port := 22
+	truth := []Resource{
+		{ID: "sg-1", Name: "web", Type: ResourceTypeSecurityGroup},
+		{ID: "sg-2", Name: "managed", Type: ResourceTypeSecurityGroup},
+		{
+			ID: "rule-1", Type: ResourceTypeSecurityGroupRule, ParentID: "sg-1", ParentName: "web",
+			Properties: map[string]any{
+				"direction": "ingress", "ethertype": "IPv4", "protocol": "tcp",
+				"port_range_min": &port, "port_range_max": &port, "remote_ip": "10.0.0.0/8",
+			},
+		},
+		{
+			ID: "srv-1", Name: "web-1", Type: ResourceTypeServer, SecurityGroups: []string{"web"},
+			Properties: map[string]any{
+				"flavor_name": "m1.small", "image_id": "img-1",
+				"metadata": map[string]string{"role": "web"},
+			},
+		},
+		{ID: "vol-1", Name: "data", Type: ResourceTypeVolume},
+	}
+	diffs := []DiffResult{
+		{ResourceType: ResourceTypeSecurityGroup, ResourceID: "sg-1", Status: StatusMissingInState},
+		{ResourceType: ResourceTypeSecurityGroup, ResourceID: "sg-2", Status: StatusNameChanged},
+		{ResourceType: ResourceTypeSecurityGroupRule, ResourceID: "rule-1", Status: StatusMissingInState},
+		{ResourceType: ResourceTypeServer, ResourceID: "srv-1", Status: StatusMissingInState},
+		{ResourceType: ResourceTypeVolume, ResourceID: "vol-1", Status: StatusMissingInState},
+	}
+
+	file := BuildImportFile(diffs, truth)
+
+	if len(file.Imports) != 3 {
+		t.Fatalf("expected 3 import blocks, got %d: %+v", len(file.Imports), file.Imports)
+	}
+	if got := countMissingInState(diffs); got != 3 {
+		t.Errorf("countMissingInState = %d, want 3", got)
+	}
+
+	var out strings.Builder
+	file.WriteTo(&out)
+	got := out.String()
+
+	for _, want := range []string{
+		`to = openstack_networking_secgroup_v2.web`,
+		`to = openstack_networking_secgroup_rule_v2.web_ingress_tcp_22`,
+		`to = openstack_compute_instance_v2.web-1`,
+		`security_group_id = openstack_networking_secgroup_v2.web.id`,
+		`remote_ip_prefix  = "10.0.0.0/8"`,
+		`flavor_name     = "m1.small"`,
+		`"role" = "web"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "managed") || strings.Contains(got, "vol-1") {
+		t.Errorf("only missing_in_state servers, security groups and rules should be imported\n%s", got)
+	}
+}
+
+func TestImportAllProjectsSkipsFailedProjects(t *testing.T) {
+	base := t.TempDir()
+	for _, name := range []string{"alpha", "beta"} {
+		if err := EnsureProjectDirs(filepath.Join(base, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(base, "beta", "state", "terraform.tfstate"), []byte(`{"version": 4, "resources": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.LoadTruth = func(project ProjectDir) ([]Resource, error) {
+		if project.Name == "alpha" {
+			return nil, errors.New("cache unavailable")
+		}
+		return []Resource{{ID: "sg-1", Name: "web", Type: ResourceTypeSecurityGroup, ProjectName: project.Name}}, nil
+	}
+
+	imports, failed, err := ImportAllProjects(base, opts)
+	if err != nil {
+		t.Fatalf("ImportAllProjects: %v", err)
+	}
+	if len(failed) != 1 || failed[0].Project != "alpha" || !strings.Contains(failed[0].Error, "cache unavailable") {
+		t.Errorf("expected alpha to fail, got %+v", failed)
+	}
+	if len(imports) != 1 || imports[0].Project.Name != "beta" || imports[0].Count != 1 {
+		t.Errorf("expected beta to be imported, got %+v", imports)
+	}
+}
@@ -1,21 +1,38 @@
 package drift
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
 )
 
 // ProjectDir represents a project directory structure
 type ProjectDir struct {
-	Name     string
-	BasePath string
+	Name      string
+	BasePath  string
 	StatePath string
 	TruthPath string
+	// StateConfigPath is the project's state.yaml describing remote state
+	StateConfigPath string
+	// StatePaths are read instead of StatePath when the project config lists state_dirs
+	StatePaths []string
+	// Config maps the directory to an OpenStack project; nil matches projects
+	// by directory name
+	Config *ProjectConfig
 }
 
+// ErrNoProjects is returned by DiscoverProjects when the base path holds no project directories
+var ErrNoProjects = errors.New("no project directories found")
+
 // DiscoverProjects finds all project directories in the given base path
-// Each project directory should contain 'state' and 'truth' subdirectories
+// Each project directory should contain 'state' and 'truth' subdirectories,
+// a state.yaml describing remote state, or a project mapping
 func DiscoverProjects(basePath string) ([]ProjectDir, error) {
 	// Verify base path exists
 	info, err := os.Stat(basePath)
@@ -31,97 +48,357 @@ func DiscoverProjects(basePath string) ([]ProjectDir, error) {
 		return nil, fmt.Errorf("failed to read base directory: %w", err)
 	}
 
-	var projects []ProjectDir
-
+	var dirNames []string
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
+		if entry.IsDir() {
+			dirNames = append(dirNames, entry.Name())
 		}
+	}
+
+	configs, err := loadProjectConfigs(basePath, dirNames)
+	if err != nil {
+		return nil, err
+	}
 
-		projectPath := filepath.Join(basePath, entry.Name())
-		statePath := filepath.Join(projectPath, "state")
-		truthPath := filepath.Join(projectPath, "truth")
+	var projects []ProjectDir
+
+	for _, name := range dirNames {
+		project := NewProjectDir(basePath, name, configs[name])
 
 		// Check if both state and truth directories exist
-		stateExists := dirExists(statePath)
-		truthExists := dirExists(truthPath)
+		stateExists := dirExists(project.StatePath)
+		truthExists := dirExists(project.TruthPath)
+		_, err := os.Stat(project.StateConfigPath)
+		remoteExists := err == nil
 
-		if !stateExists && !truthExists {
+		if !stateExists && !truthExists && !remoteExists && project.Config == nil {
 			// Skip directories that don't have either subdirectory
 			continue
 		}
 
-		projects = append(projects, ProjectDir{
-			Name:      entry.Name(),
-			BasePath:  projectPath,
-			StatePath: statePath,
-			TruthPath: truthPath,
-		})
+		projects = append(projects, project)
 	}
 
 	if len(projects) == 0 {
-		return nil, fmt.Errorf("no project directories found in %s (expected directories with 'state' and/or 'truth' subdirectories)", basePath)
+		return nil, fmt.Errorf("%w in %s (expected directories with 'state' and/or 'truth' subdirectories, a %s or a %s)", ErrNoProjects, basePath, StateConfigFileName, ProjectConfigFileName)
 	}
 
 	return projects, nil
 }
 
+// TruthLoader loads the truth resources for a project directory
+type TruthLoader func(project ProjectDir) ([]Resource, error)
+
+// LoadTruthFiles loads truth resources from the project's truth/ directory
+func LoadTruthFiles(project ProjectDir) ([]Resource, error) {
+	if !dirExists(project.TruthPath) {
+		return nil, nil
+	}
+	return LoadTruthFromDir(project.TruthPath, project.Name)
+}
+
 // LoadProject loads resources from a single project directory
 func LoadProject(project ProjectDir) (state, truth []Resource, err error) {
+	return LoadProjectWithTruth(project, nil)
+}
+
+// LoadProjectWithTruth loads state from a project directory and truth using
+// loadTruth, or the project's truth files when loadTruth is nil. Files that
+// cannot be parsed are skipped with a warning on stderr.
+func LoadProjectWithTruth(project ProjectDir, loadTruth TruthLoader) (state, truth []Resource, err error) {
+	opts := DefaultOptions()
+	opts.LoadTruth = loadTruth
+	loaded, err := loadProject(project, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnSkipped(loaded.skipped)
+	return loaded.state, loaded.truth, nil
+}
+
+// loadedProject holds the resources of a project and the files skipped while loading them
+type loadedProject struct {
+	state   []Resource
+	truth   []Resource
+	skipped []LoadError
+}
+
+// loadProject loads the state and truth resources of a project
+func loadProject(project ProjectDir, opts Options) (*loadedProject, error) {
+	loaded := &loadedProject{}
+	var err error
+
 	// Load state resources
-	if dirExists(project.StatePath) {
-		state, err = LoadTerraformStateFromDir(project.StatePath, project.Name)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to load state for project %s: %w", project.Name, err)
-		}
+	loaded.state, loaded.skipped, err = loadProjectState(project, opts.HTTPClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state for project %s: %w", project.Name, err)
 	}
 
 	// Load truth resources
-	if dirExists(project.TruthPath) {
-		truth, err = LoadTruthFromDir(project.TruthPath, project.Name)
+	var skipped []LoadError
+	loaded.truth, skipped, err = loadTruth(project, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load truth for project %s: %w", project.Name, err)
+	}
+	loaded.skipped = append(loaded.skipped, skipped...)
+
+	return loaded, nil
+}
+
+// loadTruth loads the truth resources of a project with opts.LoadTruth, or
+// from its truth files, returning the files that could not be parsed
+func loadTruth(project ProjectDir, opts Options) ([]Resource, []LoadError, error) {
+	if opts.LoadTruth != nil {
+		truth, err := opts.LoadTruth(project)
+		return truth, nil, err
+	}
+	if !dirExists(project.TruthPath) {
+		return nil, nil, nil
+	}
+	return loadTruthDir(project.TruthPath, project.Name)
+}
+
+// LoadProjectState loads the state files in the project's state directories and
+// the remote state described by its state.yaml. A nil client uses the default
+// remote state client. State files that cannot be parsed are skipped with a
+// warning on stderr.
+func LoadProjectState(project ProjectDir, client *http.Client) ([]Resource, error) {
+	state, skipped, err := loadProjectState(project, client)
+	warnSkipped(skipped)
+	return state, err
+}
+
+// loadProjectState loads local and remote state, returning the local state
+// files that could not be parsed
+func loadProjectState(project ProjectDir, client *http.Client) ([]Resource, []LoadError, error) {
+	var state []Resource
+	var skipped []LoadError
+
+	// Mapped state directories must exist; the default one is optional
+	stateDirs := project.StatePaths
+	if len(stateDirs) == 0 && dirExists(project.StatePath) {
+		stateDirs = []string{project.StatePath}
+	}
+	for _, dir := range stateDirs {
+		local, localSkipped, err := loadTerraformStateDir(dir, project.Name)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to load truth for project %s: %w", project.Name, err)
+			return nil, nil, err
 		}
+		state = append(state, local...)
+		skipped = append(skipped, localSkipped...)
 	}
 
-	return state, truth, nil
+	if project.StateConfigPath == "" {
+		return state, skipped, nil
+	}
+	cfg, err := LoadStateConfig(project.StateConfigPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg == nil {
+		return state, skipped, nil
+	}
+	remote, err := FetchHTTPState(client, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(state, ExtractResourcesFromTerraform(remote, project.Name)...), skipped, nil
+}
+
+// warnSkipped prints a warning on stderr for each skipped file
+func warnSkipped(skipped []LoadError) {
+	for _, e := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: failed to parse %s: %s\n", e.File, e.Error)
+	}
+}
+
+// Options controls how projects are loaded and compared
+type Options struct {
+	// LoadTruth loads the truth resources for a project; nil reads the
+	// project's truth/ files
+	LoadTruth TruthLoader
+	// Compare controls which properties are compared
+	Compare CompareOptions
+	// IgnoreRules suppress expected drift in every project, in addition to
+	// the rules in each project's ignore file
+	IgnoreRules []IgnoreRule
+	// HTTPClient fetches remote state; nil uses the default client
+	HTTPClient *http.Client
+	// Workers is the number of projects processed concurrently; values
+	// below 1 process one project at a time
+	Workers int
+	// PlanFile is the terraform show -json output of a saved plan, relative to
+	// each project directory. When set, drift is predicted for after the plan
+	// is applied and projects without the file are skipped.
+	PlanFile string
+}
+
+// DefaultOptions reads truth files and compares all supported properties
+func DefaultOptions() Options {
+	return Options{
+		Compare: DefaultCompareOptions(),
+	}
 }
 
 // ProcessProject loads and compares resources for a single project
 func ProcessProject(project ProjectDir) (*ProjectDrift, error) {
-	state, truth, err := LoadProject(project)
+	return ProcessProjectWithOptions(project, DefaultOptions())
+}
+
+// ProcessProjectWithOptions loads and compares resources for a single project
+func ProcessProjectWithOptions(project ProjectDir, opts Options) (*ProjectDrift, error) {
+	if opts.PlanFile != "" {
+		return processProjectPlan(project, opts)
+	}
+
+	compared, err := compareProject(project, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	// Compare resources
-	diffs := CompareResources(state, truth)
-
 	return &ProjectDrift{
 		ProjectName: project.Name,
-		Drifts:      diffs,
-		StateCount:  CountResources(state),
-		TruthCount:  CountResources(truth),
+		Drifts:      compared.diffs,
+		StateCount:  CountResources(compared.state),
+		TruthCount:  CountResources(compared.truth),
+		Ignored:     compared.ignored,
+		Checked:     checkedResources(compared.state, compared.truth),
+		Errors:      compared.skipped,
 	}, nil
 }
 
+// checkedResources lists the resources in state or truth once each, state first
+func checkedResources(state, truth []Resource) []CheckedResource {
+	seen := make(map[string]bool)
+	var checked []CheckedResource
+	for _, resources := range [][]Resource{state, truth} {
+		for i := range resources {
+			res := &resources[i]
+			key := string(res.Type) + "/" + res.ID
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			checked = append(checked, CheckedResource{
+				Type:     res.Type,
+				Name:     res.Name,
+				ID:       res.ID,
+				ParentSG: getParentSG(res),
+				Address:  res.Address,
+			})
+		}
+	}
+	return checked
+}
+
+// comparedProject holds a loaded project and its drift after ignore rules
+type comparedProject struct {
+	*loadedProject
+	diffs   []DiffResult
+	ignored int
+}
+
+// compareProject loads a project, compares state with truth and applies the
+// ignore rules, returning the loaded resources alongside the remaining drift
+func compareProject(project ProjectDir, opts Options) (*comparedProject, error) {
+	loaded, err := loadProject(project, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := projectIgnoreRules(project, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare resources
+	compared := &comparedProject{loadedProject: loaded}
+	diffs := CompareResourcesWithOptions(loaded.state, loaded.truth, opts.Compare)
+	compared.diffs, compared.ignored = ApplyIgnoreRules(diffs, rules)
+
+	return compared, nil
+}
+
+// projectIgnoreRules returns the ignore rules in opts followed by those in the project's ignore file
+func projectIgnoreRules(project ProjectDir, opts Options) ([]IgnoreRule, error) {
+	projectRules, err := LoadIgnoreFile(filepath.Join(project.BasePath, IgnoreFileName))
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]IgnoreRule{}, opts.IgnoreRules...), projectRules...), nil
+}
+
 // ProcessAllProjects processes all projects in the base path
 func ProcessAllProjects(basePath string) (*DriftReport, error) {
+	return ProcessAllProjectsWithOptions(basePath, DefaultOptions())
+}
+
+// ProcessAllProjectsWithOptions processes all projects in the base path, up to
+// opts.Workers at a time. Projects are reported in directory order; projects
+// that cannot be loaded are recorded in the report's errors and skipped, as
+// are projects without opts.PlanFile when it is set. It is an error when no
+// project has the plan file.
+func ProcessAllProjectsWithOptions(basePath string, opts Options) (*DriftReport, error) {
 	projects, err := DiscoverProjects(basePath)
 	if err != nil {
 		return nil, err
 	}
 
-	report := NewDriftReport()
+	baseRules, err := LoadIgnoreFile(filepath.Join(basePath, IgnoreFileName))
+	if err != nil {
+		return nil, err
+	}
+	opts.IgnoreRules = append(append([]IgnoreRule{}, opts.IgnoreRules...), baseRules...)
 
-	for _, project := range projects {
-		projectDrift, err := ProcessProject(project)
-		if err != nil {
-			// Log warning but continue with other projects
-			fmt.Printf("Warning: failed to process project %s: %v\n", project.Name, err)
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Each worker writes to its project's slot so results keep directory order
+	results := make([]*ProjectDrift, len(projects))
+	errs := make([]error, len(projects))
+	sem := semaphore.NewWeighted(int64(workers))
+	var wg sync.WaitGroup
+
+	planned := 0
+	for i, project := range projects {
+		if opts.PlanFile != "" && !project.HasPlan(opts.PlanFile) {
 			continue
 		}
-		report.AddProject(*projectDrift)
+		planned++
+
+		wg.Add(1)
+		go func(i int, project ProjectDir) {
+			defer wg.Done()
+
+			if err := sem.Acquire(context.Background(), 1); err != nil {
+				errs[i] = err
+				return
+			}
+			defer sem.Release(1)
+
+			results[i], errs[i] = ProcessProjectWithOptions(project, opts)
+		}(i, project)
+	}
+	wg.Wait()
+
+	// A plan file no project has is a mistake, not a clean prediction
+	if opts.PlanFile != "" && planned == 0 {
+		return nil, fmt.Errorf("no project directory in %s has the plan file %s", basePath, opts.PlanFile)
+	}
+
+	report := NewDriftReport()
+	report.Summary.PlanFile = opts.PlanFile
+	for i, project := range projects {
+		if errs[i] != nil {
+			// Record the error and continue with other projects
+			report.AddError(project.Name, errs[i])
+			continue
+		}
+		if results[i] != nil {
+			report.AddProject(*results[i])
+		}
 	}
 
 	return report, nil
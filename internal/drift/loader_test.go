@@ -0,0 +1,68 @@
+package drift
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProcessAllProjectsConcurrently(t *testing.T) {
+	base := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(base, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var names []string
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("project%02d", i)
+		names = append(names, name)
+		write(name+"/state/terraform.tfstate", `{"version": 4, "resources": [{"mode": "managed",
+			"type": "openstack_compute_instance_v2", "name": "s",
+			"instances": [{"attributes": {"id": "srv-`+name+`", "name": "web"}}]}]}`)
+	}
+	// A broken file is skipped without dropping the rest of the project
+	write("project03/state/broken.json", "{")
+	// A project with an invalid state.yaml cannot be loaded at all
+	write("project07/state.yaml", "backend: s3\n")
+
+	opts := DefaultOptions()
+	opts.Workers = 4
+	report, err := ProcessAllProjectsWithOptions(base, opts)
+	if err != nil {
+		t.Fatalf("ProcessAllProjectsWithOptions: %v", err)
+	}
+
+	if len(report.Projects) != 11 {
+		t.Fatalf("expected 11 projects, got %d", len(report.Projects))
+	}
+	i := 0
+	for _, name := range names {
+		if name == "project07" {
+			continue
+		}
+		if got := report.Projects[i].ProjectName; got != name {
+			t.Errorf("project %d: expected %s, got %s", i, name, got)
+		}
+		i++
+	}
+	if got := report.Projects[3].StateCount.Servers; got != 1 {
+		t.Errorf("expected the valid state of project03 to be loaded, got %d servers", got)
+	}
+
+	if len(report.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %+v", report.Errors)
+	}
+	if e := report.Errors[0]; e.Project != "project03" || filepath.Base(e.File) != "broken.json" || e.Error == "" {
+		t.Errorf("unexpected file error: %+v", e)
+	}
+	if e := report.Errors[1]; e.Project != "project07" || e.File != "" || e.Error == "" {
+		t.Errorf("unexpected project error: %+v", e)
+	}
+}
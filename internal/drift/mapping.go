@@ -0,0 +1,189 @@
+package drift
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/marcdicarlo/osc/internal/config"
+	"github.com/marcdicarlo/osc/internal/filter"
+	"gopkg.in/yaml.v2"
+)
+
+// ProjectConfigFileName is the optional per-project file mapping a project
+// directory to an OpenStack project
+const ProjectConfigFileName = "osc-project.yaml"
+
+// ProjectMappingFileName is the optional file in the base path mapping
+// project directories to OpenStack projects by directory name
+const ProjectMappingFileName = "osc-projects.yaml"
+
+// ProjectConfig maps a project directory to exactly one OpenStack project.
+// Without one, the directory name is a substring filter on project names.
+type ProjectConfig struct {
+	// ProjectID is the OpenStack project ID; it takes precedence over ProjectName
+	ProjectID string `yaml:"project_id"`
+	// ProjectName is the exact OpenStack project name (default: the directory name)
+	ProjectName string `yaml:"project_name"`
+	// Cloud and Region are checked against OS_CLOUD and OS_REGION_NAME when
+	// truth is read from the cache, which holds a single cloud and region
+	Cloud  string `yaml:"cloud"`
+	Region string `yaml:"region"`
+	// StateDirs are the state directories to read, relative to the project
+	// directory, e.g. one per Terraform workspace (default: state)
+	StateDirs []string `yaml:"state_dirs"`
+}
+
+// projectMapping is the layout of the base path mapping file
+type projectMapping struct {
+	Projects map[string]*ProjectConfig `yaml:"projects"`
+}
+
+// LoadProjectConfig reads a project's osc-project.yaml. A missing file yields nil.
+func LoadProjectConfig(path string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project config: %w", err)
+	}
+
+	var cfg ProjectConfig
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse project config %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid project config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// LoadProjectMapping reads the base path's osc-projects.yaml, keyed by project
+// directory name. A missing file yields nil.
+func LoadProjectMapping(path string) (map[string]*ProjectConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project mapping: %w", err)
+	}
+
+	var mapping projectMapping
+	if err := yaml.UnmarshalStrict(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse project mapping %s: %w", path, err)
+	}
+	for name, cfg := range mapping.Projects {
+		if cfg == nil {
+			cfg = &ProjectConfig{}
+			mapping.Projects[name] = cfg
+		}
+		if err := cfg.validate(); err != nil {
+			return nil, fmt.Errorf("invalid project mapping %s for %s: %w", path, name, err)
+		}
+	}
+	return mapping.Projects, nil
+}
+
+// validate checks that state directories stay inside the project directory
+func (c *ProjectConfig) validate() error {
+	for _, dir := range c.StateDirs {
+		clean := filepath.Clean(dir)
+		if dir == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("state_dirs entry %q must be a relative path inside the project directory", dir)
+		}
+	}
+	return nil
+}
+
+// CheckCloud reports an error when the mapped cloud or region differs from
+// OS_CLOUD or OS_REGION_NAME. Unset values are not checked.
+func (c *ProjectConfig) CheckCloud() error {
+	if cloud := os.Getenv("OS_CLOUD"); c.Cloud != "" && cloud != "" && c.Cloud != cloud {
+		return fmt.Errorf("project is mapped to cloud %s but OS_CLOUD is %s", c.Cloud, cloud)
+	}
+	if region := os.Getenv("OS_REGION_NAME"); c.Region != "" && region != "" && c.Region != region {
+		return fmt.Errorf("project is mapped to region %s but OS_REGION_NAME is %s", c.Region, region)
+	}
+	return nil
+}
+
+// MatchesProject reports whether a cached project belongs to a project
+// directory: exactly the mapped project when the directory has a mapping,
+// otherwise any project in scope whose name contains the directory name
+func (p ProjectDir) MatchesProject(id, name string, cfg *config.Config) bool {
+	if p.Config == nil {
+		return filter.New(p.Name, cfg).Includes(name)
+	}
+	if p.Config.ProjectID != "" {
+		return id == p.Config.ProjectID
+	}
+	projectName := p.Config.ProjectName
+	if projectName == "" {
+		projectName = p.Name
+	}
+	return name == projectName
+}
+
+// NewProjectDir describes the project directory name in basePath. mapping is
+// the directory's project config, or nil when it has none.
+func NewProjectDir(basePath, name string, mapping *ProjectConfig) ProjectDir {
+	projectPath := filepath.Join(basePath, name)
+	project := ProjectDir{
+		Name:            name,
+		BasePath:        projectPath,
+		StatePath:       filepath.Join(projectPath, "state"),
+		TruthPath:       filepath.Join(projectPath, "truth"),
+		StateConfigPath: filepath.Join(projectPath, StateConfigFileName),
+		Config:          mapping,
+	}
+	if mapping != nil {
+		for _, dir := range mapping.StateDirs {
+			project.StatePaths = append(project.StatePaths, filepath.Join(projectPath, dir))
+		}
+	}
+	return project
+}
+
+// loadProjectConfigs returns the mapping of each project directory in
+// basePath: its own osc-project.yaml, else its entry in osc-projects.yaml
+func loadProjectConfigs(basePath string, dirNames []string) (map[string]*ProjectConfig, error) {
+	configs, err := LoadProjectMapping(filepath.Join(basePath, ProjectMappingFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make(map[string]bool, len(dirNames))
+	for _, name := range dirNames {
+		dirs[name] = true
+	}
+	var unknown []string
+	for name := range configs {
+		if !dirs[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%s maps projects with no directory in %s: %s",
+			ProjectMappingFileName, basePath, strings.Join(unknown, ", "))
+	}
+
+	if configs == nil {
+		configs = make(map[string]*ProjectConfig)
+	}
+	for _, name := range dirNames {
+		cfg, err := LoadProjectConfig(filepath.Join(basePath, name, ProjectConfigFileName))
+		if err != nil {
+			return nil, err
+		}
+		if cfg != nil {
+			configs[name] = cfg
+		}
+	}
+	return configs, nil
+}
@@ -0,0 +1,155 @@
+package drift
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiscoverProjectsWithMapping(t *testing.T) {
+	base := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(base, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := func(id string) string {
+		return `{"version": 4, "resources": [{"mode": "managed", "type": "openstack_compute_instance_v2", "name": "s",
+			"instances": [{"attributes": {"id": "` + id + `", "name": "` + id + `"}}]}]}`
+	}
+
+	write(ProjectMappingFileName, `projects:
+  web-prod:
+    project_name: prod
+  web-preprod:
+    project_name: wrong
+`)
+	// The directory's own file takes precedence over the base path mapping
+	write("web-preprod/"+ProjectConfigFileName, "project_name: preprod\n")
+	write("web-prod/state/terraform.tfstate", server("srv-default"))
+	// Workspaces are read from state_dirs instead of state/
+	write("workspaces/"+ProjectConfigFileName, "project_id: p3\nstate_dirs: [state/blue, state/green]\n")
+	write("workspaces/state/blue/terraform.tfstate", server("srv-blue"))
+	write("workspaces/state/green/terraform.tfstate", server("srv-green"))
+	write("workspaces/state/ignored.tfstate", server("srv-ignored"))
+
+	projects, err := DiscoverProjects(base)
+	if err != nil {
+		t.Fatalf("DiscoverProjects: %v", err)
+	}
+	if len(projects) != 3 {
+		t.Fatalf("expected 3 projects, got %+v", projects)
+	}
+	byName := make(map[string]ProjectDir)
+	for _, p := range projects {
+		byName[p.Name] = p
+	}
+	if cfg := byName["web-prod"].Config; cfg == nil || cfg.ProjectName != "prod" {
+		t.Errorf("web-prod: expected mapping to prod, got %+v", cfg)
+	}
+	if cfg := byName["web-preprod"].Config; cfg == nil || cfg.ProjectName != "preprod" {
+		t.Errorf("web-preprod: expected osc-project.yaml to win, got %+v", cfg)
+	}
+
+	state, err := LoadProjectState(byName["workspaces"], nil)
+	if err != nil {
+		t.Fatalf("LoadProjectState: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, r := range state {
+		ids[r.ID] = true
+	}
+	if len(state) != 2 || !ids["srv-blue"] || !ids["srv-green"] {
+		t.Errorf("expected srv-blue and srv-green, got %+v", state)
+	}
+
+	// A mapped state directory that does not exist is an error
+	missing := byName["workspaces"]
+	missing.StatePaths = append(missing.StatePaths, filepath.Join(missing.BasePath, "state", "red"))
+	if _, err := LoadProjectState(missing, nil); err == nil {
+		t.Error("expected error for missing state directory")
+	}
+
+	// Mapping a directory that does not exist is an error
+	write(ProjectMappingFileName, "projects:\n  typo: {project_name: prod}\n")
+	if _, err := DiscoverProjects(base); err == nil || !strings.Contains(err.Error(), "typo") {
+		t.Errorf("expected error naming the unknown directory, got %v", err)
+	}
+}
+
+func TestLoadProjectConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"unknown field":    "project: prod\n",
+		"absolute dir":     "state_dirs: [/var/state]\n",
+		"parent directory": "state_dirs: [../other/state]\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, ProjectConfigFileName)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadProjectConfig(path); err == nil {
+				t.Errorf("expected error for %q", content)
+			}
+		})
+	}
+
+	cfg, err := LoadProjectConfig(filepath.Join(dir, "missing.yaml"))
+	if cfg != nil || err != nil {
+		t.Errorf("expected nil config for a missing file, got %+v, %v", cfg, err)
+	}
+}
+
+func TestMatchCachedProjects(t *testing.T) {
+	database, cfg := newTestDB(t)
+	if _, err := database.Exec(`INSERT INTO os_project_names VALUES ('p1', 'prod'), ('p2', 'preprod'), ('p3', 'staging')`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		project ProjectDir
+		want    []string
+	}{
+		{"substring filter", ProjectDir{Name: "prod"}, []string{"p1", "p2"}},
+		{"directory name", ProjectDir{Name: "prod", Config: &ProjectConfig{}}, []string{"p1"}},
+		{"project name", ProjectDir{Name: "web", Config: &ProjectConfig{ProjectName: "preprod"}}, []string{"p2"}},
+		{"project ID", ProjectDir{Name: "prod", Config: &ProjectConfig{ProjectID: "p3", ProjectName: "prod"}}, []string{"p3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MatchCachedProjects(context.Background(), database, cfg, tt.project)
+			if err != nil {
+				t.Fatalf("MatchCachedProjects: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for _, id := range tt.want {
+				if _, ok := got[id]; !ok {
+					t.Errorf("expected %s in %v", id, got)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectConfigCheckCloud(t *testing.T) {
+	t.Setenv("OS_CLOUD", "east")
+	t.Setenv("OS_REGION_NAME", "")
+
+	if err := (&ProjectConfig{Cloud: "east", Region: "RegionOne"}).CheckCloud(); err != nil {
+		t.Errorf("expected matching cloud and unset region to pass, got %v", err)
+	}
+	if err := (&ProjectConfig{Cloud: "west"}).CheckCloud(); err == nil {
+		t.Error("expected error for a different cloud")
+	}
+}
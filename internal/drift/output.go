@@ -3,10 +3,18 @@ package drift
 import (
 	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"slices"
+	"sort"
 	"strings"
 	"text/tabwriter"
+
+	"github.com/marcdicarlo/osc/internal/output"
+	"github.com/marcdicarlo/osc/internal/version"
 )
 
 // OutputFormat represents the output format type
@@ -16,75 +24,155 @@ const (
 	FormatTable OutputFormat = "table"
 	FormatJSON  OutputFormat = "json"
 	FormatCSV   OutputFormat = "csv"
+	// FormatJUnit writes JUnit XML with a testcase per resource for CI test reports
+	FormatJUnit OutputFormat = "junit"
+	// FormatSARIF writes a SARIF 2.1.0 log with a result per drift item
+	FormatSARIF OutputFormat = "sarif"
+	// FormatMarkdown writes a collapsible per-project summary for pull request comments
+	FormatMarkdown OutputFormat = "markdown"
+	// FormatYAML writes the report with the structure of JSON output
+	FormatYAML OutputFormat = "yaml"
+	// FormatNDJSON writes one JSON object per drift item and line for log pipelines
+	FormatNDJSON OutputFormat = "ndjson"
+)
+
+// GroupByModule groups table output by Terraform module
+const GroupByModule = "module"
+
+// Table group names for drift outside a module
+const (
+	rootModuleGroup = "(root module)"
+	notInStateGroup = "(not in state)"
 )
 
+// csvHeader is the header row of CSV drift output
+var csvHeader = []string{"project", "resource_type", "name", "id", "parent_sg", "status", "details", "address", "module_path", "index"}
+
 // DriftFormatter formats drift reports
 type DriftFormatter struct {
 	Writer io.Writer
 	Format OutputFormat
+	// GroupBy groups table rows; the only supported value is GroupByModule
+	GroupBy string
+	// ErrWriter receives load errors for formats that cannot include them (csv)
+	ErrWriter io.Writer
 }
 
-// NewDriftFormatter creates a new drift formatter
-func NewDriftFormatter(w io.Writer, format string) *DriftFormatter {
+// NewDriftFormatter creates a new drift formatter. It reports unknown formats
+// and invalid format arguments, as output.NewFormatter does.
+func NewDriftFormatter(w io.Writer, format string) (*DriftFormatter, error) {
 	var f OutputFormat
 	switch strings.ToLower(format) {
 	case "json":
 		f = FormatJSON
 	case "csv":
 		f = FormatCSV
+	case "junit":
+		f = FormatJUnit
+	case "sarif":
+		f = FormatSARIF
+	case "markdown", "md":
+		f = FormatMarkdown
+	case "yaml", "yml":
+		f = FormatYAML
+	case "ndjson":
+		f = FormatNDJSON
 	default:
-		f = FormatTable
+		var err error
+		if f, err = registeredFormat(format); err != nil {
+			return nil, err
+		}
 	}
-	return &DriftFormatter{Writer: w, Format: f}
+	return &DriftFormatter{Writer: w, Format: f, ErrWriter: os.Stderr}, nil
+}
+
+// registeredFormat returns a format registered with the output package, which
+// works for drift too. The arguments of template and jsonpath keep their case.
+func registeredFormat(format string) (OutputFormat, error) {
+	_, err := output.NewFormatter(format, io.Discard)
+	if err == nil {
+		return OutputFormat(format), nil
+	}
+	if lower := strings.ToLower(format); output.ValidateFormat(lower) {
+		return OutputFormat(lower), nil
+	}
+	var invalid *output.ErrInvalidFormat
+	if errors.As(err, &invalid) {
+		invalid.Valid = append(invalid.Valid, string(FormatJUnit), string(FormatMarkdown), string(FormatSARIF))
+	}
+	return "", err
 }
 
 // FormatReport formats a drift report according to the formatter's format
 func (f *DriftFormatter) FormatReport(report *DriftReport) error {
-	switch f.Format {
-	case FormatJSON:
-		return f.formatJSON(report)
-	case FormatCSV:
-		return f.formatCSV(report)
-	default:
-		return f.formatTable(report)
+	return output.FormatDocument(string(f.Format), f.Writer, f.document(report))
+}
+
+// document describes a drift report for the output package. Formats drift
+// renders itself take precedence; any other registered format writes the
+// report (json), its CSV rows or, for template, name and id, its drift items.
+func (f *DriftFormatter) document(report *DriftReport) *output.Document {
+	return &output.Document{
+		Value:   report,
+		Data:    output.NewOutputData(csvHeader, csvRows(report)),
+		Items:   reportDrifts(report),
+		NameKey: "resource_name",
+		IDKey:   "resource_id",
+		Renderers: map[output.Format]func(io.Writer) error{
+			output.Format(FormatTable):    func(io.Writer) error { return f.formatTable(report) },
+			output.Format(FormatCSV):      func(io.Writer) error { return f.formatCSV(report) },
+			output.Format(FormatJUnit):    func(io.Writer) error { return f.formatJUnit(report) },
+			output.Format(FormatSARIF):    func(io.Writer) error { return f.formatSARIF(report) },
+			output.Format(FormatMarkdown): func(io.Writer) error { return f.formatMarkdown(report) },
+			output.Format(FormatNDJSON):   func(io.Writer) error { return f.formatNDJSON(report) },
+		},
 	}
 }
 
+// formatNDJSON writes each drift item as a line of JSON. Load errors go to
+// ErrWriter, as for CSV.
+func (f *DriftFormatter) formatNDJSON(report *DriftReport) error {
+	if err := output.WriteLines(f.Writer, reportDrifts(report)); err != nil {
+		return err
+	}
+	f.warnLoadErrors(report)
+	return nil
+}
+
+// reportDrifts returns the drift items of all projects of a report
+func reportDrifts(report *DriftReport) []DiffResult {
+	drifts := []DiffResult{}
+	for _, project := range report.Projects {
+		drifts = append(drifts, project.Drifts...)
+	}
+	return drifts
+}
+
 // formatTable formats the drift report as a table
 func (f *DriftFormatter) formatTable(report *DriftReport) error {
 	w := tabwriter.NewWriter(f.Writer, 0, 0, 2, ' ', 0)
 
-	// Print header
-	fmt.Fprintln(w, "PROJECT\tRESOURCE TYPE\tNAME\tID\tSTATUS\tDETAILS")
-	fmt.Fprintln(w, "-------\t-------------\t----\t--\t------\t-------")
-
-	// Print rows for each project
-	for _, project := range report.Projects {
-		for _, drift := range project.Drifts {
-			name := drift.ResourceName
-			if name == "" && drift.ParentSG != "" {
-				name = fmt.Sprintf("(rule in %s)", drift.ParentSG)
+	if f.GroupBy == GroupByModule {
+		groups, drifts := groupByModule(report)
+		for i, group := range groups {
+			if i > 0 {
+				fmt.Fprintln(w)
 			}
-			if name == "" {
-				name = "(unnamed)"
+			fmt.Fprintf(w, "MODULE: %s\n", group)
+			writeTableRows(w, drifts[group])
+		}
+	} else {
+		var rows []projectDiff
+		for _, project := range report.Projects {
+			for _, drift := range project.Drifts {
+				rows = append(rows, projectDiff{project.ProjectName, drift})
 			}
-
-			// Truncate ID for display
-			id := truncateID(drift.ResourceID, 12)
-
-			// Truncate details for table display
-			details := truncateString(drift.Details, 50)
-
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
-				project.ProjectName,
-				drift.ResourceType,
-				name,
-				id,
-				drift.Status,
-				details,
-			)
 		}
+		writeTableRows(w, rows)
 	}
+	writeResolvedRows(w, report)
+	writePlannedChangeRows(w, report)
+	writeErrorRows(w, report)
 
 	// Print summary
 	fmt.Fprintln(w)
@@ -101,16 +189,188 @@ func (f *DriftFormatter) formatTable(report *DriftReport) error {
 		}
 		fmt.Fprintln(w, strings.Join(statusParts, ", "))
 	}
+	if report.Summary.Ignored > 0 {
+		fmt.Fprintf(w, "Ignored: %d drift items matched %s rules\n", report.Summary.Ignored, IgnoreFileName)
+	}
+	if report.Summary.BaselineRunID != 0 {
+		fmt.Fprintf(w, "Baseline: run %d, %d new, %d resolved\n",
+			report.Summary.BaselineRunID, report.Summary.TotalDrift, report.Summary.Resolved)
+	}
+	if report.Summary.PlanFile != "" {
+		fmt.Fprintf(w, "Plan: drift predicted after applying %s; %d planned changes touch drifted resources\n",
+			report.Summary.PlanFile, report.Summary.PlannedChanges)
+	}
 
 	return w.Flush()
 }
 
-// formatJSON formats the drift report as JSON
-func (f *DriftFormatter) formatJSON(report *DriftReport) error {
-	encoder := json.NewEncoder(f.Writer)
-	encoder.SetIndent("", "  ")
-	encoder.SetEscapeHTML(false)
-	return encoder.Encode(report)
+// projectDiff is a drift result with the project it belongs to
+type projectDiff struct {
+	projectName string
+	drift       DiffResult
+}
+
+// writeTableRows writes the table header and a row per drift
+func writeTableRows(w io.Writer, rows []projectDiff) {
+	fmt.Fprintln(w, "PROJECT\tRESOURCE TYPE\tNAME\tID\tSTATUS\tDETAILS\tADDRESS")
+	fmt.Fprintln(w, "-------\t-------------\t----\t--\t------\t-------\t-------")
+
+	for _, row := range rows {
+		drift := row.drift
+		name := displayName(drift.ResourceName, drift.ParentSG)
+
+		// Truncate ID for display
+		id := truncateID(drift.ResourceID, 12)
+
+		// Truncate details for table display
+		details := truncateString(drift.Details, 50)
+
+		// Addresses are shown in full so they can be used with terraform state commands
+		address := drift.Address
+		if address == "" {
+			address = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			row.projectName,
+			drift.ResourceType,
+			name,
+			id,
+			drift.Status,
+			details,
+			address,
+		)
+	}
+}
+
+// writeResolvedRows lists baseline drift that is no longer present
+func writeResolvedRows(w io.Writer, report *DriftReport) {
+	if report.Summary.Resolved == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "RESOLVED SINCE RUN %d\n", report.Summary.BaselineRunID)
+	fmt.Fprintln(w, "PROJECT\tRESOURCE TYPE\tNAME\tID\tSTATUS")
+	fmt.Fprintln(w, "-------\t-------------\t----\t--\t------")
+	for _, project := range report.Projects {
+		for _, d := range project.Resolved {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				project.ProjectName,
+				d.ResourceType,
+				displayName(d.ResourceName, d.ParentSG),
+				truncateID(d.ResourceID, 12),
+				d.Status,
+			)
+		}
+	}
+}
+
+// writePlannedChangeRows lists planned changes to resources that have drifted
+func writePlannedChangeRows(w io.Writer, report *DriftReport) {
+	if report.Summary.PlannedChanges == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "PLANNED CHANGES TO DRIFTED RESOURCES")
+	fmt.Fprintln(w, "PROJECT\tADDRESS\tACTIONS\tRESOURCE TYPE\tNAME\tID\tCURRENT DRIFT")
+	fmt.Fprintln(w, "-------\t-------\t-------\t-------------\t----\t--\t-------------")
+	for _, project := range report.Projects {
+		for _, c := range project.PlannedChanges {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				project.ProjectName,
+				c.Address,
+				strings.Join(c.Actions, ","),
+				c.ResourceType,
+				c.ResourceName,
+				truncateID(c.ResourceID, 12),
+				joinStatuses(c.Drift),
+			)
+		}
+	}
+}
+
+// joinStatuses joins drift statuses with commas
+func joinStatuses(statuses []DriftStatus) string {
+	parts := make([]string, len(statuses))
+	for i, status := range statuses {
+		parts[i] = string(status)
+	}
+	return strings.Join(parts, ",")
+}
+
+// writeErrorRows lists projects and files that could not be loaded
+func writeErrorRows(w io.Writer, report *DriftReport) {
+	if len(report.Errors) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "ERRORS")
+	fmt.Fprintln(w, "PROJECT\tFILE\tERROR")
+	fmt.Fprintln(w, "-------\t----\t-----")
+	for _, e := range report.Errors {
+		file := e.File
+		if file == "" {
+			file = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Project, file, e.Error)
+	}
+}
+
+// describeLoadError describes a load error on one line
+func describeLoadError(e LoadError) string {
+	if e.File != "" {
+		return fmt.Sprintf("failed to parse %s: %s", e.File, e.Error)
+	}
+	return fmt.Sprintf("failed to process project %s: %s", e.Project, e.Error)
+}
+
+// warnLoadErrors writes load errors to ErrWriter
+func (f *DriftFormatter) warnLoadErrors(report *DriftReport) {
+	if f.ErrWriter == nil {
+		return
+	}
+	for _, e := range report.Errors {
+		fmt.Fprintf(f.ErrWriter, "Warning: %s\n", describeLoadError(e))
+	}
+}
+
+// groupByModule groups drift by the Terraform module of the state resource. The
+// root module comes first, then modules by address, then drift with no state resource.
+func groupByModule(report *DriftReport) ([]string, map[string][]projectDiff) {
+	drifts := make(map[string][]projectDiff)
+	for _, project := range report.Projects {
+		for _, drift := range project.Drifts {
+			group := drift.ModulePath
+			switch {
+			case drift.Status == StatusMissingInState:
+				group = notInStateGroup
+			case group == "":
+				group = rootModuleGroup
+			}
+			drifts[group] = append(drifts[group], projectDiff{project.ProjectName, drift})
+		}
+	}
+
+	var modules []string
+	for group := range drifts {
+		if group != rootModuleGroup && group != notInStateGroup {
+			modules = append(modules, group)
+		}
+	}
+	sort.Strings(modules)
+
+	var groups []string
+	if _, ok := drifts[rootModuleGroup]; ok {
+		groups = append(groups, rootModuleGroup)
+	}
+	groups = append(groups, modules...)
+	if _, ok := drifts[notInStateGroup]; ok {
+		groups = append(groups, notInStateGroup)
+	}
+	return groups, drifts
 }
 
 // formatCSV formats the drift report as CSV
@@ -118,15 +378,28 @@ func (f *DriftFormatter) formatCSV(report *DriftReport) error {
 	w := csv.NewWriter(f.Writer)
 
 	// Write header
-	header := []string{"project", "resource_type", "name", "id", "parent_sg", "status", "details"}
-	if err := w.Write(header); err != nil {
+	if err := w.Write(csvHeader); err != nil {
 		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
 
 	// Write rows
+	for _, row := range csvRows(report) {
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	w.Flush()
+	f.warnLoadErrors(report)
+	return w.Error()
+}
+
+// csvRows flattens the drift items of a report to rows matching csvHeader
+func csvRows(report *DriftReport) [][]string {
+	var rows [][]string
 	for _, project := range report.Projects {
 		for _, drift := range project.Drifts {
-			row := []string{
+			rows = append(rows, []string{
 				project.ProjectName,
 				string(drift.ResourceType),
 				drift.ResourceName,
@@ -134,15 +407,430 @@ func (f *DriftFormatter) formatCSV(report *DriftReport) error {
 				drift.ParentSG,
 				string(drift.Status),
 				drift.Details,
+				drift.Address,
+				drift.ModulePath,
+				FormatIndex(drift.Index),
+			})
+		}
+	}
+	return rows
+}
+
+// displayName returns a resource name for display, naming rules after their security group
+func displayName(name, parentSG string) string {
+	if name == "" && parentSG != "" {
+		name = fmt.Sprintf("(rule in %s)", parentSG)
+	}
+	if name == "" {
+		name = "(unnamed)"
+	}
+	return name
+}
+
+// JUnit XML structure
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failures  []junitResult `xml:"failure,omitempty"`
+	Errors    []junitResult `xml:"error,omitempty"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// formatJUnit formats the drift report as JUnit XML: a test suite per project
+// and a test case per resource, failing when the resource has drift. Resources
+// whose drift was ignored pass. Projects and files that could not be loaded
+// are test cases with an error.
+func (f *DriftFormatter) formatJUnit(report *DriftReport) error {
+	suites := junitTestSuites{Name: "osc drift"}
+
+	for _, project := range report.Projects {
+		suite := junitTestSuite{Name: project.ProjectName}
+		className := "osc.drift." + project.ProjectName
+
+		driftsByKey := make(map[string][]DiffResult)
+		replaced := make(map[string]bool)
+		for _, d := range project.Drifts {
+			key := string(d.ResourceType) + "/" + d.ResourceID
+			driftsByKey[key] = append(driftsByKey[key], d)
+			if d.PreviousID != "" {
+				replaced[string(d.ResourceType)+"/"+d.PreviousID] = true
+			}
+		}
+
+		addCase := func(resType ResourceType, name, id, parentSG string, drifts []DiffResult) {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("%s %s (%s)", resType, displayName(name, parentSG), id),
+				ClassName: className,
 			}
-			if err := w.Write(row); err != nil {
-				return fmt.Errorf("failed to write CSV row: %w", err)
+			for _, d := range drifts {
+				tc.Failures = append(tc.Failures, junitResult{
+					Message: d.Details,
+					Type:    string(d.Status),
+					Text:    driftDescription(d),
+				})
 			}
+			suite.Tests++
+			if len(tc.Failures) > 0 {
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, tc)
 		}
+
+		// Every compared resource, then any drift for resources not in the list
+		emitted := make(map[string]bool)
+		for _, c := range project.Checked {
+			key := string(c.Type) + "/" + c.ID
+			if replaced[key] || emitted[key] {
+				continue
+			}
+			emitted[key] = true
+			addCase(c.Type, c.Name, c.ID, c.ParentSG, driftsByKey[key])
+		}
+		for _, d := range project.Drifts {
+			key := string(d.ResourceType) + "/" + d.ResourceID
+			if emitted[key] {
+				continue
+			}
+			emitted[key] = true
+			addCase(d.ResourceType, d.ResourceName, d.ResourceID, d.ParentSG, driftsByKey[key])
+		}
+
+		suites.Suites = append(suites.Suites, suite)
 	}
 
-	w.Flush()
-	return w.Error()
+	// Load errors join the suite of their project, if it was loaded at all
+	for _, e := range report.Errors {
+		i := slices.IndexFunc(suites.Suites, func(s junitTestSuite) bool { return s.Name == e.Project })
+		if i < 0 {
+			suites.Suites = append(suites.Suites, junitTestSuite{Name: e.Project})
+			i = len(suites.Suites) - 1
+		}
+		name := "load project"
+		if e.File != "" {
+			name = "load " + e.File
+		}
+		suite := &suites.Suites[i]
+		suite.Tests++
+		suite.Errors++
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      name,
+			ClassName: "osc.drift." + e.Project,
+			Errors:    []junitResult{{Message: e.Error, Type: "load_error", Text: describeLoadError(e)}},
+		})
+	}
+
+	for _, suite := range suites.Suites {
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+	}
+
+	if _, err := io.WriteString(f.Writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(f.Writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return fmt.Errorf("failed to write JUnit XML: %w", err)
+	}
+	_, err := io.WriteString(f.Writer, "\n")
+	return err
+}
+
+// driftDescription describes a drift item on multiple lines for CI reports
+func driftDescription(d DiffResult) string {
+	lines := []string{
+		fmt.Sprintf("Status: %s", d.Status),
+		fmt.Sprintf("Resource: %s %s (%s)", d.ResourceType, displayName(d.ResourceName, d.ParentSG), d.ResourceID),
+	}
+	if d.PreviousID != "" {
+		lines = append(lines, fmt.Sprintf("Previous ID: %s", d.PreviousID))
+	}
+	if d.Address != "" {
+		lines = append(lines, fmt.Sprintf("Address: %s", d.Address))
+	}
+	if len(d.Changes) > 0 {
+		for _, c := range d.Changes {
+			lines = append(lines, "- "+c.Detail)
+		}
+	} else {
+		lines = append(lines, d.Details)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SARIF 2.1.0 structure, limited to the properties osc fills in
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations,omitempty"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifRuleDescriptions describes each drift status as a SARIF rule
+var sarifRuleDescriptions = map[DriftStatus]string{
+	StatusMissingInTruth:    "Resource is in Terraform state but not in OpenStack",
+	StatusMissingInState:    "Resource is in OpenStack but not in Terraform state",
+	StatusNameChanged:       "Resource name differs between Terraform state and OpenStack",
+	StatusSecGroupChanged:   "Server security groups differ between Terraform state and OpenStack",
+	StatusRuleChanged:       "Security group rule differs between Terraform state and OpenStack",
+	StatusSizeChanged:       "Volume size differs between Terraform state and OpenStack",
+	StatusVolumeTypeChanged: "Volume type differs between Terraform state and OpenStack",
+	StatusAttachmentChanged: "Volume attachment differs between Terraform state and OpenStack",
+	StatusFlavorChanged:     "Server flavor differs between Terraform state and OpenStack",
+	StatusImageChanged:      "Server image differs between Terraform state and OpenStack",
+	StatusMetadataChanged:   "Server metadata differs between Terraform state and OpenStack",
+	StatusPowerStateChanged: "Server power state differs between Terraform state and OpenStack",
+	StatusFloatingIPChanged: "Server floating IP differs between Terraform state and OpenStack",
+	StatusRecreated:         "Resource was recreated with a new ID outside Terraform state",
+}
+
+// formatSARIF formats the drift report as a SARIF 2.1.0 log. Each drift item is a
+// result whose rule is its drift status. Resources have no source file, so they
+// are reported as logical locations named by Terraform address when known.
+func (f *DriftFormatter) formatSARIF(report *DriftReport) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "osc",
+			Version:        version.GetVersion(),
+			InformationURI: "https://github.com/marcdicarlo/osc",
+		}},
+		Results: []sarifResult{},
+	}
+
+	usedRules := make(map[DriftStatus]bool)
+	for _, project := range report.Projects {
+		for _, d := range project.Drifts {
+			usedRules[d.Status] = true
+
+			qualifiedName := d.Address
+			if qualifiedName == "" {
+				qualifiedName = fmt.Sprintf("%s/%s/%s", project.ProjectName, d.ResourceType, d.ResourceID)
+			}
+			level := "warning"
+			if d.Status == StatusMissingInTruth {
+				level = "error"
+			}
+
+			run.Results = append(run.Results, sarifResult{
+				RuleID: string(d.Status),
+				Level:  level,
+				Message: sarifMessage{Text: fmt.Sprintf("%s: %s %s (%s): %s",
+					project.ProjectName, d.ResourceType, displayName(d.ResourceName, d.ParentSG), d.ResourceID, d.Details)},
+				Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{
+					Name:               displayName(d.ResourceName, d.ParentSG),
+					FullyQualifiedName: qualifiedName,
+					Kind:               "resource",
+				}}}},
+				PartialFingerprints: map[string]string{
+					"oscDrift/v1": fmt.Sprintf("%s/%s/%s/%s", project.ProjectName, d.ResourceType, d.ResourceID, d.Status),
+				},
+			})
+		}
+	}
+
+	var statuses []string
+	for status := range usedRules {
+		statuses = append(statuses, string(status))
+	}
+	sort.Strings(statuses)
+	run.Tool.Driver.Rules = []sarifRule{}
+	for _, status := range statuses {
+		description := sarifRuleDescriptions[DriftStatus(status)]
+		if description == "" {
+			description = status
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: status, ShortDescription: sarifMessage{Text: description}})
+	}
+
+	// Load errors are tool notifications; the run itself still completes
+	if len(report.Errors) > 0 {
+		invocation := sarifInvocation{ExecutionSuccessful: true}
+		for _, e := range report.Errors {
+			invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, sarifNotification{
+				Level:   "error",
+				Message: sarifMessage{Text: describeLoadError(e)},
+			})
+		}
+		run.Invocations = []sarifInvocation{invocation}
+	}
+
+	encoder := json.NewEncoder(f.Writer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// formatMarkdown formats the drift report as Markdown for pull request comments:
+// an overall summary followed by a collapsible section per project with drift
+func (f *DriftFormatter) formatMarkdown(report *DriftReport) error {
+	var b strings.Builder
+
+	b.WriteString("## Drift report\n\n")
+	fmt.Fprintf(&b, "**%d drift items** across %d projects", report.Summary.TotalDrift, report.Summary.TotalProjects)
+	if report.Summary.TotalDrift > 0 {
+		var statuses []string
+		for status := range report.Summary.ByStatus {
+			statuses = append(statuses, string(status))
+		}
+		sort.Strings(statuses)
+		parts := make([]string, len(statuses))
+		for i, status := range statuses {
+			parts[i] = fmt.Sprintf("`%s` %d", status, report.Summary.ByStatus[DriftStatus(status)])
+		}
+		fmt.Fprintf(&b, " (%s)", strings.Join(parts, ", "))
+	}
+	b.WriteString("\n")
+	if report.Summary.Ignored > 0 {
+		fmt.Fprintf(&b, "\n%d drift items matched `%s` rules and were ignored.\n", report.Summary.Ignored, IgnoreFileName)
+	}
+	if report.Summary.BaselineRunID != 0 {
+		fmt.Fprintf(&b, "\nCompared with run %d: %d new, %d resolved.\n",
+			report.Summary.BaselineRunID, report.Summary.TotalDrift, report.Summary.Resolved)
+	}
+	if report.Summary.PlanFile != "" {
+		fmt.Fprintf(&b, "\nDrift predicted after applying `%s`; %d planned changes touch drifted resources.\n",
+			report.Summary.PlanFile, report.Summary.PlannedChanges)
+	}
+
+	var clean []string
+	for _, project := range report.Projects {
+		if len(project.Drifts) == 0 {
+			clean = append(clean, project.ProjectName)
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n<details>\n<summary><strong>%s</strong>: %d drift items</summary>\n\n",
+			markdownEscape(project.ProjectName), len(project.Drifts))
+		b.WriteString("| Resource type | Name | ID | Status | Details | Address |\n")
+		b.WriteString("|---|---|---|---|---|---|\n")
+		for _, d := range project.Drifts {
+			address := ""
+			if d.Address != "" {
+				address = "`" + d.Address + "`"
+			}
+			fmt.Fprintf(&b, "| %s | %s | `%s` | `%s` | %s | %s |\n",
+				d.ResourceType,
+				markdownEscape(displayName(d.ResourceName, d.ParentSG)),
+				d.ResourceID,
+				d.Status,
+				markdownEscape(d.Details),
+				address,
+			)
+		}
+		b.WriteString("\n</details>\n")
+	}
+
+	if len(clean) > 0 {
+		fmt.Fprintf(&b, "\nNo drift in: %s\n", markdownEscape(strings.Join(clean, ", ")))
+	}
+
+	if report.Summary.PlannedChanges > 0 {
+		b.WriteString("\n### Planned changes to drifted resources\n\n")
+		b.WriteString("| Project | Address | Actions | Current drift |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for _, project := range report.Projects {
+			for _, c := range project.PlannedChanges {
+				fmt.Fprintf(&b, "| %s | `%s` | %s | `%s` |\n",
+					markdownEscape(project.ProjectName), c.Address, strings.Join(c.Actions, ", "), joinStatuses(c.Drift))
+			}
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		b.WriteString("\n### Errors\n\n")
+		for _, e := range report.Errors {
+			fmt.Fprintf(&b, "- **%s**: %s\n", markdownEscape(e.Project), markdownEscape(describeLoadError(e)))
+		}
+	}
+
+	_, err := io.WriteString(f.Writer, b.String())
+	return err
+}
+
+// markdownEscape escapes characters that would break a Markdown table cell or inject HTML
+func markdownEscape(s string) string {
+	r := strings.NewReplacer("|", "\\|", "<", "&lt;", ">", "&gt;", "\n", " ")
+	return r.Replace(s)
 }
 
 // truncateID truncates an ID for display, showing first n characters with ellipsis
@@ -161,19 +849,62 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
-// PrintNoDrift prints a message when no drift is detected
-func (f *DriftFormatter) PrintNoDrift(projectCount int) {
-	switch f.Format {
-	case FormatJSON:
-		report := NewDriftReport()
-		report.Summary.TotalProjects = projectCount
-		f.formatJSON(report)
-	case FormatCSV:
+// PrintNoDrift prints a message when no drift is detected.
+// Formats that report passing checks write the full report instead.
+func (f *DriftFormatter) PrintNoDrift(report *DriftReport) error {
+	doc := f.document(report)
+	doc.Value = noDriftReport(report)
+	doc.Data = output.NewOutputData(csvHeader, nil)
+	doc.Renderers[output.Format(FormatTable)] = func(io.Writer) error {
+		f.printNoDriftTable(report)
+		return nil
+	}
+	doc.Renderers[output.Format(FormatCSV)] = func(io.Writer) error {
 		// For CSV, just print header with no rows
 		w := csv.NewWriter(f.Writer)
-		w.Write([]string{"project", "resource_type", "name", "id", "parent_sg", "status", "details"})
+		if err := w.Write(csvHeader); err != nil {
+			return fmt.Errorf("failed to write CSV header: %w", err)
+		}
+		w.Flush()
+		f.warnLoadErrors(report)
+		return w.Error()
+	}
+	return output.FormatDocument(string(f.Format), f.Writer, doc)
+}
+
+// noDriftReport is the structured form of a report without drift
+func noDriftReport(report *DriftReport) *DriftReport {
+	// A baseline report still lists resolved drift, and planned changes to
+	// drifted resources are listed even when no drift remains
+	if report.Summary.BaselineRunID != 0 || report.Summary.PlannedChanges > 0 {
+		return report
+	}
+	empty := NewDriftReport()
+	empty.Summary.TotalProjects = report.Summary.TotalProjects
+	empty.Summary.Ignored = report.Summary.Ignored
+	empty.Summary.PlanFile = report.Summary.PlanFile
+	empty.Errors = report.Errors
+	return empty
+}
+
+// printNoDriftTable prints the no drift message and any resolved drift,
+// planned changes and load errors
+func (f *DriftFormatter) printNoDriftTable(report *DriftReport) {
+	if report.Summary.BaselineRunID != 0 {
+		fmt.Fprintf(f.Writer, "No new drift since run %d across %d projects.\n", report.Summary.BaselineRunID, report.Summary.TotalProjects)
+	} else if report.Summary.PlanFile != "" {
+		fmt.Fprintf(f.Writer, "No drift predicted after applying %s across %d projects.\n", report.Summary.PlanFile, report.Summary.TotalProjects)
+	} else {
+		fmt.Fprintf(f.Writer, "No drift detected across %d projects.\n", report.Summary.TotalProjects)
+	}
+	if report.Summary.Ignored > 0 {
+		fmt.Fprintf(f.Writer, "Ignored: %d drift items matched %s rules\n", report.Summary.Ignored, IgnoreFileName)
+	}
+	if report.Summary.Resolved > 0 || report.Summary.PlannedChanges > 0 || len(report.Errors) > 0 {
+		w := tabwriter.NewWriter(f.Writer, 0, 0, 2, ' ', 0)
+		writeResolvedRows(w, report)
+		writePlannedChangeRows(w, report)
+		writeErrorRows(w, report)
 		w.Flush()
-	default:
-		fmt.Fprintf(f.Writer, "No drift detected across %d projects.\n", projectCount)
 	}
 }
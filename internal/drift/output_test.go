@@ -0,0 +1,239 @@
+package drift
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// newTestFormatter creates a drift formatter for a valid format
+func newTestFormatter(t *testing.T, w io.Writer, format string) *DriftFormatter {
+	t.Helper()
+	f, err := NewDriftFormatter(w, format)
+	if err != nil {
+		t.Fatalf("NewDriftFormatter(%q) error = %v", format, err)
+	}
+	return f
+}
+
+func addressReport() *DriftReport {
+	report := NewDriftReport()
+	report.AddProject(ProjectDrift{
+		ProjectName: "p1",
+		Drifts: []DiffResult{
+			{ResourceType: ResourceTypeServer, ResourceName: "app", ResourceID: "srv-1", Status: StatusMissingInTruth,
+				Address: `module.web.openstack_compute_instance_v2.app["a"]`, ModulePath: "module.web", Index: "a"},
+			{ResourceType: ResourceTypeServer, ResourceName: "new", ResourceID: "srv-2", Status: StatusMissingInState},
+			{ResourceType: ResourceTypeSecurityGroup, ResourceName: "db", ResourceID: "sg-1", Status: StatusNameChanged,
+				Address: "openstack_networking_secgroup_v2.db"},
+			{ResourceType: ResourceTypeSecurityGroup, ResourceName: "lb", ResourceID: "sg-2", Status: StatusNameChanged,
+				Address: "module.app.openstack_networking_secgroup_v2.lb[0]", ModulePath: "module.app", Index: float64(0)},
+		},
+	})
+	return report
+}
+
+func TestFormatTableGroupByModule(t *testing.T) {
+	var buf bytes.Buffer
+	f := newTestFormatter(t, &buf, "table")
+	f.GroupBy = GroupByModule
+	if err := f.FormatReport(addressReport()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	// Root module first, then modules by address, then drift not in state
+	var order []int
+	for _, group := range []string{"MODULE: (root module)", "MODULE: module.app", "MODULE: module.web", "MODULE: (not in state)"} {
+		i := strings.Index(out, group)
+		if i < 0 {
+			t.Fatalf("missing group %q in\n%s", group, out)
+		}
+		order = append(order, i)
+	}
+	for i := 1; i < len(order); i++ {
+		if order[i] < order[i-1] {
+			t.Errorf("groups out of order\n%s", out)
+		}
+	}
+	if !strings.Contains(out, `module.web.openstack_compute_instance_v2.app["a"]`) {
+		t.Errorf("expected full address in table\n%s", out)
+	}
+}
+
+func TestFormatCSVIncludesAddress(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestFormatter(t, &buf, "csv").FormatReport(addressReport()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !strings.HasSuffix(lines[0], ",address,module_path,index") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[4], ",module.app.openstack_networking_secgroup_v2.lb[0],module.app,0") {
+		t.Errorf("unexpected row %q", lines[4])
+	}
+}
+
+func TestFormatJUnit(t *testing.T) {
+	report := NewDriftReport()
+	report.AddProject(ProjectDrift{
+		ProjectName: "p1",
+		Drifts: []DiffResult{
+			{ResourceType: ResourceTypeServer, ResourceName: "web", ResourceID: "srv-new", PreviousID: "srv-old", Status: StatusRecreated, Details: "recreated: ID srv-old -> srv-new"},
+		},
+		Checked: []CheckedResource{
+			{Type: ResourceTypeServer, Name: "web", ID: "srv-old"},
+			{Type: ResourceTypeServer, Name: "web", ID: "srv-new"},
+			{Type: ResourceTypeSecurityGroup, Name: "default", ID: "sg-1"},
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := newTestFormatter(t, &buf, "junit").FormatReport(report); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	// The replaced ID is reported by the recreated drift, not as its own test case
+	for _, want := range []string{
+		`<testsuites name="osc drift" tests="2" failures="1" errors="0">`,
+		`<testcase name="server web (srv-new)" classname="osc.drift.p1">`,
+		`<failure message="recreated: ID srv-old -&gt; srv-new" type="recreated">`,
+		`<testcase name="security-group default (sg-1)" classname="osc.drift.p1"></testcase>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestFormatter(t, &buf, "sarif").FormatReport(addressReport()); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 4 || len(run.Tool.Driver.Rules) != 3 {
+		t.Errorf("expected 4 results and 3 rules, got %d and %d", len(run.Results), len(run.Tool.Driver.Rules))
+	}
+	first := run.Results[0]
+	if first.RuleID != "missing_in_truth" || first.Level != "error" ||
+		first.Locations[0].LogicalLocations[0].FullyQualifiedName != `module.web.openstack_compute_instance_v2.app["a"]` {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if got := run.Results[1].Locations[0].LogicalLocations[0].FullyQualifiedName; got != "p1/server/srv-2" {
+		t.Errorf("resource without address should be named by project, type and ID, got %q", got)
+	}
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	report := addressReport()
+	report.AddProject(ProjectDrift{ProjectName: "clean"})
+	report.Projects[0].Drifts[2].Details = "name: a|b"
+
+	var buf bytes.Buffer
+	if err := newTestFormatter(t, &buf, "markdown").FormatReport(report); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"**4 drift items** across 2 projects (`missing_in_state` 1, `missing_in_truth` 1, `name_changed` 2)",
+		"<details>\n<summary><strong>p1</strong>: 4 drift items</summary>",
+		"| security-group | db | `sg-1` | `name_changed` | name: a\\|b | `openstack_networking_secgroup_v2.db` |",
+		"No drift in: clean",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatNDJSONAndYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestFormatter(t, &buf, "ndjson").FormatReport(addressReport()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a line per drift item, got\n%s", buf.String())
+	}
+	var item DiffResult
+	if err := json.Unmarshal([]byte(lines[2]), &item); err != nil || item.ResourceID != "sg-1" || item.Status != StatusNameChanged {
+		t.Errorf("unexpected line %q (%v)", lines[2], err)
+	}
+
+	buf.Reset()
+	if err := newTestFormatter(t, &buf, "yaml").FormatReport(addressReport()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "projects:\n- project_name: p1\n  drifts:\n  - resource_type: server\n") {
+		t.Errorf("unexpected YAML\n%s", buf.String())
+	}
+}
+
+func TestFormatNameIDAndTemplate(t *testing.T) {
+	for format, want := range map[string]string{
+		"name":                                  "app\nnew\ndb\nlb\n",
+		"id":                                    "srv-1\nsrv-2\nsg-1\nsg-2\n",
+		"template={{.resource_id}} {{.status}}": "srv-1 missing_in_truth\nsrv-2 missing_in_state\nsg-1 name_changed\nsg-2 name_changed\n",
+	} {
+		var buf bytes.Buffer
+		if err := newTestFormatter(t, &buf, format).FormatReport(addressReport()); err != nil {
+			t.Fatalf("FormatReport(%q) error = %v", format, err)
+		}
+		if buf.String() != want {
+			t.Errorf("FormatReport(%q) = %q, want %q", format, buf.String(), want)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestPrintNoDriftError(t *testing.T) {
+	report := NewDriftReport()
+	report.AddProject(ProjectDrift{ProjectName: "clean"})
+	for _, format := range []string{"json", "csv"} {
+		if err := newTestFormatter(t, failingWriter{}, format).PrintNoDrift(report); err == nil {
+			t.Errorf("expected the %s write error to be returned", format)
+		}
+	}
+}
+
+func TestNewDriftFormatterInvalid(t *testing.T) {
+	for _, format := range []string{"bogus", "template={{.bad", "jsonpath="} {
+		if _, err := NewDriftFormatter(io.Discard, format); err == nil {
+			t.Errorf("NewDriftFormatter(%q) should fail", format)
+		}
+	}
+	_, err := NewDriftFormatter(io.Discard, "bogus")
+	if err == nil || !strings.Contains(err.Error(), "junit") {
+		t.Errorf("expected the drift formats among the valid formats, got %v", err)
+	}
+
+	// Registered formats keep the case of their argument
+	for format, want := range map[string]OutputFormat{
+		"YAML":                      FormatYAML,
+		"Name":                      "name",
+		"template={{.Summary.Foo}}": "template={{.Summary.Foo}}",
+	} {
+		f, err := NewDriftFormatter(io.Discard, format)
+		if err != nil || f.Format != want {
+			t.Errorf("NewDriftFormatter(%q) = %v, %v; want %q", format, f, err, want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// TerraformPlan represents the parts of terraform show -json output for a saved
+// plan that drift prediction uses
+type TerraformPlan struct {
+	FormatVersion    string               `json:"format_version"`
+	TerraformVersion string               `json:"terraform_version"`
+	PlannedValues    *TerraformValues     `json:"planned_values"`
+	PriorState       *TerraformState      `json:"prior_state"`
+	ResourceChanges  []PlanResourceChange `json:"resource_changes"`
+}
+
+// PlanResourceChange is a planned action on a single resource instance
+type PlanResourceChange struct {
+	Address       string     `json:"address"`
+	ModuleAddress string     `json:"module_address"`
+	Mode          string     `json:"mode"`
+	Type          string     `json:"type"`
+	Name          string     `json:"name"`
+	Index         any        `json:"index,omitempty"`
+	Change        PlanChange `json:"change"`
+}
+
+// PlanChange holds the actions and the before and after values of a planned change.
+// After omits values that are only known after apply.
+type PlanChange struct {
+	Actions []string       `json:"actions"`
+	Before  map[string]any `json:"before"`
+	After   map[string]any `json:"after"`
+}
+
+// changesResource reports whether applying the change modifies the resource
+func (c PlanChange) changesResource() bool {
+	return slices.Contains(c.Actions, "create") || slices.Contains(c.Actions, "update") || slices.Contains(c.Actions, "delete")
+}
+
+// ParseTerraformPlan parses terraform show -json output for a saved plan
+func ParseTerraformPlan(r io.Reader) (*TerraformPlan, error) {
+	var plan TerraformPlan
+	if err := json.NewDecoder(r).Decode(&plan); err != nil {
+		return nil, fmt.Errorf("failed to parse Terraform plan: %w", err)
+	}
+	if plan.PlannedValues == nil {
+		return nil, fmt.Errorf("not a Terraform plan: no planned_values (use terraform show -json on a saved plan)")
+	}
+	return &plan, nil
+}
+
+// ParseTerraformPlanFile parses a Terraform plan JSON file from path
+func ParseTerraformPlanFile(path string) (*TerraformPlan, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Terraform plan file: %w", err)
+	}
+	defer f.Close()
+	return ParseTerraformPlan(f)
+}
+
+// PlannedResources extracts the resources that exist after the plan is applied.
+// Resources whose ID is only known after apply, i.e. new resources, are left out.
+func (p *TerraformPlan) PlannedResources(projectName string) []Resource {
+	return ExtractResourcesFromTerraform(&TerraformState{Values: p.PlannedValues}, projectName)
+}
+
+// changeResources returns the resources a planned change acts on, before and
+// after the change, keyed by type and ID
+func (rc PlanResourceChange) changeResources(projectName string) map[string]Resource {
+	resources := make(map[string]Resource)
+	for _, values := range []map[string]any{rc.Change.Before, rc.Change.After} {
+		if values == nil {
+			continue
+		}
+		tfRes := TerraformResource{Address: rc.Address, Mode: rc.Mode, Type: rc.Type, Name: rc.Name, Index: rc.Index, Values: values}
+		for _, res := range extractResourcesFromModule([]TerraformResource{tfRes}, projectName, rc.ModuleAddress) {
+			resources[string(res.Type)+"/"+res.ID] = res
+		}
+	}
+	return resources
+}
+
+// HasPlan reports whether the project directory holds the plan file planFile
+func (p ProjectDir) HasPlan(planFile string) bool {
+	info, err := os.Stat(filepath.Join(p.BasePath, planFile))
+	return err == nil && !info.IsDir()
+}
+
+// processProjectPlan predicts the drift of a project after its plan is
+// applied. The planned end state is compared with the truth; drift on
+// resources the plan creates, updates or deletes is expected to converge and
+// is left out. Planned changes to resources that have drifted now, judged from
+// the plan's prior state, are listed separately.
+func processProjectPlan(project ProjectDir, opts Options) (*ProjectDrift, error) {
+	plan, err := ParseTerraformPlanFile(filepath.Join(project.BasePath, opts.PlanFile))
+	if err != nil {
+		return nil, err
+	}
+
+	truth, skipped, err := loadTruth(project, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load truth for project %s: %w", project.Name, err)
+	}
+
+	// The prior state is refreshed by terraform plan; fall back to the state
+	// directories for plans made without one
+	var prior []Resource
+	if plan.PriorState != nil {
+		prior = ExtractResourcesFromTerraform(plan.PriorState, project.Name)
+	} else {
+		var stateSkipped []LoadError
+		prior, stateSkipped, err = loadProjectState(project, opts.HTTPClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load state for project %s: %w", project.Name, err)
+		}
+		skipped = append(skipped, stateSkipped...)
+	}
+
+	rules, err := projectIgnoreRules(project, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := plan.PlannedResources(project.Name)
+	current, _ := ApplyIgnoreRules(CompareResourcesWithOptions(prior, truth, opts.Compare), rules)
+	after, ignored := ApplyIgnoreRules(CompareResourcesWithOptions(planned, truth, opts.Compare), rules)
+
+	currentByKey := make(map[string][]DiffResult)
+	for _, d := range current {
+		currentByKey[string(d.ResourceType)+"/"+d.ResourceID] = append(currentByKey[string(d.ResourceType)+"/"+d.ResourceID], d)
+	}
+
+	converging := make(map[string]bool)
+	var changes []PlannedChange
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode == "data" || !rc.Change.changesResource() {
+			continue
+		}
+
+		resources := rc.changeResources(project.Name)
+		keys := make([]string, 0, len(resources))
+		for key := range resources {
+			keys = append(keys, key)
+			converging[key] = true
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			drifts := currentByKey[key]
+			if len(drifts) == 0 {
+				continue
+			}
+			res := resources[key]
+			change := PlannedChange{
+				Address:      rc.Address,
+				Actions:      rc.Change.Actions,
+				ResourceType: res.Type,
+				ResourceID:   res.ID,
+				ResourceName: res.Name,
+			}
+			for _, d := range drifts {
+				change.Drift = append(change.Drift, d.Status)
+			}
+			changes = append(changes, change)
+			break
+		}
+	}
+
+	var remaining []DiffResult
+	for _, d := range after {
+		if converging[string(d.ResourceType)+"/"+d.ResourceID] || (d.PreviousID != "" && converging[string(d.ResourceType)+"/"+d.PreviousID]) {
+			continue
+		}
+		remaining = append(remaining, d)
+	}
+
+	return &ProjectDrift{
+		ProjectName:    project.Name,
+		Drifts:         remaining,
+		StateCount:     CountResources(planned),
+		TruthCount:     CountResources(truth),
+		Ignored:        ignored,
+		Checked:        checkedResources(planned, truth),
+		PlannedChanges: changes,
+		Errors:         skipped,
+	}, nil
+}
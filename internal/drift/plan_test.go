@@ -0,0 +1,112 @@
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessProjectPlan(t *testing.T) {
+	base := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(base, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := func(name, id, serverName string) string {
+		return `{"address": "openstack_compute_instance_v2.` + name + `", "mode": "managed",
+			"type": "openstack_compute_instance_v2", "name": "` + name + `",
+			"values": {"id": "` + id + `", "name": "` + serverName + `"}}`
+	}
+
+	// db was renamed outside Terraform and the plan renames it back; old was
+	// deleted outside Terraform and the plan leaves it alone; new is created
+	write("alpha/plan.json", `{
+		"format_version": "1.2",
+		"planned_values": {"root_module": {"resources": [
+			`+server("web", "srv-1", "web")+`,
+			`+server("db", "srv-2", "db")+`,
+			`+server("old", "srv-3", "old")+`,
+			{"address": "openstack_compute_instance_v2.new", "mode": "managed",
+				"type": "openstack_compute_instance_v2", "name": "new", "values": {"name": "new"}}
+		]}},
+		"prior_state": {"values": {"root_module": {"resources": [
+			`+server("web", "srv-1", "web")+`,
+			`+server("db", "srv-2", "db")+`,
+			`+server("old", "srv-3", "old")+`
+		]}}},
+		"resource_changes": [
+			{"address": "openstack_compute_instance_v2.db", "mode": "managed", "type": "openstack_compute_instance_v2", "name": "db",
+				"change": {"actions": ["update"], "before": {"id": "srv-2", "name": "db"}, "after": {"id": "srv-2", "name": "db"}}},
+			{"address": "openstack_compute_instance_v2.old", "mode": "managed", "type": "openstack_compute_instance_v2", "name": "old",
+				"change": {"actions": ["no-op"], "before": {"id": "srv-3", "name": "old"}, "after": {"id": "srv-3", "name": "old"}}},
+			{"address": "openstack_compute_instance_v2.new", "mode": "managed", "type": "openstack_compute_instance_v2", "name": "new",
+				"change": {"actions": ["create"], "before": null, "after": {"name": "new"}}}
+		]
+	}`)
+	write("alpha/truth/servers.json", `{"headers": ["name", "id", "type"], "data": [
+		{"type": "server", "id": "srv-1", "name": "web"},
+		{"type": "server", "id": "srv-2", "name": "db-renamed"}
+	]}`)
+	// Projects without the plan file are skipped
+	write("beta/truth/servers.json", `{"headers": ["name", "id", "type"], "data": []}`)
+
+	opts := DefaultOptions()
+	opts.PlanFile = "plan.json"
+	report, err := ProcessAllProjectsWithOptions(base, opts)
+	if err != nil {
+		t.Fatalf("ProcessAllProjectsWithOptions: %v", err)
+	}
+
+	if len(report.Projects) != 1 || report.Projects[0].ProjectName != "alpha" {
+		t.Fatalf("expected only alpha to be checked, got %+v", report.Projects)
+	}
+	if report.Summary.PlanFile != "plan.json" {
+		t.Errorf("expected plan file in summary, got %q", report.Summary.PlanFile)
+	}
+
+	project := report.Projects[0]
+	if len(project.Drifts) != 1 || project.Drifts[0].ResourceID != "srv-3" || project.Drifts[0].Status != StatusMissingInTruth {
+		t.Errorf("expected only srv-3 to remain missing_in_truth, got %+v", project.Drifts)
+	}
+	if got := project.StateCount.Servers; got != 3 {
+		t.Errorf("expected 3 planned servers with known IDs, got %d", got)
+	}
+
+	if len(project.PlannedChanges) != 1 {
+		t.Fatalf("expected 1 planned change, got %+v", project.PlannedChanges)
+	}
+	change := project.PlannedChanges[0]
+	if change.Address != "openstack_compute_instance_v2.db" || change.ResourceID != "srv-2" ||
+		len(change.Drift) != 1 || change.Drift[0] != StatusNameChanged {
+		t.Errorf("unexpected planned change: %+v", change)
+	}
+	if report.Summary.PlannedChanges != 1 {
+		t.Errorf("expected 1 planned change in summary, got %d", report.Summary.PlannedChanges)
+	}
+}
+
+func TestProcessAllProjectsWithoutPlanFile(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "alpha", "truth"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.PlanFile = "plan.json"
+	if _, err := ProcessAllProjectsWithOptions(base, opts); err == nil || !strings.Contains(err.Error(), "plan.json") {
+		t.Errorf("expected an error when no project has the plan file, got %v", err)
+	}
+}
+
+func TestParseTerraformPlanRejectsState(t *testing.T) {
+	_, err := ParseTerraformPlan(strings.NewReader(`{"format_version": "1.0", "values": {"root_module": {}}}`))
+	if err == nil || !strings.Contains(err.Error(), "planned_values") {
+		t.Errorf("expected error for state JSON, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package drift
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marcdicarlo/osc/internal/logx"
+	"gopkg.in/yaml.v2"
+)
+
+// StateConfigFileName is the optional per-project file describing remote state
+const StateConfigFileName = "state.yaml"
+
+// defaultRemoteStateTimeout bounds a single remote state request
+const defaultRemoteStateTimeout = 30 * time.Second
+
+// StateConfig describes where a project's remote Terraform state is stored.
+// Address, Username, Password and header values may reference environment
+// variables as $VAR or ${VAR} so credentials stay out of the file.
+type StateConfig struct {
+	// Backend is the state backend; only "http" is supported
+	Backend string `yaml:"backend"`
+	// Address is the state URL, e.g. a GitLab terraform/state endpoint
+	Address string `yaml:"address"`
+	// Username and Password are sent as HTTP basic auth when Username is set
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// Headers are added to the request, e.g. PRIVATE-TOKEN or Authorization
+	Headers map[string]string `yaml:"headers"`
+	// Timeout limits the request (default 30s)
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// LoadStateConfig reads a project's state.yaml. A missing file yields nil.
+func LoadStateConfig(path string) (*StateConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state config: %w", err)
+	}
+
+	var cfg StateConfig
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse state config %s: %w", path, err)
+	}
+	if cfg.Backend == "" {
+		cfg.Backend = "http"
+	}
+	if cfg.Backend != "http" {
+		return nil, fmt.Errorf("unsupported state backend %q in %s (supported: http)", cfg.Backend, path)
+	}
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("state config %s has no address", path)
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultRemoteStateTimeout
+	}
+	return &cfg, nil
+}
+
+// FetchHTTPState reads state from a Terraform HTTP backend with a plain GET.
+// The state is not locked. An empty response body yields an empty state; a
+// 404 is an error, since it usually means a mistyped address. A nil client
+// uses a client that logs redacted requests in debug mode.
+func FetchHTTPState(client *http.Client, cfg *StateConfig) (*TerraformState, error) {
+	if client == nil {
+		client = &http.Client{Transport: logx.NewLoggingRoundTripper(nil)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	address := os.ExpandEnv(cfg.Address)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state address %s: %s", logx.RedactURL(address), logx.RedactSensitive(err.Error()))
+	}
+	req.Header.Set("Accept", "application/json")
+	if cfg.Username != "" {
+		req.SetBasicAuth(os.ExpandEnv(cfg.Username), os.ExpandEnv(cfg.Password))
+	}
+	for key, value := range cfg.Headers {
+		req.Header.Set(key, os.ExpandEnv(value))
+	}
+
+	logx.Debugf("remote_state_fetch url=%s %s", logx.RedactURL(address), describeStateAuth(req.Header))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Errors from the client include the URL, which may carry credentials
+		return nil, fmt.Errorf("failed to fetch remote state: %s", logx.RedactSensitive(err.Error()))
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("no remote state at %s (404 Not Found), check the address in %s",
+			logx.RedactURL(address), StateConfigFileName)
+	default:
+		return nil, fmt.Errorf("failed to fetch remote state from %s: %s", logx.RedactURL(address), resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote state: %s", logx.RedactSensitive(err.Error()))
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return &TerraformState{}, nil
+	}
+	return ParseTerraformState(bytes.NewReader(body))
+}
+
+// describeStateAuth summarizes request headers for debug output with credentials redacted
+func describeStateAuth(h http.Header) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+logx.RedactHeaderValue(k, h.Get(k)))
+	}
+	return logx.RedactSensitive("headers=" + strings.Join(pairs, ","))
+}
@@ -0,0 +1,177 @@
+package drift
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marcdicarlo/osc/internal/logx"
+)
+
+func TestLoadProjectStateFromHTTPBackend(t *testing.T) {
+	t.Setenv("OSC_TEST_STATE_PASSWORD", "s3cret-password")
+	t.Setenv("OSC_TEST_STATE_TOKEN", "glpat-s3cret-token")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected lock-free GET, got %s", r.Method)
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "ci" || pass != "s3cret-password" {
+			t.Errorf("unexpected basic auth %q %q", user, pass)
+		}
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "glpat-s3cret-token" {
+			t.Errorf("PRIVATE-TOKEN = %q", got)
+		}
+		switch r.URL.Path {
+		case "/state/prod":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(rawStateJSON))
+		case "/state/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	base := t.TempDir()
+	writeStateConfig := func(project, path string) {
+		dir := filepath.Join(base, project)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		config := "backend: http\n" +
+			"address: " + server.URL + path + "\n" +
+			"username: ci\n" +
+			"password: ${OSC_TEST_STATE_PASSWORD}\n" +
+			"headers:\n" +
+			"  PRIVATE-TOKEN: ${OSC_TEST_STATE_TOKEN}\n"
+		if err := os.WriteFile(filepath.Join(dir, StateConfigFileName), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeStateConfig("prod", "/state/prod")
+	writeStateConfig("empty", "/state/empty")
+
+	// Debug output must not leak credentials
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	logx.SetDebug(true)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		logx.SetDebug(false)
+	}()
+
+	projects, err := DiscoverProjects(base)
+	if err != nil {
+		t.Fatalf("DiscoverProjects: %v", err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("expected 2 projects with only a state.yaml, got %+v", projects)
+	}
+
+	for _, project := range projects {
+		resources, err := LoadProjectState(project, server.Client())
+		if err != nil {
+			t.Fatalf("LoadProjectState(%s): %v", project.Name, err)
+		}
+		switch project.Name {
+		case "prod":
+			if counts := CountResources(resources); counts.Servers != 2 || counts.SecurityGroups != 1 {
+				t.Errorf("unexpected prod counts %+v", counts)
+			}
+		case "empty":
+			if len(resources) != 0 {
+				t.Errorf("expected no resources for empty remote state, got %+v", resources)
+			}
+		}
+	}
+
+	// The default client logs requests; use it once so its output is checked too
+	project := projects[0]
+	if _, err := LoadProjectState(project, nil); err != nil {
+		t.Fatalf("LoadProjectState with default client: %v", err)
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, "remote_state_fetch") {
+		t.Errorf("expected remote state debug output, got %q", out)
+	}
+	for _, secret := range []string{"s3cret-password", "glpat-s3cret-token"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("debug output leaks %q: %s", secret, out)
+		}
+	}
+}
+
+func TestLoadStateConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, StateConfigFileName)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	if cfg, err := LoadStateConfig(filepath.Join(dir, "missing.yaml")); cfg != nil || err != nil {
+		t.Errorf("missing file: got %+v, %v", cfg, err)
+	}
+	if _, err := LoadStateConfig(write("backend: s3\naddress: x\n")); err == nil {
+		t.Error("expected error for unsupported backend")
+	}
+	if _, err := LoadStateConfig(write("backend: http\n")); err == nil {
+		t.Error("expected error for missing address")
+	}
+	if _, err := LoadStateConfig(write("address: http://x\nlock_address: http://x/lock\n")); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func TestFetchHTTPStateEmptyAndMissing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/empty":
+			w.WriteHeader(http.StatusOK)
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetch := func(path string) (*TerraformState, error) {
+		return FetchHTTPState(server.Client(), &StateConfig{Address: server.URL + path, Timeout: defaultRemoteStateTimeout})
+	}
+	for _, path := range []string{"/empty", "/no-content"} {
+		state, err := fetch(path)
+		if err != nil || state == nil || len(ExtractResourcesFromTerraform(state, "p")) != 0 {
+			t.Errorf("%s: expected an empty state, got %+v, %v", path, state, err)
+		}
+	}
+	if _, err := fetch("/mistyped"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected 404 error for a missing state, got %v", err)
+	}
+}
+
+func TestFetchHTTPStateError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := FetchHTTPState(server.Client(), &StateConfig{Address: server.URL + "/state?token=abc", Timeout: defaultRemoteStateTimeout})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected 401 error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "abc") {
+		t.Errorf("error leaks token: %v", err)
+	}
+}
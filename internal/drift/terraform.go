@@ -6,14 +6,21 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-// TerraformState represents the top-level structure of terraform show -json output
+// TerraformState represents the top-level structure of terraform show -json output.
+// Raw state files (terraform.tfstate, terraform state pull) set Version and
+// Resources instead of Values; ParseTerraformState converts them into Values.
 type TerraformState struct {
-	FormatVersion    string          `json:"format_version"`
-	TerraformVersion string          `json:"terraform_version"`
+	FormatVersion    string           `json:"format_version"`
+	TerraformVersion string           `json:"terraform_version"`
 	Values           *TerraformValues `json:"values"`
+
+	Version   int                `json:"version,omitempty"`
+	Resources []RawStateResource `json:"resources,omitempty"`
 }
 
 // TerraformValues contains the root module
@@ -36,13 +43,13 @@ type TerraformChildModule struct {
 
 // TerraformResource represents a single resource in Terraform state
 type TerraformResource struct {
-	Address      string                 `json:"address"`
-	Mode         string                 `json:"mode"`
-	Type         string                 `json:"type"`
-	Name         string                 `json:"name"`
-	Index        any                    `json:"index,omitempty"`
-	ProviderName string                 `json:"provider_name"`
-	Values       map[string]any         `json:"values"`
+	Address      string         `json:"address"`
+	Mode         string         `json:"mode"`
+	Type         string         `json:"type"`
+	Name         string         `json:"name"`
+	Index        any            `json:"index,omitempty"`
+	ProviderName string         `json:"provider_name"`
+	Values       map[string]any `json:"values"`
 }
 
 // OpenStack resource type constants
@@ -51,15 +58,25 @@ const (
 	TerraformTypeSecurityGroup   = "openstack_networking_secgroup_v2"
 	TerraformTypeSecGroupRule    = "openstack_networking_secgroup_rule_v2"
 	TerraformTypeBlockVolume     = "openstack_blockstorage_volume_v3"
+	TerraformTypeVolumeAttach    = "openstack_compute_volume_attach_v2"
+	TerraformTypePort            = "openstack_networking_port_v2"
+	TerraformTypePortSecGroup    = "openstack_networking_port_secgroup_associate_v2"
+	TerraformTypeFloatingIPAssoc = "openstack_networking_floatingip_associate_v2"
 )
 
-// ParseTerraformState parses a Terraform state JSON file
+// ParseTerraformState parses Terraform state JSON, either terraform show -json
+// output or a raw version 4 state file
 func ParseTerraformState(r io.Reader) (*TerraformState, error) {
 	var state TerraformState
 	decoder := json.NewDecoder(r)
 	if err := decoder.Decode(&state); err != nil {
 		return nil, fmt.Errorf("failed to parse Terraform state: %w", err)
 	}
+	if state.Values == nil && state.Version != 0 {
+		if err := convertRawState(&state); err != nil {
+			return nil, err
+		}
+	}
 	return &state, nil
 }
 
@@ -82,47 +99,237 @@ func ExtractResourcesFromTerraform(state *TerraformState, projectName string) []
 	var resources []Resource
 
 	// Case 1: Process direct resources in root module (resources NOT in a module)
-	resources = append(resources, extractResourcesFromModule(state.Values.RootModule.Resources, projectName)...)
+	resources = append(resources, extractResourcesFromModule(state.Values.RootModule.Resources, projectName, "")...)
 
 	// Case 2: Recursively process resources in child modules
 	resources = append(resources, extractResourcesFromChildModules(state.Values.RootModule.ChildModules, projectName)...)
 
+	nameVolumeAttachments(resources)
+
+	ports := collectPorts(state.Values.RootModule.Resources, state.Values.RootModule.ChildModules)
+	ports.resolveOntoServers(resources)
+
 	return resources
 }
 
+// portAttachment is what a Neutron port contributes to the server it is bound to
+type portAttachment struct {
+	deviceID       string
+	securityGroups []string // security group IDs
+	floatingIP     string
+}
+
+// portAttachments holds the ports of a state and the associations made on them, by port ID
+type portAttachments map[string]*portAttachment
+
+// port returns the attachment for a port ID, creating it when missing
+func (p portAttachments) port(id string) *portAttachment {
+	if p[id] == nil {
+		p[id] = &portAttachment{}
+	}
+	return p[id]
+}
+
+// collectPorts gathers ports, port security group associations and floating IP
+// associations from all modules of a state
+func collectPorts(rootResources []TerraformResource, modules []TerraformChildModule) portAttachments {
+	ports := make(portAttachments)
+	var collect func(tfResources []TerraformResource, modules []TerraformChildModule)
+	collect = func(tfResources []TerraformResource, modules []TerraformChildModule) {
+		for _, tfRes := range tfResources {
+			if tfRes.Mode == "data" {
+				continue
+			}
+			switch tfRes.Type {
+			case TerraformTypePort:
+				id := getStringValue(tfRes.Values, "id")
+				if id == "" {
+					continue
+				}
+				port := ports.port(id)
+				port.deviceID = getStringValue(tfRes.Values, "device_id")
+				// An association resource on the same port takes precedence
+				if port.securityGroups == nil {
+					port.securityGroups = securityGroupIDs(tfRes.Values)
+				}
+			case TerraformTypePortSecGroup:
+				id := getStringValue(tfRes.Values, "port_id")
+				if id == "" {
+					continue
+				}
+				ports.port(id).securityGroups = securityGroupIDs(tfRes.Values)
+			case TerraformTypeFloatingIPAssoc:
+				if id := getStringValue(tfRes.Values, "port_id"); id != "" {
+					ports.port(id).floatingIP = getStringValue(tfRes.Values, "floating_ip")
+				}
+			}
+		}
+		for _, module := range modules {
+			collect(module.Resources, module.ChildModules)
+		}
+	}
+	collect(rootResources, modules)
+	return ports
+}
+
+// securityGroupIDs returns the security group IDs of a port or port association,
+// or an empty non-nil slice when it has none. all_security_group_ids also holds
+// groups added outside the association when enforce is false.
+func securityGroupIDs(values map[string]any) []string {
+	list, ok := values["all_security_group_ids"].([]any)
+	if !ok || len(list) == 0 {
+		list, _ = values["security_group_ids"].([]any)
+	}
+	ids := []string{}
+	for _, v := range list {
+		if id, ok := v.(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// resolveOntoServers adds the security groups and floating IPs of each server's
+// ports to the server. A port belongs to a server when the server's network
+// block names it or its device_id is the server. Security group IDs are
+// resolved to names from the security groups in the same state; IDs of groups
+// managed elsewhere are kept as they are.
+func (p portAttachments) resolveOntoServers(resources []Resource) {
+	if len(p) == 0 {
+		return
+	}
+
+	sgNames := make(map[string]string)
+	for _, res := range resources {
+		if res.Type == ResourceTypeSecurityGroup {
+			sgNames[res.ID] = res.Name
+		}
+	}
+
+	portsByServer := make(map[string][]string)
+	for id, port := range p {
+		if port.deviceID != "" {
+			portsByServer[port.deviceID] = append(portsByServer[port.deviceID], id)
+		}
+	}
+
+	for i := range resources {
+		server := &resources[i]
+		if server.Type != ResourceTypeServer {
+			continue
+		}
+
+		portIDs := append(portsByServer[server.ID], serverPortIDs(server)...)
+		sort.Strings(portIDs)
+		seen := make(map[string]bool)
+		var floatingIPs []string
+		for _, id := range portIDs {
+			port := p[id]
+			if port == nil || seen[id] {
+				continue
+			}
+			seen[id] = true
+			for _, sgID := range port.securityGroups {
+				name := sgNames[sgID]
+				if name == "" {
+					name = sgID
+				}
+				server.SecurityGroups = append(server.SecurityGroups, name)
+			}
+			if port.floatingIP != "" {
+				floatingIPs = append(floatingIPs, port.floatingIP)
+			}
+		}
+		if len(floatingIPs) > 0 {
+			server.Properties["floating_ip"] = floatingIPs[0]
+		}
+	}
+}
+
+// serverPortIDs returns the port IDs named in a server's network blocks
+func serverPortIDs(server *Resource) []string {
+	ids, _ := server.Properties["ports"].([]string)
+	return ids
+}
+
 // extractResourcesFromChildModules recursively extracts resources from child modules
 func extractResourcesFromChildModules(modules []TerraformChildModule, projectName string) []Resource {
 	var resources []Resource
 	for _, module := range modules {
-		resources = append(resources, extractResourcesFromModule(module.Resources, projectName)...)
+		resources = append(resources, extractResourcesFromModule(module.Resources, projectName, module.Address)...)
 		// Recursively process nested child modules
 		resources = append(resources, extractResourcesFromChildModules(module.ChildModules, projectName)...)
 	}
 	return resources
 }
 
-// extractResourcesFromModule extracts resources from a slice of TerraformResource
-func extractResourcesFromModule(tfResources []TerraformResource, projectName string) []Resource {
+// extractResourcesFromModule extracts resources from a slice of TerraformResource.
+// modulePath is the address of the module holding the resources, empty for the root module.
+func extractResourcesFromModule(tfResources []TerraformResource, projectName, modulePath string) []Resource {
 	var resources []Resource
 	for _, tfRes := range tfResources {
+		// Data sources read existing resources; they are not managed by this state
+		if tfRes.Mode == "data" {
+			continue
+		}
+
+		var res *Resource
 		switch tfRes.Type {
 		case TerraformTypeComputeInstance:
-			if res := extractServer(tfRes, projectName); res != nil {
-				resources = append(resources, *res)
-			}
+			res = extractServer(tfRes, projectName)
 		case TerraformTypeSecurityGroup:
-			if res := extractSecurityGroup(tfRes, projectName); res != nil {
-				resources = append(resources, *res)
-			}
+			res = extractSecurityGroup(tfRes, projectName)
 		case TerraformTypeSecGroupRule:
-			if res := extractSecurityGroupRule(tfRes, projectName); res != nil {
-				resources = append(resources, *res)
-			}
+			res = extractSecurityGroupRule(tfRes, projectName)
+		case TerraformTypeBlockVolume:
+			res = extractVolume(tfRes, projectName)
+		case TerraformTypeVolumeAttach:
+			res = extractVolumeAttachment(tfRes, projectName)
+		}
+		if res == nil {
+			continue
+		}
+
+		res.Address = tfRes.Address
+		if res.Address == "" && tfRes.Name != "" {
+			res.Address = resourceAddress(modulePath, tfRes.Type, tfRes.Name, tfRes.Index)
 		}
+		res.ModulePath = modulePath
+		res.Index = tfRes.Index
+		resources = append(resources, *res)
 	}
 	return resources
 }
 
+// resourceAddress builds a resource instance address, e.g. module.web.openstack_compute_instance_v2.app["a"]
+func resourceAddress(modulePath, resType, name string, index any) string {
+	address := resType + "." + name
+	if modulePath != "" {
+		address = modulePath + "." + address
+	}
+	if index != nil {
+		address += "[" + FormatIndex(index) + "]"
+	}
+	return address
+}
+
+// FormatIndex formats a count or for_each index key as it appears in an address:
+// numbers as-is and strings quoted
+func FormatIndex(index any) string {
+	switch key := index.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.Itoa(int(key))
+	case int:
+		return strconv.Itoa(key)
+	case string:
+		return strconv.Quote(key)
+	default:
+		return fmt.Sprint(key)
+	}
+}
+
 // extractServer extracts a server resource from Terraform resource
 func extractServer(tfRes TerraformResource, projectName string) *Resource {
 	id := getStringValue(tfRes.Values, "id")
@@ -147,9 +354,28 @@ func extractServer(tfRes TerraformResource, projectName string) *Resource {
 	props["ip_address"] = getStringValue(tfRes.Values, "access_ip_v4")
 	props["flavor_name"] = getStringValue(tfRes.Values, "flavor_name")
 	props["flavor_id"] = getStringValue(tfRes.Values, "flavor_id")
+	// Servers booted from a volume have a placeholder instead of an image ID
+	if imageID := getStringValue(tfRes.Values, "image_id"); !strings.HasPrefix(imageID, "Attempt to boot from volume") {
+		props["image_id"] = imageID
+	}
 	props["image_name"] = getStringValue(tfRes.Values, "image_name")
+	props["metadata"] = getStringMapValue(tfRes.Values, "metadata")
 	props["power_state"] = getStringValue(tfRes.Values, "power_state")
 	props["availability_zone"] = getStringValue(tfRes.Values, "availability_zone")
+	// Ports are resolved onto the server once the whole state has been read
+	var ports []string
+	if networks, ok := tfRes.Values["network"].([]any); ok {
+		for _, n := range networks {
+			if network, ok := n.(map[string]any); ok {
+				if port := getStringValue(network, "port"); port != "" {
+					ports = append(ports, port)
+				}
+			}
+		}
+	}
+	if len(ports) > 0 {
+		props["ports"] = ports
+	}
 
 	return &Resource{
 		ID:             id,
@@ -198,12 +424,10 @@ func extractSecurityGroupRule(tfRes TerraformResource, projectName string) *Reso
 	props["remote_ip_prefix"] = getStringValue(tfRes.Values, "remote_ip_prefix")
 	props["remote_group_id"] = getStringValue(tfRes.Values, "remote_group_id")
 
-	// Handle port range
+	// Handle port range ("0:0" means any port)
 	portMin := getIntValue(tfRes.Values, "port_range_min")
 	portMax := getIntValue(tfRes.Values, "port_range_max")
-	if portMin > 0 || portMax > 0 {
-		props["port_range"] = fmt.Sprintf("%d:%d", portMin, portMax)
-	}
+	props["port_range"] = fmt.Sprintf("%d:%d", portMin, portMax)
 
 	return &Resource{
 		ID:          id,
@@ -215,30 +439,111 @@ func extractSecurityGroupRule(tfRes TerraformResource, projectName string) *Reso
 	}
 }
 
-// LoadTerraformStateFromDir loads and merges all Terraform state files from a directory
+// extractVolume extracts a volume resource from Terraform resource
+func extractVolume(tfRes TerraformResource, projectName string) *Resource {
+	id := getStringValue(tfRes.Values, "id")
+	name := getStringValue(tfRes.Values, "name")
+
+	if id == "" {
+		return nil
+	}
+
+	props := make(map[string]any)
+	props["size"] = strconv.Itoa(getIntValue(tfRes.Values, "size"))
+	props["volume_type"] = getStringValue(tfRes.Values, "volume_type")
+
+	return &Resource{
+		ID:          id,
+		Name:        name,
+		Type:        ResourceTypeVolume,
+		ProjectName: projectName,
+		Properties:  props,
+	}
+}
+
+// extractVolumeAttachment extracts a volume attachment from Terraform resource.
+// Attachments are identified by "<server_id>/<volume_id>", Terraform's attach
+// ID, so that a volume attached to several servers has one attachment each.
+func extractVolumeAttachment(tfRes TerraformResource, projectName string) *Resource {
+	volumeID := getStringValue(tfRes.Values, "volume_id")
+	serverID := getStringValue(tfRes.Values, "instance_id")
+
+	if volumeID == "" || serverID == "" {
+		return nil
+	}
+
+	props := make(map[string]any)
+	props["server_id"] = serverID
+	props["volume_id"] = volumeID
+	props["device"] = getStringValue(tfRes.Values, "device")
+
+	return &Resource{
+		ID:          volumeAttachmentID(serverID, volumeID),
+		Name:        "", // Filled in from the volume by nameVolumeAttachments
+		Type:        ResourceTypeVolumeAttachment,
+		ProjectName: projectName,
+		Properties:  props,
+	}
+}
+
+// volumeAttachmentID returns the ID of the attachment of a volume to a server
+func volumeAttachmentID(serverID, volumeID string) string {
+	return serverID + "/" + volumeID
+}
+
+// nameVolumeAttachments names attachments after their volume when the volume is in the same state
+func nameVolumeAttachments(resources []Resource) {
+	volumeNames := make(map[string]string)
+	for _, res := range resources {
+		if res.Type == ResourceTypeVolume {
+			volumeNames[res.ID] = res.Name
+		}
+	}
+	for i := range resources {
+		if resources[i].Type == ResourceTypeVolumeAttachment && resources[i].Name == "" {
+			resources[i].Name = volumeNames[getPropertyString(resources[i].Properties, "volume_id")]
+		}
+	}
+}
+
+// LoadTerraformStateFromDir loads and merges all Terraform state files from a directory.
+// It reads terraform show -json output (*.json) and raw state files (*.tfstate).
+// A *.tfstate.backup file is only read when its *.tfstate file is missing.
+// Files that cannot be parsed are skipped with a warning on stderr.
 func LoadTerraformStateFromDir(dirPath, projectName string) ([]Resource, error) {
+	resources, skipped, err := loadTerraformStateDir(dirPath, projectName)
+	warnSkipped(skipped)
+	return resources, err
+}
+
+// loadTerraformStateDir loads the state files in a directory, returning the
+// files that could not be parsed alongside the resources of the others
+func loadTerraformStateDir(dirPath, projectName string) ([]Resource, []LoadError, error) {
 	var allResources []Resource
+	var skipped []LoadError
 
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read state directory: %w", err)
+		return nil, nil, fmt.Errorf("failed to read state directory: %w", err)
 	}
 
+	files := make(map[string]bool)
 	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+		if !entry.IsDir() {
+			files[entry.Name()] = true
 		}
+	}
 
-		// Only process .json files
-		if !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+	for _, entry := range entries {
+		if entry.IsDir() || !isStateFile(entry.Name(), files) {
 			continue
 		}
 
 		filePath := filepath.Join(dirPath, entry.Name())
 		state, err := ParseTerraformStateFile(filePath)
 		if err != nil {
-			// Log warning but continue with other files
-			fmt.Printf("Warning: failed to parse %s: %v\n", filePath, err)
+			// Skip the file but continue with the others
+			skipped = append(skipped, LoadError{Project: projectName, File: filePath, Error: err.Error()})
 			continue
 		}
 
@@ -246,7 +551,20 @@ func LoadTerraformStateFromDir(dirPath, projectName string) ([]Resource, error)
 		allResources = append(allResources, resources...)
 	}
 
-	return allResources, nil
+	return allResources, skipped, nil
+}
+
+// isStateFile reports whether a file in a state directory should be loaded.
+// files holds the names of all files in the directory.
+func isStateFile(name string, files map[string]bool) bool {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".json"), strings.HasSuffix(lower, ".tfstate"):
+		return true
+	case strings.HasSuffix(lower, ".tfstate.backup"):
+		return !files[strings.TrimSuffix(name, ".backup")]
+	}
+	return false
 }
 
 // getStringValue safely extracts a string value from a map
@@ -259,6 +577,21 @@ func getStringValue(m map[string]any, key string) string {
 	return ""
 }
 
+// getStringMapValue safely extracts a map of strings from a map
+func getStringMapValue(m map[string]any, key string) map[string]string {
+	result := make(map[string]string)
+	if v, ok := m[key].(map[string]any); ok {
+		for k, val := range v {
+			if s, ok := val.(string); ok {
+				result[k] = s
+			} else {
+				result[k] = fmt.Sprint(val)
+			}
+		}
+	}
+	return result
+}
+
 // getIntValue safely extracts an int value from a map
 func getIntValue(m map[string]any, key string) int {
 	if v, ok := m[key]; ok {
@@ -0,0 +1,83 @@
+package drift
+
+import (
+	"fmt"
+)
+
+// RawStateResource is a resource in a raw Terraform state file (terraform.tfstate
+// or terraform state pull output, state format version 4)
+type RawStateResource struct {
+	Module    string             `json:"module,omitempty"`
+	Mode      string             `json:"mode"`
+	Type      string             `json:"type"`
+	Name      string             `json:"name"`
+	Provider  string             `json:"provider"`
+	Instances []RawStateInstance `json:"instances"`
+}
+
+// RawStateInstance is a single instance of a raw state resource. IndexKey is
+// set for resources using count (a number) or for_each (a string).
+type RawStateInstance struct {
+	IndexKey   any            `json:"index_key,omitempty"`
+	Deposed    string         `json:"deposed,omitempty"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// rawStateVersion is the only raw state format version that can be read
+const rawStateVersion = 4
+
+// convertRawState converts raw v4 state resources into the module structure used
+// by terraform show -json, so both formats are extracted the same way. Each
+// instance becomes a resource with its full address, e.g.
+// module.net.openstack_networking_secgroup_v2.web["dmz"].
+func convertRawState(state *TerraformState) error {
+	if state.Version != rawStateVersion {
+		return fmt.Errorf("unsupported Terraform state version %d (only version %d is supported)", state.Version, rawStateVersion)
+	}
+
+	root := &TerraformRootModule{}
+	modules := make(map[string]int) // module address -> index in root.ChildModules
+
+	for _, raw := range state.Resources {
+		for _, inst := range raw.Instances {
+			// Deposed objects are pending destruction and no longer managed
+			if inst.Deposed != "" {
+				continue
+			}
+
+			res := TerraformResource{
+				Address:      rawResourceAddress(raw, inst.IndexKey),
+				Mode:         raw.Mode,
+				Type:         raw.Type,
+				Name:         raw.Name,
+				Index:        inst.IndexKey,
+				ProviderName: raw.Provider,
+				Values:       inst.Attributes,
+			}
+
+			if raw.Module == "" {
+				root.Resources = append(root.Resources, res)
+				continue
+			}
+			i, ok := modules[raw.Module]
+			if !ok {
+				i = len(root.ChildModules)
+				modules[raw.Module] = i
+				root.ChildModules = append(root.ChildModules, TerraformChildModule{Address: raw.Module})
+			}
+			root.ChildModules[i].Resources = append(root.ChildModules[i].Resources, res)
+		}
+	}
+
+	state.Values = &TerraformValues{RootModule: root}
+	return nil
+}
+
+// rawResourceAddress builds the address of a resource instance
+func rawResourceAddress(raw RawStateResource, indexKey any) string {
+	resType := raw.Type
+	if raw.Mode == "data" {
+		resType = "data." + resType
+	}
+	return resourceAddress(raw.Module, resType, raw.Name, indexKey)
+}
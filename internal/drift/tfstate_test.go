@@ -0,0 +1,145 @@
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const rawStateJSON = `{
+  "version": 4,
+  "terraform_version": "1.6.2",
+  "serial": 12,
+  "lineage": "3f0c1e2a",
+  "outputs": {},
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "openstack_compute_instance_v2",
+      "name": "web",
+      "provider": "provider[\"registry.terraform.io/terraform-provider-openstack/openstack\"]",
+      "instances": [
+        {"index_key": 0, "schema_version": 0, "attributes": {"id": "srv-1", "name": "web-0", "security_groups": ["default"]}},
+        {"index_key": 1, "schema_version": 0, "attributes": {"id": "srv-2", "name": "web-1", "security_groups": ["default"]}}
+      ]
+    },
+    {
+      "module": "module.net",
+      "mode": "managed",
+      "type": "openstack_networking_secgroup_v2",
+      "name": "zone",
+      "provider": "provider[\"registry.terraform.io/terraform-provider-openstack/openstack\"]",
+      "instances": [
+        {"index_key": "dmz", "schema_version": 0, "attributes": {"id": "sg-1", "name": "dmz"}},
+        {"index_key": "dmz", "deposed": "a1b2c3d4", "schema_version": 0, "attributes": {"id": "sg-old", "name": "dmz"}}
+      ]
+    },
+    {
+      "module": "module.net",
+      "mode": "managed",
+      "type": "openstack_networking_secgroup_rule_v2",
+      "name": "ssh",
+      "provider": "provider[\"registry.terraform.io/terraform-provider-openstack/openstack\"]",
+      "instances": [
+        {"schema_version": 0, "attributes": {"id": "rule-1", "security_group_id": "sg-1", "direction": "ingress", "ethertype": "IPv4", "protocol": "tcp", "port_range_min": 22, "port_range_max": 22}}
+      ]
+    },
+    {
+      "mode": "data",
+      "type": "openstack_networking_secgroup_v2",
+      "name": "default",
+      "provider": "provider[\"registry.terraform.io/terraform-provider-openstack/openstack\"]",
+      "instances": [
+        {"schema_version": 0, "attributes": {"id": "sg-default", "name": "default"}}
+      ]
+    }
+  ]
+}`
+
+func TestParseRawTerraformState(t *testing.T) {
+	state, err := ParseTerraformState(strings.NewReader(rawStateJSON))
+	if err != nil {
+		t.Fatalf("ParseTerraformState: %v", err)
+	}
+
+	root := state.Values.RootModule
+	if len(root.ChildModules) != 1 || root.ChildModules[0].Address != "module.net" {
+		t.Fatalf("expected one module.net child module, got %+v", root.ChildModules)
+	}
+
+	var addresses []string
+	for _, r := range root.Resources {
+		addresses = append(addresses, r.Address)
+	}
+	for _, r := range root.ChildModules[0].Resources {
+		addresses = append(addresses, r.Address)
+	}
+	want := []string{
+		"openstack_compute_instance_v2.web[0]",
+		"openstack_compute_instance_v2.web[1]",
+		"data.openstack_networking_secgroup_v2.default",
+		`module.net.openstack_networking_secgroup_v2.zone["dmz"]`,
+		"module.net.openstack_networking_secgroup_rule_v2.ssh",
+	}
+	if strings.Join(addresses, "\n") != strings.Join(want, "\n") {
+		t.Errorf("addresses = %v, want %v", addresses, want)
+	}
+
+	resources := ExtractResourcesFromTerraform(state, "p1")
+	counts := CountResources(resources)
+	// The deposed security group and the data source are skipped
+	if counts.Servers != 2 || counts.SecurityGroups != 1 || counts.SecurityGroupRules != 1 {
+		t.Errorf("unexpected counts %+v", counts)
+	}
+	for _, r := range resources {
+		if r.Type == ResourceTypeSecurityGroupRule && r.Properties["port_range"] != "22:22" {
+			t.Errorf("rule port_range = %v, want 22:22", r.Properties["port_range"])
+		}
+		if r.Type == ResourceTypeSecurityGroup {
+			if r.ModulePath != "module.net" || r.Index != "dmz" || FormatIndex(r.Index) != `"dmz"` {
+				t.Errorf("security group module %q index %v, want module.net and \"dmz\"", r.ModulePath, r.Index)
+			}
+		}
+	}
+}
+
+func TestParseRawTerraformStateUnsupportedVersion(t *testing.T) {
+	_, err := ParseTerraformState(strings.NewReader(`{"version": 3, "modules": []}`))
+	if err == nil || !strings.Contains(err.Error(), "unsupported Terraform state version 3") {
+		t.Errorf("expected unsupported version error, got %v", err)
+	}
+}
+
+func TestLoadTerraformStateFromDirRawFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := func(id string) string {
+		return `{"version": 4, "resources": [{"mode": "managed", "type": "openstack_compute_instance_v2", "name": "s",
+			"instances": [{"attributes": {"id": "` + id + `", "name": "` + id + `"}}]}]}`
+	}
+
+	// The backup is ignored while its state file exists
+	write("terraform.tfstate", server("srv-current"))
+	write("terraform.tfstate.backup", server("srv-previous"))
+	// A backup on its own is read
+	write("network.tfstate.backup", server("srv-network"))
+	write("notes.txt", "not state")
+
+	resources, err := LoadTerraformStateFromDir(dir, "p1")
+	if err != nil {
+		t.Fatalf("LoadTerraformStateFromDir: %v", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, r := range resources {
+		ids[r.ID] = true
+	}
+	if len(resources) != 2 || !ids["srv-current"] || !ids["srv-network"] {
+		t.Errorf("expected srv-current and srv-network, got %+v", resources)
+	}
+}
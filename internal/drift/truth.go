@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -31,7 +32,7 @@ type OscFiltering struct {
 // OscRow represents a row of data from osc output
 // Supports both the new normalized format (top-level fields) and legacy format (Fields map)
 type OscRow struct {
-	// New top-level fields (matching output/json.go JSONRow)
+	// Top-level fields of typed records
 	Type        string `json:"type,omitempty"`
 	ID          string `json:"id,omitempty"`
 	Name        string `json:"name,omitempty"`
@@ -40,23 +41,188 @@ type OscRow struct {
 	IPAddress   string `json:"ip_address,omitempty"`
 
 	// Server-specific
-	SecurityGroups []string `json:"security_groups,omitempty"`
+	SecurityGroups OscSecurityGroups `json:"security_groups,omitempty"`
+
+	// Security group-specific: the rules of a group as nested by osc list
+	// secgrps -r -o json
+	Rules []OscRow `json:"rules,omitempty"`
 
 	// Rule-specific
 	ParentID   string         `json:"parent_id,omitempty"`
 	ParentName string         `json:"parent_name,omitempty"`
 	RuleFields *OscRuleFields `json:"rule_fields,omitempty"`
 
-	// Legacy (for backward compatibility with old JSON format)
+	// Rule details of typed records (osc list secgrps -r -f); converted into
+	// RuleFields by ruleFields
+	Direction      string          `json:"direction,omitempty"`
+	Ethertype      string          `json:"ethertype,omitempty"`
+	Protocol       *string         `json:"protocol,omitempty"`
+	PortRangeMin   *int            `json:"port_range_min,omitempty"`
+	PortRangeMax   *int            `json:"port_range_max,omitempty"`
+	RemoteIPPrefix *string         `json:"remote_ip_prefix,omitempty"`
+	RemoteGroup    *OscRemoteGroup `json:"remote_group,omitempty"`
+
+	// Legacy (for backward compatibility with old JSON format), and the other
+	// values of typed records
 	Fields map[string]string `json:"fields,omitempty"`
 }
 
+// oscRowKeys are the keys of OscRow's own fields
+var oscRowKeys = map[string]bool{
+	"type": true, "id": true, "name": true, "project_name": true, "project_id": true,
+	"ip_address": true, "security_groups": true, "parent_id": true, "parent_name": true,
+	"rule_fields": true, "direction": true, "ethertype": true, "protocol": true,
+	"port_range_min": true, "port_range_max": true, "remote_ip_prefix": true,
+	"remote_group": true, "rules": true, "fields": true,
+}
+
+// UnmarshalJSON reads a row of either format. The other values of typed
+// records, e.g. size_gb, are added to Fields as strings, objects as JSON, so
+// they are read the same way as the legacy fields.
+func (row *OscRow) UnmarshalJSON(data []byte) error {
+	type plain OscRow
+	if err := json.Unmarshal(data, (*plain)(row)); err != nil {
+		return err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	for key, raw := range values {
+		if oscRowKeys[key] {
+			continue
+		}
+		var value string
+		switch raw[0] {
+		case '"':
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return err
+			}
+		case 'n', '[':
+			continue // null and lists have no string form
+		default:
+			value = string(raw) // numbers, booleans and objects
+		}
+		if row.Fields == nil {
+			row.Fields = make(map[string]string)
+		}
+		if _, ok := row.Fields[key]; !ok {
+			row.Fields[key] = value
+		}
+	}
+	return nil
+}
+
 // OscRuleFields contains security group rule specific fields
 type OscRuleFields struct {
-	Direction string `json:"direction,omitempty"`
-	Protocol  string `json:"protocol,omitempty"`
-	PortRange string `json:"port_range,omitempty"`
-	RemoteIP  string `json:"remote_ip,omitempty"`
+	Direction   string `json:"direction,omitempty"`
+	Protocol    string `json:"protocol,omitempty"`
+	PortRange   string `json:"port_range,omitempty"`
+	RemoteIP    string `json:"remote_ip,omitempty"`
+	Ethertype   string `json:"ethertype,omitempty"`
+	RemoteGroup string `json:"remote_group,omitempty"`
+}
+
+// OscSecurityGroups are the security groups of a server. osc lists them as
+// names, or as {"id", "name"} objects with --full.
+type OscSecurityGroups []OscRemoteGroup
+
+// UnmarshalJSON accepts security groups as names or as objects
+func (s *OscSecurityGroups) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	groups := make(OscSecurityGroups, 0, len(items))
+	for _, item := range items {
+		var name string
+		if err := json.Unmarshal(item, &name); err == nil {
+			groups = append(groups, OscRemoteGroup{Name: name})
+			continue
+		}
+		var group OscRemoteGroup
+		if err := json.Unmarshal(item, &group); err != nil {
+			return err
+		}
+		groups = append(groups, group)
+	}
+	*s = groups
+	return nil
+}
+
+// Names returns the group names, or the ID of groups without a name
+func (s OscSecurityGroups) Names() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s))
+	for _, group := range s {
+		if group.Name != "" {
+			names = append(names, group.Name)
+		} else {
+			names = append(names, group.ID)
+		}
+	}
+	return names
+}
+
+// namesByID maps the IDs of groups listed with both an ID and a name to the name
+func (s OscSecurityGroups) namesByID() map[string]string {
+	names := make(map[string]string)
+	for _, group := range s {
+		if group.ID != "" && group.Name != "" {
+			names[group.ID] = group.Name
+		}
+	}
+	return names
+}
+
+// OscRemoteGroup is a security group referenced by ID and name
+type OscRemoteGroup struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ruleFields returns the rule details of a row, from the rule_fields object
+// of older output or from the rule details of typed records
+func (row OscRow) ruleFields() *OscRuleFields {
+	if row.RuleFields != nil || row.Direction == "" {
+		return row.RuleFields
+	}
+	fields := &OscRuleFields{
+		Direction: row.Direction,
+		Protocol:  "any",
+		PortRange: "any",
+		RemoteIP:  "any",
+		Ethertype: row.Ethertype,
+	}
+	if row.Protocol != nil && *row.Protocol != "" {
+		fields.Protocol = *row.Protocol
+	}
+	switch {
+	case row.PortRangeMin != nil && row.PortRangeMax != nil && *row.PortRangeMin == *row.PortRangeMax:
+		fields.PortRange = strconv.Itoa(*row.PortRangeMin)
+	case row.PortRangeMin != nil || row.PortRangeMax != nil:
+		fields.PortRange = formatPort(row.PortRangeMin) + "-" + formatPort(row.PortRangeMax)
+	}
+	if row.RemoteIPPrefix != nil && *row.RemoteIPPrefix != "" {
+		fields.RemoteIP = *row.RemoteIPPrefix
+	}
+	if row.RemoteGroup != nil {
+		fields.RemoteGroup = row.RemoteGroup.ID
+		if row.RemoteGroup.Name != "" {
+			fields.RemoteGroup += " (" + row.RemoteGroup.Name + ")"
+		}
+	}
+	return fields
+}
+
+// formatPort formats an optional port number, empty when unset
+func formatPort(port *int) string {
+	if port == nil {
+		return ""
+	}
+	return strconv.Itoa(*port)
 }
 
 // ParseOscOutput parses osc JSON output from a reader
@@ -91,13 +257,32 @@ func ExtractResourcesFromOsc(output *OscOutput, projectName string) []Resource {
 	for _, row := range output.Data {
 		switch row.Type {
 		case "security-group":
-			if res := extractOscSecurityGroup(row, projectName); res != nil {
-				resources = append(resources, *res)
+			res := extractOscSecurityGroup(row, projectName)
+			if res == nil {
+				continue
+			}
+			resources = append(resources, *res)
+			for _, rule := range row.Rules {
+				rule.ParentID, rule.ParentName = res.ID, res.Name
+				if rule.ProjectName == "" {
+					rule.ProjectName = row.ProjectName
+				}
+				if ruleRes := extractOscSecurityGroupRule(rule, projectName); ruleRes != nil {
+					resources = append(resources, *ruleRes)
+				}
 			}
 		case "security-group-rule":
 			if res := extractOscSecurityGroupRule(row, projectName); res != nil {
 				resources = append(resources, *res)
 			}
+		case "volume":
+			if res := extractOscVolume(row, projectName); res != nil {
+				resources = append(resources, *res)
+			}
+		case "volume-attachment":
+			if res := extractOscVolumeAttachment(row, projectName); res != nil {
+				resources = append(resources, *res)
+			}
 		default:
 			// This is likely a server row (no type field or type="server")
 			if res := extractOscServer(row, projectName); res != nil {
@@ -143,12 +328,39 @@ func extractOscServer(row OscRow, projectName string) *Resource {
 	props := make(map[string]any)
 	props["ip_address"] = ipAddr
 
+	// Optional server details, only compared when the truth file has them
+	for _, key := range []string{"flavor_id", "flavor_name", "image_id", "image_name"} {
+		if v := getOscField(row.Fields, key); v != "" {
+			props[key] = v
+		}
+	}
+	if v := getOscField(row.Fields, "status", "power_state"); v != "" {
+		props["power_state"] = v
+	}
+	// An empty floating IP means the server has none
+	if v, ok := row.Fields["floating_ip"]; ok {
+		props["floating_ip"] = v
+	}
+	if v, ok := row.Fields["metadata"]; ok {
+		metadata := make(map[string]string)
+		if v != "" {
+			if err := json.Unmarshal([]byte(v), &metadata); err == nil {
+				props["metadata"] = metadata
+			}
+		} else {
+			props["metadata"] = metadata
+		}
+	}
+	if ids := row.SecurityGroups.namesByID(); len(ids) > 0 {
+		props["security_group_names"] = ids
+	}
+
 	return &Resource{
 		ID:             id,
 		Name:           name,
 		Type:           ResourceTypeServer,
 		ProjectName:    project,
-		SecurityGroups: row.SecurityGroups,
+		SecurityGroups: row.SecurityGroups.Names(),
 		Properties:     props,
 	}
 }
@@ -217,11 +429,16 @@ func extractOscSecurityGroupRule(row OscRow, projectName string) *Resource {
 	}
 
 	props := make(map[string]any)
-	if row.RuleFields != nil {
-		props["direction"] = row.RuleFields.Direction
-		props["protocol"] = row.RuleFields.Protocol
-		props["port_range"] = row.RuleFields.PortRange
-		props["remote_ip"] = row.RuleFields.RemoteIP
+	if ruleFields := row.ruleFields(); ruleFields != nil {
+		props["direction"] = ruleFields.Direction
+		props["protocol"] = ruleFields.Protocol
+		props["port_range"] = ruleFields.PortRange
+		props["remote_ip"] = ruleFields.RemoteIP
+		// Ethertype and remote group are only present in full output (osc list secgrps -r -f)
+		if ruleFields.Ethertype != "" {
+			props["ethertype"] = ruleFields.Ethertype
+			props["remote_group"] = ruleFields.RemoteGroup
+		}
 	}
 
 	return &Resource{
@@ -235,13 +452,92 @@ func extractOscSecurityGroupRule(row OscRow, projectName string) *Resource {
 	}
 }
 
-// LoadTruthFromDir loads and merges all osc JSON files from a directory
+// extractOscVolume extracts a volume resource from osc row
+func extractOscVolume(row OscRow, projectName string) *Resource {
+	id := row.ID
+	if id == "" {
+		id = getOscField(row.Fields, "ID", "id")
+	}
+
+	name := row.Name
+	if name == "" {
+		name = getOscField(row.Fields, "Name", "name")
+	}
+
+	if id == "" {
+		return nil
+	}
+
+	project := projectName
+	if project == "" {
+		project = row.ProjectName
+	}
+
+	props := make(map[string]any)
+	props["size"] = getOscField(row.Fields, "size_gb", "size")
+	props["volume_type"] = getOscField(row.Fields, "volume_type")
+
+	return &Resource{
+		ID:          id,
+		Name:        name,
+		Type:        ResourceTypeVolume,
+		ProjectName: project,
+		Properties:  props,
+	}
+}
+
+// extractOscVolumeAttachment extracts a volume attachment from osc row.
+// The row ID is "<server_id>/<volume_id>", matching Terraform attachments;
+// rows with only the volume ID get the attached server prepended.
+func extractOscVolumeAttachment(row OscRow, projectName string) *Resource {
+	id := row.ID
+	if id == "" {
+		id = getOscField(row.Fields, "ID", "id")
+	}
+	serverID := getOscField(row.Fields, "attached_server", "server_id")
+
+	if id == "" || serverID == "" {
+		return nil
+	}
+	volumeID := strings.TrimPrefix(id, serverID+"/")
+	id = volumeAttachmentID(serverID, volumeID)
+
+	project := projectName
+	if project == "" {
+		project = row.ProjectName
+	}
+
+	props := make(map[string]any)
+	props["server_id"] = serverID
+	props["volume_id"] = volumeID
+	props["device"] = getOscField(row.Fields, "device")
+
+	return &Resource{
+		ID:          id,
+		Name:        row.Name,
+		Type:        ResourceTypeVolumeAttachment,
+		ProjectName: project,
+		Properties:  props,
+	}
+}
+
+// LoadTruthFromDir loads and merges all osc JSON files from a directory.
+// Files that cannot be parsed are skipped with a warning on stderr.
 func LoadTruthFromDir(dirPath, projectName string) ([]Resource, error) {
+	resources, skipped, err := loadTruthDir(dirPath, projectName)
+	warnSkipped(skipped)
+	return resources, err
+}
+
+// loadTruthDir loads the osc JSON files in a directory, returning the files
+// that could not be parsed alongside the resources of the others
+func loadTruthDir(dirPath, projectName string) ([]Resource, []LoadError, error) {
 	var allResources []Resource
+	var skipped []LoadError
 
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read truth directory: %w", err)
+		return nil, nil, fmt.Errorf("failed to read truth directory: %w", err)
 	}
 
 	for _, entry := range entries {
@@ -257,8 +553,8 @@ func LoadTruthFromDir(dirPath, projectName string) ([]Resource, error) {
 		filePath := filepath.Join(dirPath, entry.Name())
 		output, err := ParseOscOutputFile(filePath)
 		if err != nil {
-			// Log warning but continue with other files
-			fmt.Printf("Warning: failed to parse %s: %v\n", filePath, err)
+			// Skip the file but continue with the others
+			skipped = append(skipped, LoadError{Project: projectName, File: filePath, Error: err.Error()})
 			continue
 		}
 
@@ -266,7 +562,7 @@ func LoadTruthFromDir(dirPath, projectName string) ([]Resource, error) {
 		allResources = append(allResources, resources...)
 	}
 
-	return allResources, nil
+	return allResources, skipped, nil
 }
 
 // getOscField tries multiple field names and returns the first non-empty value
@@ -7,48 +7,126 @@ const (
 	ResourceTypeServer            ResourceType = "server"
 	ResourceTypeSecurityGroup     ResourceType = "security-group"
 	ResourceTypeSecurityGroupRule ResourceType = "security-group-rule"
+	ResourceTypeVolume            ResourceType = "volume"
+	ResourceTypeVolumeAttachment  ResourceType = "volume-attachment"
 )
 
 // DriftStatus represents the type of drift detected
 type DriftStatus string
 
 const (
-	StatusMissingInTruth  DriftStatus = "missing_in_truth"
-	StatusMissingInState  DriftStatus = "missing_in_state"
-	StatusNameChanged     DriftStatus = "name_changed"
-	StatusSecGroupChanged DriftStatus = "secgroups_changed"
-	StatusRuleChanged     DriftStatus = "rule_changed"
+	StatusMissingInTruth    DriftStatus = "missing_in_truth"
+	StatusMissingInState    DriftStatus = "missing_in_state"
+	StatusNameChanged       DriftStatus = "name_changed"
+	StatusSecGroupChanged   DriftStatus = "secgroups_changed"
+	StatusRuleChanged       DriftStatus = "rule_changed"
+	StatusSizeChanged       DriftStatus = "size_changed"
+	StatusVolumeTypeChanged DriftStatus = "volume_type_changed"
+	StatusAttachmentChanged DriftStatus = "attachment_changed"
+	StatusFlavorChanged     DriftStatus = "flavor_changed"
+	StatusImageChanged      DriftStatus = "image_changed"
+	StatusMetadataChanged   DriftStatus = "metadata_changed"
+	StatusPowerStateChanged DriftStatus = "power_state_changed"
+	StatusFloatingIPChanged DriftStatus = "floating_ip_changed"
+	StatusRecreated         DriftStatus = "recreated"
 )
 
 // Resource represents a unified resource from either Terraform state or osc truth
 type Resource struct {
-	ID             string                 `json:"id"`
-	Name           string                 `json:"name"`
-	Type           ResourceType           `json:"type"`
-	ProjectName    string                 `json:"project_name"`
-	ParentID       string                 `json:"parent_id,omitempty"`       // For rules: parent security group ID
-	ParentName     string                 `json:"parent_name,omitempty"`     // For rules: parent security group name
-	SecurityGroups []string               `json:"security_groups,omitempty"` // For servers: attached security group names
-	Properties     map[string]any `json:"properties,omitempty"` // Additional properties for detailed comparison
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Type           ResourceType   `json:"type"`
+	ProjectName    string         `json:"project_name"`
+	ParentID       string         `json:"parent_id,omitempty"`       // For rules: parent security group ID
+	ParentName     string         `json:"parent_name,omitempty"`     // For rules: parent security group name
+	SecurityGroups []string       `json:"security_groups,omitempty"` // For servers: attached security group names
+	Properties     map[string]any `json:"properties,omitempty"`      // Additional properties for detailed comparison
+	Address        string         `json:"address,omitempty"`         // Terraform resource address, state only
+	ModulePath     string         `json:"module_path,omitempty"`     // Terraform module address, empty for the root module
+	Index          any            `json:"index,omitempty"`           // count (number) or for_each (string) index key
 }
 
 // DiffResult represents a single drift detection result
 type DiffResult struct {
-	ResourceType ResourceType `json:"resource_type"`
-	ResourceName string       `json:"resource_name"`
-	ResourceID   string       `json:"resource_id"`
-	ProjectName  string       `json:"project_name"`
-	ParentSG     string       `json:"parent_sg,omitempty"` // For rules only
-	Status       DriftStatus  `json:"status"`
-	Details      string       `json:"details"` // Description of what changed
+	ResourceType ResourceType  `json:"resource_type"`
+	ResourceName string        `json:"resource_name"`
+	ResourceID   string        `json:"resource_id"`
+	PreviousID   string        `json:"previous_id,omitempty"` // For recreated resources: the ID in state
+	ProjectName  string        `json:"project_name"`
+	ParentSG     string        `json:"parent_sg,omitempty"` // For rules only
+	Status       DriftStatus   `json:"status"`
+	Details      string        `json:"details"`               // Description of what changed
+	Changes      []FieldChange `json:"changes,omitempty"`     // Field-level changes for property drift
+	Address      string        `json:"address,omitempty"`     // Terraform address of the state resource
+	ModulePath   string        `json:"module_path,omitempty"` // Terraform module of the state resource
+	Index        any           `json:"index,omitempty"`       // count or for_each index key of the state resource
+}
+
+// HasStatus reports whether the drift item or any of its field changes has a status
+func (d DiffResult) HasStatus(status DriftStatus) bool {
+	if d.Status == status {
+		return true
+	}
+	for _, c := range d.Changes {
+		if c.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldChange describes a single changed property of a resource
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Status DriftStatus `json:"status"`
+	Detail string      `json:"detail"`
 }
 
 // ProjectDrift holds drift detection results for a single project
 type ProjectDrift struct {
-	ProjectName string       `json:"project_name"`
-	Drifts      []DiffResult `json:"drifts"`
+	ProjectName string         `json:"project_name"`
+	Drifts      []DiffResult   `json:"drifts"`
 	StateCount  ResourceCounts `json:"state_count"`
 	TruthCount  ResourceCounts `json:"truth_count"`
+	Ignored     int            `json:"ignored"` // Drift items suppressed by ignore rules
+	// Resolved lists baseline drift that is no longer present
+	Resolved []DiffResult `json:"resolved,omitempty"`
+	// Checked lists every compared resource, with or without drift, for
+	// formats that report passing checks
+	Checked []CheckedResource `json:"-"`
+	// Errors lists state and truth files that were skipped; AddProject adds
+	// them to the report
+	Errors []LoadError `json:"-"`
+	// PlannedChanges lists planned changes to resources that have drifted,
+	// when drift is predicted from a plan
+	PlannedChanges []PlannedChange `json:"planned_changes,omitempty"`
+}
+
+// PlannedChange is a change in a Terraform plan to a resource that has drifted
+type PlannedChange struct {
+	Address      string        `json:"address"`
+	Actions      []string      `json:"actions"`
+	ResourceType ResourceType  `json:"resource_type"`
+	ResourceID   string        `json:"resource_id"`
+	ResourceName string        `json:"resource_name"`
+	Drift        []DriftStatus `json:"drift"` // Current drift of the resource
+}
+
+// LoadError records a project or file that could not be loaded. File is empty
+// when the whole project was skipped.
+type LoadError struct {
+	Project string `json:"project"`
+	File    string `json:"file,omitempty"`
+	Error   string `json:"error"`
+}
+
+// CheckedResource identifies a resource that was compared
+type CheckedResource struct {
+	Type     ResourceType
+	Name     string
+	ID       string
+	ParentSG string
+	Address  string
 }
 
 // ResourceCounts holds counts of resources by type
@@ -56,20 +134,31 @@ type ResourceCounts struct {
 	Servers            int `json:"servers"`
 	SecurityGroups     int `json:"security_groups"`
 	SecurityGroupRules int `json:"security_group_rules"`
+	Volumes            int `json:"volumes"`
+	VolumeAttachments  int `json:"volume_attachments"`
 }
 
 // DriftReport holds the complete drift detection report
 type DriftReport struct {
 	Projects []ProjectDrift `json:"projects"`
 	Summary  DriftSummary   `json:"summary"`
+	// Errors lists projects and files that could not be loaded
+	Errors []LoadError `json:"errors,omitempty"`
 }
 
 // DriftSummary provides aggregate statistics
 type DriftSummary struct {
-	TotalProjects int                    `json:"total_projects"`
-	TotalDrift    int                    `json:"total_drift"`
-	ByStatus      map[DriftStatus]int    `json:"by_status"`
-	ByType        map[ResourceType]int   `json:"by_type"`
+	TotalProjects int                  `json:"total_projects"`
+	TotalDrift    int                  `json:"total_drift"`
+	ByStatus      map[DriftStatus]int  `json:"by_status"`
+	ByType        map[ResourceType]int `json:"by_type"`
+	Ignored       int                  `json:"ignored"` // Drift items suppressed by ignore rules
+	// BaselineRunID is set when the report only holds drift new since a saved run
+	BaselineRunID int64 `json:"baseline_run_id,omitempty"`
+	Resolved      int   `json:"resolved,omitempty"` // Baseline drift items no longer present
+	// PlanFile is set when the report predicts drift after applying each project's plan
+	PlanFile       string `json:"plan_file,omitempty"`
+	PlannedChanges int    `json:"planned_changes,omitempty"` // Planned changes to drifted resources
 }
 
 // NewDriftReport creates a new empty DriftReport
@@ -88,6 +177,10 @@ func (r *DriftReport) AddProject(project ProjectDrift) {
 	r.Projects = append(r.Projects, project)
 	r.Summary.TotalProjects++
 	r.Summary.TotalDrift += len(project.Drifts)
+	r.Summary.Ignored += project.Ignored
+	r.Summary.Resolved += len(project.Resolved)
+	r.Summary.PlannedChanges += len(project.PlannedChanges)
+	r.Errors = append(r.Errors, project.Errors...)
 
 	for _, drift := range project.Drifts {
 		r.Summary.ByStatus[drift.Status]++
@@ -95,6 +188,31 @@ func (r *DriftReport) AddProject(project ProjectDrift) {
 	}
 }
 
+// AddError records a project that could not be loaded
+func (r *DriftReport) AddError(project string, err error) {
+	r.Errors = append(r.Errors, LoadError{Project: project, Error: err.Error()})
+}
+
+// Exit codes of a drift check
+const (
+	ExitNoDrift = 0
+	ExitDrift   = 1
+	// ExitLoadErrors is used when a project or file could not be loaded, with
+	// or without drift elsewhere
+	ExitLoadErrors = 2
+)
+
+// ExitCode returns the exit code of a drift check producing the report
+func (r *DriftReport) ExitCode() int {
+	switch {
+	case len(r.Errors) > 0:
+		return ExitLoadErrors
+	case r.HasDrift():
+		return ExitDrift
+	}
+	return ExitNoDrift
+}
+
 // HasDrift returns true if any drift was detected
 func (r *DriftReport) HasDrift() bool {
 	return r.Summary.TotalDrift > 0
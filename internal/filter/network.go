@@ -0,0 +1,140 @@
+package filter
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/marcdicarlo/osc/internal/secgrp"
+)
+
+// PortSpec describes a protocol and optional port range to match against security group rules
+type PortSpec struct {
+	// Protocol is the normalized protocol name, or "any"
+	Protocol string
+	// PortMin and PortMax bound the requested ports; both are 0 when no port was given
+	PortMin int
+	PortMax int
+}
+
+// ParsePortSpec parses a spec of the form <protocol>[/<port>[-<port>]],
+// for example "tcp/3306", "udp/5000-5100", "icmp" or "any/22"
+func ParsePortSpec(spec string) (*PortSpec, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty port spec")
+	}
+
+	protocol, ports, hasPorts := strings.Cut(spec, "/")
+	ps := &PortSpec{Protocol: secgrp.NormalizeProtocol(protocol)}
+	if !hasPorts {
+		return ps, nil
+	}
+
+	minStr, maxStr, isRange := strings.Cut(ports, "-")
+	if !isRange {
+		maxStr = minStr
+	}
+	min, err := parsePort(minStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port spec %q: %w", spec, err)
+	}
+	max, err := parsePort(maxStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port spec %q: %w", spec, err)
+	}
+	if min > max {
+		return nil, fmt.Errorf("invalid port spec %q: start port is greater than end port", spec)
+	}
+
+	ps.PortMin, ps.PortMax = min, max
+	return ps, nil
+}
+
+// parsePort parses a single TCP/UDP port number
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %q must be a number between 1 and 65535", s)
+	}
+	return port, nil
+}
+
+// MatchesRule reports whether a rule with the given protocol and port range
+// allows any of the ports in the spec. Nil ports on the rule mean all ports.
+func (ps *PortSpec) MatchesRule(protocol string, portMin, portMax *int) bool {
+	ruleProtocol := secgrp.NormalizeProtocol(protocol)
+	if ps.Protocol != "any" && ruleProtocol != "any" && ruleProtocol != ps.Protocol {
+		return false
+	}
+
+	if ps.PortMin == 0 && ps.PortMax == 0 {
+		return true
+	}
+	// Ports only apply to protocols with ports; for ICMP the fields hold type and code
+	if ruleProtocol != "any" && ruleProtocol != "tcp" && ruleProtocol != "udp" && ruleProtocol != "sctp" {
+		return false
+	}
+
+	ruleMin, ruleMax := 1, 65535
+	if portMin != nil {
+		ruleMin = *portMin
+	}
+	if portMax != nil {
+		ruleMax = *portMax
+	}
+	return ruleMin <= ps.PortMax && ruleMax >= ps.PortMin
+}
+
+// ParsePrefix parses a CIDR or a bare IP address (treated as a single-host prefix)
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR or IP address %q", s)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// CIDRContainsAddr reports whether addr lies inside prefix.
+// Unparseable addresses never match.
+func CIDRContainsAddr(prefix netip.Prefix, addr string) bool {
+	a, err := netip.ParseAddr(strings.TrimSpace(addr))
+	if err != nil {
+		return false
+	}
+	return prefix.Contains(a.Unmap())
+}
+
+// RuleAllowsFrom reports whether a rule with the given ethertype and remote IP prefix
+// admits traffic from at least one address inside from. An empty remote prefix
+// admits every address of the rule's ethertype.
+func RuleAllowsFrom(ethertype, remoteIPPrefix string, from netip.Prefix) bool {
+	if !ethertypeMatches(ethertype, from) {
+		return false
+	}
+	if strings.TrimSpace(remoteIPPrefix) == "" {
+		return true
+	}
+	remote, err := ParsePrefix(remoteIPPrefix)
+	if err != nil {
+		return false
+	}
+	return remote.Overlaps(from)
+}
+
+// ethertypeMatches reports whether the address family of prefix matches the rule ethertype
+func ethertypeMatches(ethertype string, prefix netip.Prefix) bool {
+	switch strings.ToLower(strings.TrimSpace(ethertype)) {
+	case "ipv4":
+		return prefix.Addr().Is4()
+	case "ipv6":
+		return prefix.Addr().Is6()
+	default:
+		return true
+	}
+}
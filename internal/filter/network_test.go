@@ -0,0 +1,112 @@
+package filter
+
+import (
+	"testing"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestParsePortSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    PortSpec
+		wantErr bool
+	}{
+		{"tcp/3306", PortSpec{Protocol: "tcp", PortMin: 3306, PortMax: 3306}, false},
+		{"udp/5000-5100", PortSpec{Protocol: "udp", PortMin: 5000, PortMax: 5100}, false},
+		{"icmp", PortSpec{Protocol: "icmp"}, false},
+		{"TCP/22", PortSpec{Protocol: "tcp", PortMin: 22, PortMax: 22}, false},
+		{"tcp/0", PortSpec{}, true},
+		{"tcp/90-80", PortSpec{}, true},
+		{"tcp/http", PortSpec{}, true},
+		{"", PortSpec{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParsePortSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePortSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && *got != tt.want {
+				t.Errorf("ParsePortSpec() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPortSpecMatchesRule(t *testing.T) {
+	mysql, _ := ParsePortSpec("tcp/3306")
+
+	tests := []struct {
+		name     string
+		protocol string
+		min, max *int
+		want     bool
+	}{
+		{"exact port", "tcp", intPtr(3306), intPtr(3306), true},
+		{"inside range", "tcp", intPtr(3000), intPtr(4000), true},
+		{"outside range", "tcp", intPtr(22), intPtr(22), false},
+		{"any ports", "tcp", nil, nil, true},
+		{"any protocol", "", nil, nil, true},
+		{"protocol number", "6", intPtr(3306), intPtr(3306), true},
+		{"other protocol", "udp", intPtr(3306), intPtr(3306), false},
+		{"icmp ignores ports", "icmp", nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mysql.MatchesRule(tt.protocol, tt.min, tt.max); got != tt.want {
+				t.Errorf("MatchesRule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleAllowsFrom(t *testing.T) {
+	from, err := ParsePrefix("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("ParsePrefix() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		ethertype string
+		remote    string
+		want      bool
+	}{
+		{"everything", "IPv4", "0.0.0.0/0", true},
+		{"no prefix", "IPv4", "", true},
+		{"subnet inside", "IPv4", "10.20.0.0/16", true},
+		{"single host inside", "IPv4", "10.1.2.3", true},
+		{"disjoint", "IPv4", "192.168.0.0/16", false},
+		{"wrong family", "IPv6", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RuleAllowsFrom(tt.ethertype, tt.remote, from); got != tt.want {
+				t.Errorf("RuleAllowsFrom() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCIDRContainsAddr(t *testing.T) {
+	prefix, err := ParsePrefix("192.168.2.0/24")
+	if err != nil {
+		t.Fatalf("ParsePrefix() error = %v", err)
+	}
+
+	if !CIDRContainsAddr(prefix, "192.168.2.103") {
+		t.Error("Expected 192.168.2.103 to be inside 192.168.2.0/24")
+	}
+	if CIDRContainsAddr(prefix, "192.168.3.1") {
+		t.Error("Expected 192.168.3.1 to be outside 192.168.2.0/24")
+	}
+	if CIDRContainsAddr(prefix, "") {
+		t.Error("Expected empty address not to match")
+	}
+}
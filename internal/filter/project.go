@@ -60,6 +60,17 @@ func (pf *ProjectFilter) shouldIncludeProject(projectName string) bool {
 	return true
 }
 
+// Excludes reports whether scope or filter settings can leave out any project
+func (pf *ProjectFilter) Excludes() bool {
+	return (pf.Config.ProjectScope != "" && pf.Config.ProjectScope != "all") ||
+		pf.Config.ProjectFilter != "" || pf.FlagFilter != ""
+}
+
+// Includes reports whether a project name passes the scope and filter settings
+func (pf *ProjectFilter) Includes(projectName string) bool {
+	return pf.shouldIncludeProject(projectName)
+}
+
 // MatchProjects filters a slice of project data based on scope and filter settings
 // Returns the filtered data and a map of matched project names
 func (pf *ProjectFilter) MatchProjects(data [][]string, projectNameIndex int) ([][]string, map[string]bool) {
@@ -80,6 +91,24 @@ func (pf *ProjectFilter) MatchProjects(data [][]string, projectNameIndex int) ([
 	return filteredData, matchedProjects
 }
 
+// MatchItems filters items on the project name returned by projectName, e.g.
+// the project_name field of output records.
+// Returns the filtered items and a map of matched project names.
+func MatchItems[T any](pf *ProjectFilter, items []T, projectName func(T) string) ([]T, map[string]bool) {
+	matchedProjects := make(map[string]bool)
+	var filtered []T
+
+	for _, item := range items {
+		pname := projectName(item)
+		if pf.shouldIncludeProject(pname) {
+			matchedProjects[pname] = true
+			filtered = append(filtered, item)
+		}
+	}
+
+	return filtered, matchedProjects
+}
+
 // FormatMatchedProjects returns a formatted string describing which projects were matched
 func (pf *ProjectFilter) FormatMatchedProjects(matchedProjects map[string]bool, resourceType string) string {
 	if len(matchedProjects) == 0 {
@@ -0,0 +1,27 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marcdicarlo/osc/internal/config"
+)
+
+func TestMatchItems(t *testing.T) {
+	type server struct{ name, project string }
+	servers := []server{{"web-1", "prod"}, {"web-2", "preprod"}, {"db-1", "dev"}}
+
+	pf := New("prod", &config.Config{ProjectFilter: "pre"})
+	got, matched := MatchItems(pf, servers, func(s server) string { return s.project })
+
+	if len(got) != 1 || got[0].name != "web-1" {
+		t.Errorf("MatchItems() = %v, want only web-1", got)
+	}
+	var names []string
+	for name := range matched {
+		names = append(names, name)
+	}
+	if strings.Join(names, ",") != "prod" {
+		t.Errorf("matched projects = %v, want [prod]", names)
+	}
+}
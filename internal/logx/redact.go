@@ -28,9 +28,14 @@ func RedactSensitive(in string) string {
 	return out
 }
 
-// RedactHeaderValue redacts values for known-sensitive headers.
+// RedactHeaderValue redacts values for known-sensitive headers and headers whose
+// name suggests a credential, such as PRIVATE-TOKEN or X-Api-Key.
 func RedactHeaderValue(key, value string) string {
-	if _, ok := sensitiveHeaderKeys[strings.ToLower(strings.TrimSpace(key))]; ok {
+	lk := strings.ToLower(strings.TrimSpace(key))
+	if _, ok := sensitiveHeaderKeys[lk]; ok {
+		return "[REDACTED]"
+	}
+	if strings.Contains(lk, "token") || strings.Contains(lk, "secret") || strings.Contains(lk, "password") || strings.Contains(lk, "auth") || strings.Contains(lk, "key") {
 		return "[REDACTED]"
 	}
 	return RedactSensitive(value)
@@ -50,7 +55,8 @@ func RedactURL(raw string) string {
 		}
 	}
 	u.RawQuery = query.Encode()
-	return u.String()
+	// Redacted hides a password in the userinfo
+	return u.Redacted()
 }
 
 // IsTextLikeContentType reports whether safe small previews are likely text.
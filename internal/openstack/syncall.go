@@ -7,7 +7,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -393,7 +395,7 @@ func SyncAll(sqlDB *sql.DB, cfg *config.Config) error {
 	defer stmtPrj.Close()
 
 	stmtSrv, err := tx.PrepareContext(ctx,
-		"INSERT INTO "+cfg.Tables.Servers+"(server_id, server_name, project_id, ipv4_addr, status, image_id, image_name, flavor_id, flavor_name, metadata) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		"INSERT INTO "+cfg.Tables.Servers+"(server_id, server_name, project_id, ipv4_addr, status, image_id, image_name, flavor_id, flavor_name, metadata, floating_ip) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		prepareStep.DoneWithError(err, "phase", "prepare_statements", "statement", "servers")
 		return phaseError("prepare_servers_statement", err)
@@ -517,7 +519,7 @@ func SyncAll(sqlDB *sql.DB, cfg *config.Config) error {
 		}
 
 		if _, err := stmtSrv.ExecContext(ctx, s.ID, s.Name, s.TenantID, ipv4Addr,
-			s.Status, imageID, imageName, flavorID, flavorName, metadataJSON); err != nil {
+			s.Status, imageID, imageName, flavorID, flavorName, metadataJSON, floatingIPv4(s.Addresses)); err != nil {
 			insertServersStep.DoneWithError(err, "phase", "insert_servers", "server_id", s.ID, "index", i)
 			return phaseError("insert_server", fmt.Errorf("server=%s id=%s index=%d: %w", s.Name, s.ID, i, err))
 		}
@@ -635,6 +637,32 @@ func SyncAll(sqlDB *sql.DB, cfg *config.Config) error {
 	return nil
 }
 
+// floatingIPv4 returns the first floating IPv4 address from a server's addresses,
+// if any. Networks are read in name order so the same address is picked on
+// every sync of a server with floating IPs on several networks.
+func floatingIPv4(addresses map[string]interface{}) string {
+	for _, name := range slices.Sorted(maps.Keys(addresses)) {
+		addrList, ok := addresses[name].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, addr := range addrList {
+			address, ok := addr.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ipType, _ := address["OS-EXT-IPS:type"].(string)
+			version, _ := address["version"].(float64)
+			if ipType == "floating" && version == 4 {
+				if ip, ok := address["addr"].(string); ok {
+					return ip
+				}
+			}
+		}
+	}
+	return ""
+}
+
 // findProjectByName looks up a project by name using partial matching (case-insensitive)
 // Returns error if no match or multiple matches found
 func findProjectByName(identityClient *gophercloud.ServiceClient, searchTerm string) (*projects.Project, error) {
@@ -877,7 +905,7 @@ func SyncProject(sqlDB *sql.DB, cfg *config.Config, projectName string) error {
 	defer stmtPrj.Close()
 
 	stmtSrv, err := tx.PrepareContext(ctx,
-		"INSERT INTO "+cfg.Tables.Servers+"(server_id, server_name, project_id, ipv4_addr, status, image_id, image_name, flavor_id, flavor_name, metadata) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		"INSERT INTO "+cfg.Tables.Servers+"(server_id, server_name, project_id, ipv4_addr, status, image_id, image_name, flavor_id, flavor_name, metadata, floating_ip) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		prepareStep.DoneWithError(err, "phase", "prepare_statements", "statement", "servers")
 		return phaseError("prepare_servers_statement", err)
@@ -989,7 +1017,7 @@ func SyncProject(sqlDB *sql.DB, cfg *config.Config, projectName string) error {
 			}
 		}
 
-		if _, err := stmtSrv.ExecContext(ctx, s.ID, s.Name, s.TenantID, ipv4Addr, s.Status, imageID, imageName, flavorID, flavorName, metadataJSON); err != nil {
+		if _, err := stmtSrv.ExecContext(ctx, s.ID, s.Name, s.TenantID, ipv4Addr, s.Status, imageID, imageName, flavorID, flavorName, metadataJSON, floatingIPv4(s.Addresses)); err != nil {
 			insertServersStep.DoneWithError(err, "phase", "insert_servers", "server_id", s.ID, "index", i)
 			return phaseError("insert_server", fmt.Errorf("server=%s id=%s index=%d: %w", s.Name, s.ID, i, err))
 		}
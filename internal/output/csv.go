@@ -23,17 +23,16 @@ func (f *CSVFormatter) Format(data *OutputData) error {
 	writer := csv.NewWriter(f.Writer)
 	defer writer.Flush()
 
-	// Handle empty filter results - write headers only
-	if data.HasFiltering && data.FilteredProjectCount == 0 {
+	// Write headers
+	if !data.NoHeaders {
 		if err := writer.Write(data.Headers); err != nil {
 			return fmt.Errorf("error writing headers: %v", err)
 		}
-		return nil
 	}
 
-	// Write headers
-	if err := writer.Write(data.Headers); err != nil {
-		return fmt.Errorf("error writing headers: %v", err)
+	// Handle empty filter results - no rows after the headers
+	if data.NoMatchingProjects() {
+		return nil
 	}
 
 	// Write data rows
@@ -46,6 +45,17 @@ func (f *CSVFormatter) Format(data *OutputData) error {
 	return nil
 }
 
+// FormatDocument writes the document's rows, or uses its CSV renderer
+func (f *CSVFormatter) FormatDocument(doc *Document) error {
+	if render, ok := doc.Renderers[FormatCSV]; ok {
+		return render(f.Writer)
+	}
+	if doc.Data == nil {
+		return fmt.Errorf("document has no CSV form")
+	}
+	return f.Format(doc.Data)
+}
+
 // FormatSecurityGroupRules formats security group rules in CSV format
 func (f *CSVFormatter) FormatSecurityGroupRules(groupName, groupID string, rules [][]string) error {
 	writer := csv.NewWriter(f.Writer)
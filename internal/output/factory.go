@@ -10,11 +10,33 @@ import (
 type Format string
 
 const (
-	FormatTable Format = "table"
-	FormatJSON  Format = "json"
-	FormatCSV   Format = "csv"
+	FormatTable    Format = "table"
+	FormatJSON     Format = "json"
+	FormatCSV      Format = "csv"
+	FormatYAML     Format = "yaml"
+	FormatNDJSON   Format = "ndjson"
+	FormatTemplate Format = "template"
+	FormatJSONPath Format = "jsonpath"
+	FormatName     Format = "name"
+	FormatID       Format = "id"
 )
 
+func init() {
+	Register(FormatTable, "Output in human-readable table format (default)", func(w io.Writer) Formatter { return NewTableFormatter(w) })
+	Register(FormatJSON, "Output in JSON format with metadata", func(w io.Writer) Formatter { return NewJSONFormatter(w) })
+	Register(FormatCSV, "Output in CSV format with headers", func(w io.Writer) Formatter { return NewCSVFormatter(w) })
+	Register(FormatYAML, "Output in YAML format, with the structure of JSON output", func(w io.Writer) Formatter { return NewYAMLFormatter(w) })
+	Register(FormatNDJSON, "Output one JSON object per line, written as rows are read", func(w io.Writer) Formatter { return NewNDJSONFormatter(w) })
+	RegisterWithArgument(FormatTemplate, "<go-template>", "Output each resource through a Go template, e.g. template='{{.name}} {{.ip_address}}'", func(w io.Writer, arg string) (Formatter, error) {
+		return NewTemplateFormatter(w, arg)
+	})
+	RegisterWithArgument(FormatJSONPath, "<expression>", "Output the JSON output filtered by a JSONPath expression, e.g. jsonpath='{.data[*].id}'", func(w io.Writer, arg string) (Formatter, error) {
+		return NewJSONPathFormatter(w, arg)
+	})
+	Register(FormatName, "Output resource names, one per line", func(w io.Writer) Formatter { return NewFieldFormatter(w, FormatName) })
+	Register(FormatID, "Output resource IDs, one per line", func(w io.Writer) Formatter { return NewFieldFormatter(w, FormatID) })
+}
+
 // ErrInvalidFormat is returned when an unsupported format is specified
 type ErrInvalidFormat struct {
 	Format string
@@ -31,49 +53,37 @@ func (e *ErrInvalidFormat) Error() string {
 
 // GetValidFormats returns a list of supported format strings
 func GetValidFormats() []string {
-	return []string{
-		string(FormatTable),
-		string(FormatJSON),
-		string(FormatCSV),
+	formats := make([]string, 0, len(registry))
+	for _, r := range registry {
+		formats = append(formats, string(r.format))
 	}
+	return formats
 }
 
 // NewFormatter creates a new formatter based on the specified format
 func NewFormatter(format string, w io.Writer) (Formatter, error) {
-	if !ValidateFormat(format) {
+	r, arg, ok := lookup(format)
+	if !ok {
 		return nil, &ErrInvalidFormat{
 			Format: format,
 			Valid:  GetValidFormats(),
 		}
 	}
-
-	switch Format(format) {
-	case FormatTable:
-		return NewTableFormatter(w), nil
-	case FormatJSON:
-		return NewJSONFormatter(w), nil
-	case FormatCSV:
-		return NewCSVFormatter(w), nil
-	default:
-		// This should never happen due to ValidateFormat check
-		return nil, fmt.Errorf("internal error: unhandled format %q", format)
-	}
+	return r.newFunc(w, arg)
 }
 
-// ValidateFormat checks if the given format is supported
+// ValidateFormat checks if the given format is supported, including the
+// argument of formats that take one
 func ValidateFormat(format string) bool {
-	switch Format(format) {
-	case FormatTable, FormatJSON, FormatCSV:
-		return true
-	default:
-		return false
-	}
+	_, err := NewFormatter(format, io.Discard)
+	return err == nil
 }
 
 // FormatHelp returns a help string describing the available formats
 func FormatHelp() string {
-	return `Available output formats:
-  table    Output in human-readable table format (default)
-  json     Output in JSON format with metadata
-  csv      Output in CSV format with headers`
+	lines := []string{"Available output formats:"}
+	for _, r := range registry {
+		lines = append(lines, formatHelpLine(r))
+	}
+	return strings.Join(lines, "\n")
 }
@@ -8,6 +8,15 @@ import (
 type OutputData struct {
 	Headers []string
 	Rows    [][]string
+	// Columns and Records hold typed records when the data was created with
+	// NewRecordData; Headers and Rows are then the records flattened
+	Columns []Column
+	Records []Record
+	// NoHeaders omits the header row and filter summary of table and CSV output
+	NoHeaders bool
+	// NameKey and IDKey are the record fields written by -o name and -o id
+	// (default: "name" and "id")
+	NameKey, IDKey string
 	// Optional metadata for special cases like filtering results
 	FilteredProjectCount int
 	MatchedProjects      []string
@@ -18,6 +27,18 @@ type OutputData struct {
 type Formatter interface {
 	// Format writes the formatted output to the writer
 	Format(data *OutputData) error
+	// FormatDocument writes a document that is not a single list of rows
+	FormatDocument(doc *Document) error
+}
+
+// RecordStreamer is implemented by formatters that write typed records one at
+// a time (ndjson), so that commands can write records as they read them instead
+// of collecting them first
+type RecordStreamer interface {
+	// WriteRecord writes one record
+	WriteRecord(r Record) error
+	// Flush writes any buffered output; call it after the last record
+	Flush() error
 }
 
 // BaseFormatter provides common functionality for formatters
@@ -33,6 +54,12 @@ func NewOutputData(headers []string, rows [][]string) *OutputData {
 	}
 }
 
+// NoMatchingProjects reports whether a project filter matched no projects.
+// Formats write no rows for such results, only their empty form.
+func (d *OutputData) NoMatchingProjects() bool {
+	return d.HasFiltering && d.FilteredProjectCount == 0
+}
+
 // WithFilterInfo adds filtering information to the output data
 func (d *OutputData) WithFilterInfo(matchedProjects []string) *OutputData {
 	d.HasFiltering = true
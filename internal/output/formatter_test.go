@@ -3,6 +3,8 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
 )
@@ -34,67 +36,67 @@ func TestJSONFormatter(t *testing.T) {
 	var buf bytes.Buffer
 	f := NewJSONFormatter(&buf)
 
-	data := &OutputData{
-		Headers: []string{"Name", "ID", "Project ID", "Project Name", "Resource Type", "Direction", "Protocol", "Port Range", "Remote IP"},
-		Rows: [][]string{
-			{"default", "sg-123", "proj-123", "prod-app1", "security-group", "", "", "", ""},
-			{"rule-123", "sg-123", "proj-123", "prod-app1", "security-group-rule", "ingress", "tcp", "22", "0.0.0.0/0"},
-		},
-		HasFiltering:         true,
-		FilteredProjectCount: 1,
-		MatchedProjects:      []string{"prod-app1"},
-	}
+	minPort, maxPort := 22, 23
+	data := NewRecordData([]Column{
+		{Header: "Name", Key: "name"},
+		{Header: "Resource Type", Key: "type", Fields: []string{}, Format: func(r Record) string { return r.Type }},
+		{Header: "Port Range", Key: "port_range", Fields: []string{"port_range_min", "port_range_max"},
+			Format: func(r Record) string { return r.String("port_range_min") + "-" + r.String("port_range_max") }},
+		{Header: "Port Max", Key: "port_range_max"},
+	}, []Record{
+		NewRecord("security-group", Field{Key: "name", Value: "default"}),
+		NewRecord("security-group-rule",
+			Field{Key: "name", Value: "rule-123"},
+			Field{Key: "port_range_min", Value: &minPort},
+			Field{Key: "port_range_max", Value: &maxPort},
+		),
+	}).WithFilterInfo([]string{"prod-app1"})
 
 	if err := f.Format(data); err != nil {
-		t.Errorf("JSONFormatter.Format() error = %v", err)
+		t.Fatalf("JSONFormatter.Format() error = %v", err)
 	}
 
-	var output JSONOutput
+	var output struct {
+		Metadata *JSONMetadata    `json:"metadata"`
+		Headers  []string         `json:"headers"`
+		Data     []map[string]any `json:"data"`
+	}
 	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
-		t.Errorf("Failed to parse JSON output: %v", err)
+		t.Fatalf("Failed to parse JSON output: %v", err)
 	}
 
-	// Verify metadata
-	if output.Metadata == nil {
-		t.Error("Expected metadata in JSON output")
-	} else if output.Metadata.Filtering.FilteredProjectCount != 1 {
-		t.Errorf("Expected FilteredProjectCount = 1, got %d", output.Metadata.Filtering.FilteredProjectCount)
+	if output.Metadata == nil || output.Metadata.Filtering.FilteredProjectCount != 1 {
+		t.Errorf("Expected filtering metadata for 1 project, got %+v", output.Metadata)
+	}
+	// Headers are the fields the columns read: none for the type, both
+	// fields of the port range and each field once
+	if got := strings.Join(output.Headers, ","); got != "name,port_range_min,port_range_max" {
+		t.Errorf("Expected the record fields as headers, got %s", got)
 	}
+	if len(output.Data) != 2 || output.Data[0]["type"] != "security-group" || output.Data[1]["port_range_min"] != float64(22) {
+		t.Errorf("Unexpected records %v", output.Data)
+	}
+}
 
-	// Verify data structure
-	if len(output.Data) != 2 {
-		t.Errorf("Expected 2 rows, got %d", len(output.Data))
+func TestJSONFormatterRows(t *testing.T) {
+	var buf bytes.Buffer
+	data := NewOutputData([]string{"Server Name", "Public IP"}, [][]string{{"web-1", "203.0.113.10"}})
+	if err := NewJSONFormatter(&buf).Format(data); err != nil {
+		t.Fatalf("JSONFormatter.Format() error = %v", err)
 	}
 
-	// Check security group
-	sg := output.Data[0]
-	if sg.Type != "security-group" {
-		t.Errorf("Expected type security-group, got %s", sg.Type)
+	var output struct {
+		Headers []string            `json:"headers"`
+		Data    []map[string]string `json:"data"`
 	}
-	if sg.RuleFields != nil {
-		t.Error("Security group should not have rule fields")
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
 	}
-
-	// Check security group rule
-	rule := output.Data[1]
-	if rule.Type != "security-group-rule" {
-		t.Errorf("Expected type security-group-rule, got %s", rule.Type)
+	if strings.Join(output.Headers, ",") != "server_name,public_ip" {
+		t.Errorf("Expected headers as record keys, got %v", output.Headers)
 	}
-	if rule.RuleFields == nil {
-		t.Error("Security group rule should have rule fields")
-	} else {
-		if rule.RuleFields.Direction != "ingress" {
-			t.Errorf("Expected direction ingress, got %s", rule.RuleFields.Direction)
-		}
-		if rule.RuleFields.Protocol != "tcp" {
-			t.Errorf("Expected protocol tcp, got %s", rule.RuleFields.Protocol)
-		}
-		if rule.RuleFields.PortRange != "22" {
-			t.Errorf("Expected port range 22, got %s", rule.RuleFields.PortRange)
-		}
-		if rule.RuleFields.RemoteIP != "0.0.0.0/0" {
-			t.Errorf("Expected remote IP 0.0.0.0/0, got %s", rule.RuleFields.RemoteIP)
-		}
+	if len(output.Data) != 1 || output.Data[0]["server_name"] != "web-1" || output.Data[0]["public_ip"] != "203.0.113.10" {
+		t.Errorf("Expected a record keyed by header, got %v", output.Data)
 	}
 }
 
@@ -165,3 +167,289 @@ func TestFormatterFactory(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordData(t *testing.T) {
+	port := 22
+	records := []Record{
+		NewRecord("server",
+			Field{Key: "name", Value: "web-1"},
+			Field{Key: "port", Value: &port},
+			Field{Key: "public", Value: true},
+			Field{Key: "security_groups", Value: []Record{
+				{Fields: []Field{{Key: "id", Value: "sg-1"}, {Key: "name", Value: "default"}}},
+				{Fields: []Field{{Key: "id", Value: "sg-2"}, {Key: "name", Value: "web<ssh>"}}},
+			}},
+		),
+	}
+	columns := []Column{
+		{Header: "Name", Key: "name"},
+		{Header: "Port", Key: "port"},
+		{Header: "Public", Key: "public"},
+		{Header: "Security Groups", Key: "security_groups"},
+	}
+	data := NewRecordData(columns, records)
+
+	want := []string{"web-1", "22", "true", "default, web<ssh>"}
+	if strings.Join(data.Rows[0], "|") != strings.Join(want, "|") {
+		t.Errorf("Expected flattened row %v, got %v", want, data.Rows[0])
+	}
+
+	var buf bytes.Buffer
+	if err := NewJSONFormatter(&buf).Format(data); err != nil {
+		t.Fatalf("JSONFormatter.Format() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"type": "server",
+      "name": "web-1",
+      "port": 22,
+      "public": true,`) {
+		t.Errorf("Expected typed fields in record order, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"name": "web<ssh>"`) {
+		t.Errorf("Expected nested records without HTML escaping, got:\n%s", buf.String())
+	}
+
+	var output struct {
+		Headers []string         `json:"headers"`
+		Data    []map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if strings.Join(output.Headers, ",") != "name,port,public,security_groups" {
+		t.Errorf("Expected record keys as headers, got %v", output.Headers)
+	}
+	groups, ok := output.Data[0]["security_groups"].([]any)
+	if !ok || len(groups) != 2 {
+		t.Fatalf("Expected security groups as an array of 2, got %v", output.Data[0]["security_groups"])
+	}
+}
+
+func TestSelectAndNoHeaders(t *testing.T) {
+	record := NewRecord("server",
+		Field{Key: "name", Value: "web-1"},
+		Field{Key: "id", Value: "srv-1"},
+		Field{Key: "status", Value: "ACTIVE"},
+	)
+	selected := record.Select([]string{"status", "missing", "name"})
+	if selected.Type != "server" || len(selected.Fields) != 2 ||
+		selected.Fields[0].Key != "status" || selected.Fields[1].Key != "name" {
+		t.Fatalf("Expected type and fields status,name, got %+v", selected)
+	}
+
+	data := NewRecordData([]Column{{Header: "Status", Key: "status"}, {Header: "Name", Key: "name"}}, []Record{selected})
+	data.NoHeaders = true
+	data.WithFilterInfo([]string{"prod"})
+
+	var buf bytes.Buffer
+	if err := NewCSVFormatter(&buf).Format(data); err != nil {
+		t.Fatalf("CSVFormatter.Format() error = %v", err)
+	}
+	if buf.String() != "ACTIVE,web-1\n" {
+		t.Errorf("Expected only the data row, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := NewTableFormatter(&buf).Format(data); err != nil {
+		t.Fatalf("TableFormatter.Format() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "STATUS") || strings.Contains(buf.String(), "prod") || !strings.Contains(buf.String(), "web-1") {
+		t.Errorf("Expected a table without headers or filter summary, got:\n%s", buf.String())
+	}
+}
+
+func TestTemplateFormatter(t *testing.T) {
+	data := NewRecordData([]Column{{Header: "Name", Key: "name"}}, []Record{
+		NewRecord("server",
+			Field{Key: "name", Value: "web-1"},
+			Field{Key: "id", Value: "srv-1"},
+			Field{Key: "ip_address", Value: "10.0.0.5"},
+			Field{Key: "security_groups", Value: []string{"default", "web"}},
+		),
+		NewRecord("server",
+			Field{Key: "name", Value: "db-1"},
+			Field{Key: "id", Value: "srv-2"},
+			Field{Key: "ip_address", Value: ""},
+			Field{Key: "security_groups", Value: []string{}},
+		),
+	})
+
+	var buf bytes.Buffer
+	f, err := NewFormatter(`template={{.name}} {{.ip_address | default "none"}} {{join "," .security_groups}} {{cidrContains "10.0.0.0/8" .ip_address}}`, &buf)
+	if err != nil {
+		t.Fatalf("NewFormatter(template) error = %v", err)
+	}
+	if err := f.Format(data); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	want := "web-1 10.0.0.5 default,web true\ndb-1 none  false\n"
+	if buf.String() != want {
+		t.Errorf("Template output = %q, want %q", buf.String(), want)
+	}
+
+	// Records the template writes nothing for get no line
+	buf.Reset()
+	f, _ = NewFormatter(`template={{if cidrContains "10.0.0.0/8" .ip_address}}{{.name}}{{end}}`, &buf)
+	if err := f.Format(data); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if buf.String() != "web-1\n" {
+		t.Errorf("Filtering template output = %q, want %q", buf.String(), "web-1\n")
+	}
+
+	buf.Reset()
+	f, _ = NewFormatter("id", &buf)
+	if err := f.Format(data); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if buf.String() != "srv-1\nsrv-2\n" {
+		t.Errorf("ID output = %q", buf.String())
+	}
+
+	buf.Reset()
+	doc := &Document{Value: []map[string]string{{"server": "web-1"}}, NameKey: "server"}
+	if err := FormatDocument("name", &buf, doc); err != nil {
+		t.Fatalf("FormatDocument(name) error = %v", err)
+	}
+	if buf.String() != "web-1\n" {
+		t.Errorf("Document name output = %q", buf.String())
+	}
+
+	for _, format := range []string{"template", "template={{.name", "json=x", "jsonpath="} {
+		if ValidateFormat(format) {
+			t.Errorf("ValidateFormat(%q) should fail", format)
+		}
+	}
+}
+
+func TestFormatDocumentEscapeHTML(t *testing.T) {
+	value := map[string]string{"description": "<a&b>"}
+	tests := []struct {
+		escape bool
+		want   string
+	}{
+		{false, `"<a&b>"`},
+		{true, `"\u003ca\u0026b\u003e"`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := FormatDocument("json", &buf, &Document{Value: value, EscapeHTML: tt.escape}); err != nil {
+			t.Fatalf("FormatDocument(json) error = %v", err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("EscapeHTML=%v: output %q does not contain %s", tt.escape, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestYAMLAndNDJSONFormatters(t *testing.T) {
+	port := 22
+	data := NewRecordData([]Column{{Header: "Name", Key: "name"}}, []Record{
+		NewRecord("security-group-rule", Field{Key: "name", Value: "ssh"}, Field{Key: "port_range_min", Value: &port}),
+		NewRecord("security-group-rule", Field{Key: "name", Value: "any"}, Field{Key: "port_range_min", Value: nil}),
+	})
+	data.WithFilterInfo([]string{"prod"})
+
+	var buf bytes.Buffer
+	f, err := NewFormatter("yaml", &buf)
+	if err != nil {
+		t.Fatalf("NewFormatter(yaml) error = %v", err)
+	}
+	if err := f.Format(data); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	want := `metadata:
+  filtering:
+    filtered_project_count: 1
+    matched_projects:
+    - prod
+headers:
+- name
+data:
+- type: security-group-rule
+  name: ssh
+  port_range_min: 22
+- type: security-group-rule
+  name: any
+  port_range_min: null
+`
+	if buf.String() != want {
+		t.Errorf("YAML output = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	f, _ = NewFormatter("ndjson", &buf)
+	stream, ok := f.(RecordStreamer)
+	if !ok {
+		t.Fatal("NDJSON formatter should stream records")
+	}
+	for _, r := range data.Records {
+		if err := stream.WriteRecord(r); err != nil {
+			t.Fatalf("WriteRecord() error = %v", err)
+		}
+	}
+	if err := stream.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	want = `{"type":"security-group-rule","name":"ssh","port_range_min":22}
+{"type":"security-group-rule","name":"any","port_range_min":null}
+`
+	if buf.String() != want {
+		t.Errorf("NDJSON output = %q, want %q", buf.String(), want)
+	}
+}
+
+// upperFormatter is a test format that writes row values in upper case
+type upperFormatter struct {
+	BaseFormatter
+}
+
+func (f *upperFormatter) Format(data *OutputData) error {
+	for _, row := range data.Rows {
+		fmt.Fprintln(f.Writer, strings.ToUpper(strings.Join(row, " ")))
+	}
+	return nil
+}
+
+func (f *upperFormatter) FormatDocument(doc *Document) error {
+	return f.Format(doc.Data)
+}
+
+func TestRegisterFormat(t *testing.T) {
+	Register("upper", "Upper case rows", func(w io.Writer) Formatter { return &upperFormatter{BaseFormatter{Writer: w}} })
+	t.Cleanup(func() { registry = registry[:len(registry)-1] })
+
+	if !ValidateFormat("upper") || !strings.Contains(FormatHelp(), "upper    Upper case rows") {
+		t.Fatalf("Expected upper to be registered, got %v", GetValidFormats())
+	}
+
+	doc := &Document{
+		Value: map[string]string{"name": "web-1"},
+		Data:  NewOutputData([]string{"Name"}, [][]string{{"web-1"}}),
+		Renderers: map[Format]func(io.Writer) error{
+			FormatTable: func(w io.Writer) error { _, err := io.WriteString(w, "custom table\n"); return err },
+			"report":    func(w io.Writer) error { _, err := io.WriteString(w, "custom report\n"); return err },
+		},
+	}
+	tests := map[string]string{
+		"upper":  "WEB-1\n",
+		"table":  "custom table\n",
+		"report": "custom report\n",
+		"json":   "{\n  \"name\": \"web-1\"\n}\n",
+		"csv":    "Name\nweb-1\n",
+	}
+	for format, want := range tests {
+		var buf bytes.Buffer
+		if err := FormatDocument(format, &buf, doc); err != nil {
+			t.Errorf("FormatDocument(%s) error = %v", format, err)
+		}
+		if buf.String() != want {
+			t.Errorf("FormatDocument(%s) = %q, want %q", format, buf.String(), want)
+		}
+	}
+
+	err := FormatDocument("xml", io.Discard, doc)
+	want := `unsupported output format "xml" (valid formats: table, json, csv, yaml, ndjson, template, jsonpath, name, id, upper, report)`
+	if err == nil || err.Error() != want {
+		t.Errorf("FormatDocument(xml) error = %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,120 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// The template, jsonpath, name and id formats work on the JSON form of the
+// output, decoded into generic values: map[string]any objects, []any arrays,
+// json.Number numbers, strings, booleans and nil. Keys are therefore the same
+// as in -o json.
+
+// dataValue returns the JSON output of data as a generic value
+func dataValue(data *OutputData) (any, error) {
+	var buf bytes.Buffer
+	if err := NewJSONFormatter(&buf).Format(data); err != nil {
+		return nil, err
+	}
+	return decodeGeneric(buf.Bytes())
+}
+
+// documentValue returns the value of a document as a generic value
+func documentValue(doc *Document) (any, error) {
+	data, err := marshalNoEscape(doc.Value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeGeneric(data)
+}
+
+// documentItems returns the resources of a document as generic values: its
+// Items when set, otherwise the resources of its Value
+func documentItems(doc *Document) ([]any, error) {
+	if doc.Items == nil {
+		v, err := documentValue(doc)
+		if err != nil {
+			return nil, err
+		}
+		return resourceItems(v), nil
+	}
+	data, err := marshalNoEscape(doc.Items)
+	if err != nil {
+		return nil, err
+	}
+	v, err := decodeGeneric(data)
+	if err != nil {
+		return nil, err
+	}
+	return resourceItems(v), nil
+}
+
+// decodeGeneric decodes JSON into a generic value, keeping numbers exact
+func decodeGeneric(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// resourceItems returns the resources of a generic value: the data of list
+// output, the elements of an array, or the value itself
+func resourceItems(v any) []any {
+	switch value := v.(type) {
+	case []any:
+		return value
+	case map[string]any:
+		if data, ok := value["data"].([]any); ok {
+			return data
+		}
+	}
+	if v == nil {
+		return nil
+	}
+	return []any{v}
+}
+
+// genericText formats a generic value as text: strings and numbers as is,
+// nil as empty, objects by their name and arrays of them joined with ", "
+func genericText(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		if value {
+			return "true"
+		}
+		return "false"
+	case map[string]any:
+		if name, ok := value["name"].(string); ok {
+			return name
+		}
+	case []any:
+		texts := make([]string, len(value))
+		for i, item := range value {
+			texts[i] = genericText(item)
+		}
+		return strings.Join(texts, ", ")
+	}
+	data, _ := marshalNoEscape(v)
+	return string(data)
+}
+
+// genericJSON formats a generic value as text, writing objects and arrays as
+// compact JSON
+func genericJSON(v any) string {
+	switch v.(type) {
+	case map[string]any, []any:
+		data, _ := marshalNoEscape(v)
+		return string(data)
+	}
+	return genericText(v)
+}
@@ -0,0 +1,731 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPathFormatter implements the Formatter interface with a kubectl-style
+// JSONPath template evaluated on the JSON output, e.g. {.data[*].id}.
+//
+// The supported subset:
+//
+//   - fields: .name, or ['name'] and ["name"] for keys with dots, brackets or
+//     quotes; \. escapes a dot in a dotted name (.metadata\.owner) and \'
+//     a quote in a quoted key
+//   - $ for the root and @ for the current element
+//   - recursive descent: ..name or ..[0], over objects (in key order) and
+//     arrays alike
+//   - indexes [0] and [-1], slices [1:3] and [:-1], wildcards [*] and .*
+//   - unions of keys or indexes: ['name','id'] and [0,2]
+//   - filters: [?(@.protocol=="tcp")], [?(@.remote_group)] for an existing,
+//     non-null value, with ==, !=, <, <=, > and >= against a string, number,
+//     boolean or null literal, combined with && and || (&& binds tighter; no
+//     parentheses, and the right side cannot be a path)
+//   - {range <expression>}...{end}, which may be nested
+//   - quoted text: {"\n"} or {'text'}; braces inside quotes do not end an
+//     expression
+//
+// Missing fields produce no output; several results of one expression are
+// separated by spaces, and objects and arrays are written as compact JSON.
+type JSONPathFormatter struct {
+	BaseFormatter
+	nodes []jsonPathNode
+}
+
+// jsonPathNode is literal text, an expression or a range over an expression
+type jsonPathNode struct {
+	text  string
+	path  []jsonPathStep
+	isExp bool
+	// body is set for {range <expression>} and holds the nodes up to {end}
+	body []jsonPathNode
+}
+
+// jsonPathStep is one step of a JSONPath expression
+type jsonPathStep struct {
+	kind   jsonPathStepKind
+	key    string
+	index  int
+	start  *int
+	end    *int
+	filter *jsonPathFilter
+	// union holds the field and index steps of a [a,b] union
+	union []jsonPathStep
+}
+
+type jsonPathStepKind int
+
+const (
+	stepField jsonPathStepKind = iota
+	stepRecursive
+	stepIndex
+	stepSlice
+	stepWildcard
+	stepFilter
+	stepRoot
+	stepUnion
+)
+
+// jsonPathFilter is a [?(...)] filter: conditions joined by && in groups
+// joined by ||
+type jsonPathFilter struct {
+	anyOf [][]jsonPathCondition
+}
+
+// jsonPathCondition is a path relative to the element, optionally compared
+// with a literal
+type jsonPathCondition struct {
+	path  []jsonPathStep
+	op    string
+	value any
+}
+
+// NewJSONPathFormatter creates a new JSONPath formatter from the template text
+func NewJSONPathFormatter(w io.Writer, text string) (*JSONPathFormatter, error) {
+	if text == "" {
+		return nil, fmt.Errorf("jsonpath output needs an expression, e.g. -o jsonpath='{.data[*].id}' or --template-file")
+	}
+	nodes, err := parseJSONPathTemplate(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jsonpath expression: %w", err)
+	}
+	return &JSONPathFormatter{BaseFormatter: BaseFormatter{Writer: w}, nodes: nodes}, nil
+}
+
+// Format evaluates the expression on the JSON output of data
+func (f *JSONPathFormatter) Format(data *OutputData) error {
+	v, err := dataValue(data)
+	if err != nil {
+		return err
+	}
+	return f.write(v)
+}
+
+// FormatDocument evaluates the expression on the JSON value of the document
+func (f *JSONPathFormatter) FormatDocument(doc *Document) error {
+	v, err := documentValue(doc)
+	if err != nil {
+		return err
+	}
+	return f.write(v)
+}
+
+// write evaluates the template and ends non-empty output with a newline
+func (f *JSONPathFormatter) write(root any) error {
+	var buf bytes.Buffer
+	executeJSONPath(&buf, f.nodes, root, root)
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err := f.Writer.Write(buf.Bytes())
+	return err
+}
+
+// executeJSONPath writes the nodes evaluated against current
+func executeJSONPath(buf *bytes.Buffer, nodes []jsonPathNode, root, current any) {
+	for _, node := range nodes {
+		switch {
+		case node.body != nil:
+			for _, item := range evalJSONPath(node.path, root, current) {
+				executeJSONPath(buf, node.body, root, item)
+			}
+		case node.isExp:
+			results := evalJSONPath(node.path, root, current)
+			for i, r := range results {
+				if i > 0 {
+					buf.WriteByte(' ')
+				}
+				buf.WriteString(genericJSON(r))
+			}
+		default:
+			buf.WriteString(node.text)
+		}
+	}
+}
+
+// parseJSONPathTemplate splits template text into literal text, {expression},
+// {"quoted text"} and {range expression}...{end} nodes
+func parseJSONPathTemplate(text string) ([]jsonPathNode, error) {
+	var stack [][]jsonPathNode
+	var ranges []jsonPathNode
+	var nodes []jsonPathNode
+	for len(text) > 0 {
+		open := strings.IndexByte(text, '{')
+		if open < 0 {
+			nodes = append(nodes, jsonPathNode{text: text})
+			break
+		}
+		if open > 0 {
+			nodes = append(nodes, jsonPathNode{text: text[:open]})
+		}
+		end := closingBrace(text, open)
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed { in %q", text[open:])
+		}
+		expr := strings.TrimSpace(text[open+1 : end])
+		text = text[end+1:]
+
+		switch {
+		case expr == "end":
+			if len(ranges) == 0 {
+				return nil, fmt.Errorf("{end} without {range}")
+			}
+			r := ranges[len(ranges)-1]
+			ranges = ranges[:len(ranges)-1]
+			r.body = nodes
+			if r.body == nil {
+				r.body = []jsonPathNode{}
+			}
+			nodes = append(stack[len(stack)-1], r)
+			stack = stack[:len(stack)-1]
+		case strings.HasPrefix(expr, "range "):
+			path, err := parseJSONPath(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, err
+			}
+			ranges = append(ranges, jsonPathNode{path: path})
+			stack = append(stack, nodes)
+			nodes = nil
+		case strings.HasPrefix(expr, `"`) || strings.HasPrefix(expr, "'"):
+			literal, err := unquoteJSONPath(expr)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, jsonPathNode{text: literal})
+		default:
+			path, err := parseJSONPath(expr)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, jsonPathNode{path: path, isExp: true})
+		}
+	}
+	if len(ranges) > 0 {
+		return nil, fmt.Errorf("{range} without {end}")
+	}
+	return nodes, nil
+}
+
+// closingBrace returns the index of the } closing the { at open, skipping
+// quoted text
+func closingBrace(text string, open int) int {
+	var quote byte
+	for i := open + 1; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// unquoteJSONPath unquotes a "double" or 'single' quoted literal. Both take
+// Go escapes such as \n and \t; single quoted text also takes \'.
+func unquoteJSONPath(s string) (string, error) {
+	if strings.HasPrefix(s, "'") {
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated literal %s", s)
+		}
+		// Rewrite as a double quoted literal
+		var b strings.Builder
+		b.WriteByte('"')
+		inner := s[1 : len(s)-1]
+		for i := 0; i < len(inner); i++ {
+			switch c := inner[i]; {
+			case c == '\\' && i+1 < len(inner) && inner[i+1] == '\'':
+				b.WriteByte('\'')
+				i++
+			case c == '\\' && i+1 < len(inner):
+				b.WriteByte(c)
+				b.WriteByte(inner[i+1])
+				i++
+			case c == '"':
+				b.WriteString(`\"`)
+			default:
+				b.WriteByte(c)
+			}
+		}
+		b.WriteByte('"')
+		s = b.String()
+	}
+	literal, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid literal %s", s)
+	}
+	return literal, nil
+}
+
+// parseJSONPath parses an expression such as .data[*].id or $.metadata
+func parseJSONPath(expr string) ([]jsonPathStep, error) {
+	var steps []jsonPathStep
+	s := expr
+	switch {
+	case strings.HasPrefix(s, "$"):
+		steps = append(steps, jsonPathStep{kind: stepRoot})
+		s = s[1:]
+	case strings.HasPrefix(s, "@"):
+		s = s[1:]
+	case s != "" && s[0] != '.' && s[0] != '[':
+		// A leading field without a dot, e.g. {data[0].name}
+		s = "." + s
+	}
+
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			steps = append(steps, jsonPathStep{kind: stepRecursive})
+			s = s[2:]
+			if strings.HasPrefix(s, "[") {
+				continue
+			}
+			name, rest := jsonPathName(s)
+			if name == "" {
+				return nil, fmt.Errorf("missing field name after .. in %q", expr)
+			}
+			steps = append(steps, fieldStep(name))
+			s = rest
+		case strings.HasPrefix(s, "."):
+			name, rest := jsonPathName(s[1:])
+			if name == "" {
+				if rest == "" && len(steps) == 0 {
+					// {.} is the current value
+					return steps, nil
+				}
+				return nil, fmt.Errorf("missing field name in %q", expr)
+			}
+			steps = append(steps, fieldStep(name))
+			s = rest
+		case strings.HasPrefix(s, "["):
+			end := closingBracket(s)
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", expr)
+			}
+			step, err := parseJSONPathBracket(strings.TrimSpace(s[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("%w in %q", err, expr)
+			}
+			steps = append(steps, step)
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", s, expr)
+		}
+	}
+	return steps, nil
+}
+
+// fieldStep returns a field step, or a wildcard for *
+func fieldStep(name string) jsonPathStep {
+	if name == "*" {
+		return jsonPathStep{kind: stepWildcard}
+	}
+	return jsonPathStep{kind: stepField, key: name}
+}
+
+// jsonPathName splits a field name from the rest of an expression. A
+// backslash escapes the next character, e.g. a dot in metadata\.owner.
+func jsonPathName(s string) (string, string) {
+	var name strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			name.WriteByte(s[i])
+		case c == '.' || c == '[':
+			return name.String(), s[i:]
+		default:
+			name.WriteByte(c)
+		}
+	}
+	return name.String(), ""
+}
+
+// closingBracket returns the index of the ] closing the [ at the start of s,
+// skipping quoted text and nested brackets
+func closingBracket(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseJSONPathBracket parses the content of [...]
+func parseJSONPathBracket(content string) (jsonPathStep, error) {
+	if !strings.HasPrefix(content, "?(") {
+		if parts := splitOutsideQuotes(content, ","); len(parts) > 1 {
+			step := jsonPathStep{kind: stepUnion}
+			for _, part := range parts {
+				part = strings.TrimSpace(part)
+				member, err := parseJSONPathBracket(part)
+				if err != nil {
+					return jsonPathStep{}, err
+				}
+				if member.kind != stepField && member.kind != stepIndex {
+					return jsonPathStep{}, fmt.Errorf("union member [%s] must be a key or an index", part)
+				}
+				step.union = append(step.union, member)
+			}
+			return step, nil
+		}
+	}
+	switch {
+	case content == "*":
+		return jsonPathStep{kind: stepWildcard}, nil
+	case strings.HasPrefix(content, "'") || strings.HasPrefix(content, `"`):
+		key, err := unquoteJSONPath(content)
+		if err != nil {
+			return jsonPathStep{}, err
+		}
+		return jsonPathStep{kind: stepField, key: key}, nil
+	case strings.HasPrefix(content, "?(") && strings.HasSuffix(content, ")"):
+		filter, err := parseJSONPathFilter(strings.TrimSpace(content[2 : len(content)-1]))
+		if err != nil {
+			return jsonPathStep{}, err
+		}
+		return jsonPathStep{kind: stepFilter, filter: filter}, nil
+	case strings.Contains(content, ":"):
+		startText, endText, _ := strings.Cut(content, ":")
+		step := jsonPathStep{kind: stepSlice}
+		for _, bound := range []struct {
+			text string
+			dst  **int
+		}{{startText, &step.start}, {endText, &step.end}} {
+			if text := strings.TrimSpace(bound.text); text != "" {
+				n, err := strconv.Atoi(text)
+				if err != nil {
+					return jsonPathStep{}, fmt.Errorf("invalid slice [%s]", content)
+				}
+				*bound.dst = &n
+			}
+		}
+		return step, nil
+	default:
+		n, err := strconv.Atoi(content)
+		if err != nil {
+			return jsonPathStep{}, fmt.Errorf("invalid index [%s]", content)
+		}
+		return jsonPathStep{kind: stepIndex, index: n}, nil
+	}
+}
+
+// jsonPathOperators are the filter comparisons, longest first
+var jsonPathOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseJSONPathFilter parses a filter such as @.protocol=="tcp" or
+// @.remote_group, or conditions joined by && and ||
+func parseJSONPathFilter(content string) (*jsonPathFilter, error) {
+	filter := &jsonPathFilter{}
+	for _, alternative := range splitOutsideQuotes(content, "||") {
+		var all []jsonPathCondition
+		for _, text := range splitOutsideQuotes(alternative, "&&") {
+			condition, err := parseJSONPathCondition(strings.TrimSpace(text))
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, condition)
+		}
+		filter.anyOf = append(filter.anyOf, all)
+	}
+	return filter, nil
+}
+
+// parseJSONPathCondition parses one filter condition
+func parseJSONPathCondition(content string) (jsonPathCondition, error) {
+	left, op, right := content, "", ""
+	for _, candidate := range jsonPathOperators {
+		if i := indexOutsideQuotes(content, candidate); i >= 0 {
+			left, op, right = content[:i], candidate, content[i+len(candidate):]
+			break
+		}
+	}
+	left = strings.TrimSpace(left)
+	if !strings.HasPrefix(left, "@") {
+		return jsonPathCondition{}, fmt.Errorf("filter must start with @: %s", content)
+	}
+	path, err := parseJSONPath(left)
+	if err != nil {
+		return jsonPathCondition{}, err
+	}
+	condition := jsonPathCondition{path: path, op: op}
+	if op == "" {
+		return condition, nil
+	}
+	right = strings.TrimSpace(right)
+	if strings.HasPrefix(right, "'") || strings.HasPrefix(right, `"`) {
+		condition.value, err = unquoteJSONPath(right)
+		if err != nil {
+			return jsonPathCondition{}, err
+		}
+		return condition, nil
+	}
+	if err := json.Unmarshal([]byte(right), &condition.value); err != nil {
+		return jsonPathCondition{}, fmt.Errorf("invalid filter value %s", right)
+	}
+	return condition, nil
+}
+
+// indexOutsideQuotes returns the index of sub in s outside quoted text and
+// nested brackets or parentheses, or -1
+func indexOutsideQuotes(s, sub string) int {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], sub):
+			return i
+		}
+	}
+	return -1
+}
+
+// splitOutsideQuotes splits s around each sep outside quoted text and nested
+// brackets or parentheses
+func splitOutsideQuotes(s, sep string) []string {
+	var parts []string
+	for {
+		i := indexOutsideQuotes(s, sep)
+		if i < 0 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s = s[i+len(sep):]
+	}
+}
+
+// evalJSONPath returns the values an expression selects from current
+func evalJSONPath(steps []jsonPathStep, root, current any) []any {
+	values := []any{current}
+	for _, step := range steps {
+		var next []any
+		for _, v := range values {
+			next = append(next, step.apply(root, v)...)
+		}
+		values = next
+	}
+	return values
+}
+
+// apply returns the values a step selects from v
+func (step jsonPathStep) apply(root, v any) []any {
+	switch step.kind {
+	case stepRoot:
+		return []any{root}
+	case stepField:
+		if fields, ok := v.(map[string]any); ok {
+			if value, ok := fields[step.key]; ok {
+				return []any{value}
+			}
+		}
+	case stepWildcard:
+		return children(v)
+	case stepRecursive:
+		return descendants(v)
+	case stepIndex:
+		if list, ok := v.([]any); ok {
+			i := step.index
+			if i < 0 {
+				i += len(list)
+			}
+			if i >= 0 && i < len(list) {
+				return []any{list[i]}
+			}
+		}
+	case stepSlice:
+		if list, ok := v.([]any); ok {
+			start, end := sliceBound(step.start, 0, len(list)), sliceBound(step.end, len(list), len(list))
+			if start < end {
+				return list[start:end]
+			}
+		}
+	case stepUnion:
+		var values []any
+		for _, member := range step.union {
+			values = append(values, member.apply(root, v)...)
+		}
+		return values
+	case stepFilter:
+		var matched []any
+		for _, item := range children(v) {
+			if step.filter.matches(root, item) {
+				matched = append(matched, item)
+			}
+		}
+		return matched
+	}
+	return nil
+}
+
+// sliceBound resolves an optional, possibly negative slice bound
+func sliceBound(bound *int, def, length int) int {
+	if bound == nil {
+		return def
+	}
+	n := *bound
+	if n < 0 {
+		n += length
+	}
+	return min(max(n, 0), length)
+}
+
+// children returns the elements of an array or the values of an object,
+// ordered by key
+func children(v any) []any {
+	switch value := v.(type) {
+	case []any:
+		return value
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := make([]any, len(keys))
+		for i, k := range keys {
+			items[i] = value[k]
+		}
+		return items
+	}
+	return nil
+}
+
+// descendants returns v and all values nested in it, depth first
+func descendants(v any) []any {
+	values := []any{v}
+	for _, child := range children(v) {
+		values = append(values, descendants(child)...)
+	}
+	return values
+}
+
+// matches reports whether an element passes the filter
+func (f *jsonPathFilter) matches(root, item any) bool {
+	for _, all := range f.anyOf {
+		passes := true
+		for _, condition := range all {
+			if !condition.matches(root, item) {
+				passes = false
+				break
+			}
+		}
+		if passes {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether an element passes the condition
+func (f jsonPathCondition) matches(root, item any) bool {
+	values := evalJSONPath(f.path, root, item)
+	if f.op == "" {
+		return len(values) > 0 && values[0] != nil
+	}
+	if len(values) == 0 {
+		return false
+	}
+	left, right := values[0], f.value
+	if a, b, ok := jsonPathNumbers(left, right); ok {
+		switch f.op {
+		case "==":
+			return a == b
+		case "!=":
+			return a != b
+		case "<":
+			return a < b
+		case "<=":
+			return a <= b
+		case ">":
+			return a > b
+		case ">=":
+			return a >= b
+		}
+	}
+	switch f.op {
+	case "==":
+		return genericJSON(left) == genericJSON(right)
+	case "!=":
+		return genericJSON(left) != genericJSON(right)
+	}
+	// Other values are only ordered when both are strings
+	a, okA := left.(string)
+	b, okB := right.(string)
+	if !okA || !okB {
+		return false
+	}
+	switch f.op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// jsonPathNumbers returns both values as numbers when both are numeric
+func jsonPathNumbers(left, right any) (float64, float64, bool) {
+	a, okA := jsonPathNumber(left)
+	b, okB := jsonPathNumber(right)
+	return a, b, okA && okB
+}
+
+// jsonPathNumber returns a numeric value as a float
+func jsonPathNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
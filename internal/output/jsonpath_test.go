@@ -0,0 +1,155 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJSONPathFormatter(t *testing.T) {
+	port := 22
+	data := NewRecordData([]Column{{Header: "Name", Key: "name"}}, []Record{
+		NewRecord("security-group-rule",
+			Field{Key: "name", Value: "ssh"},
+			Field{Key: "id", Value: "rule-1"},
+			Field{Key: "protocol", Value: "tcp"},
+			Field{Key: "port_range_min", Value: &port},
+			Field{Key: "remote_group", Value: nil},
+		),
+		NewRecord("security-group-rule",
+			Field{Key: "name", Value: "icmp"},
+			Field{Key: "id", Value: "rule-2"},
+			Field{Key: "protocol", Value: "icmp"},
+			Field{Key: "port_range_min", Value: nil},
+			Field{Key: "remote_group", Value: Record{Fields: []Field{{Key: "id", Value: "sg-1"}, {Key: "name", Value: "web"}}}},
+		),
+	})
+
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{"wildcard", "{.data[*].id}", "rule-1 rule-2\n"},
+		{"index", "{.data[0].name}", "ssh\n"},
+		{"negative index", "{.data[-1].name}", "icmp\n"},
+		{"slice", "{.data[1:].id}", "rule-2\n"},
+		{"bracket key", "{.data[0]['port_range_min']}", "22\n"},
+		{"recursive descent", "{..name}", "ssh icmp web\n"},
+		{"string filter", `{.data[?(@.protocol=="icmp")].id}`, "rule-2\n"},
+		{"number filter", "{.data[?(@.port_range_min<100)].id}", "rule-1\n"},
+		{"existence filter", "{.data[?(@.remote_group)].remote_group.name}", "web\n"},
+		{"range", `{range .data[*]}{.id}{"\t"}{.protocol}{"\n"}{end}`, "rule-1\ttcp\nrule-2\ticmp\n"},
+		{"literal text", "ids: {.data[*].id}", "ids: rule-1 rule-2\n"},
+		{"object", "{.data[1].remote_group}", `{"id":"sg-1","name":"web"}` + "\n"},
+		{"missing field", "{.data[0].flavor}", ""},
+		{"root", "{$.data[0].id}", "rule-1\n"},
+		{"wildcard dot", "{.data[1].remote_group.*}", "sg-1 web\n"},
+		{"slice to negative", "{.data[:-1].id}", "rule-1\n"},
+		{"key union", "{.data[0]['name','id']}", "ssh rule-1\n"},
+		{"index union", "{.data[1,0].id}", "rule-2 rule-1\n"},
+		{"union per element", `{range .data[*]}{['id', "protocol"]}{"\n"}{end}`, "rule-1 tcp\nrule-2 icmp\n"},
+		{"and filter", `{.data[?(@.protocol=="tcp" && @.port_range_min==22)].id}`, "rule-1\n"},
+		{"and filter no match", `{.data[?(@.protocol=="tcp" && @.remote_group)].id}`, ""},
+		{"or filter", `{.data[?(@.name=="icmp" || @.port_range_min>=22)].id}`, "rule-1 rule-2\n"},
+		{"and binds tighter", `{.data[?(@.name=="x" && @.id=="rule-1" || @.protocol=="icmp")].id}`, "rule-2\n"},
+		{"not equal filter", `{.data[?(@.protocol!="tcp")].id}`, "rule-2\n"},
+		{"null filter", "{.data[?(@.remote_group==null)].id}", "rule-1\n"},
+		{"nested path filter", `{.data[?(@.remote_group.name=="web")].id}`, "rule-2\n"},
+		{"operator in filter string", `{.data[?(@.name!="a||b && c==d")].id}`, "rule-1 rule-2\n"},
+		{"recursive descent on key", "{.data..id}", "rule-1 rule-2 sg-1\n"},
+		{"nested range", `{range .data[*]}{range .remote_group.*}{@}{","}{end}{end}`, "sg-1,web,\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			f, err := NewJSONPathFormatter(&buf, tt.expr)
+			if err != nil {
+				t.Fatalf("NewJSONPathFormatter(%q) error = %v", tt.expr, err)
+			}
+			if err := f.Format(data); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("%s = %q, want %q", tt.expr, buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPathFormatterInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"{.data[*]",
+		"{range .data[*]}{.id}",
+		"{end}",
+		"{.data[x]}",
+		`{.data[?(protocol=="tcp")]}`,
+		"{.data['id'}",
+		`{.data[?(@.id=="a" && protocol=="b")]}`,
+		"{.data[?(@.id==x)]}",
+		"{.data[0,*]}",
+		"{.data['a',1:2]}",
+		`{"unterminated}`,
+	} {
+		if _, err := NewJSONPathFormatter(&bytes.Buffer{}, expr); err == nil {
+			t.Errorf("NewJSONPathFormatter(%q) should fail", expr)
+		}
+	}
+}
+
+func TestJSONPathFormatterSpecialKeys(t *testing.T) {
+	data := NewRecordData([]Column{{Header: "Name", Key: "name"}}, []Record{
+		NewRecord("server",
+			Field{Key: "name", Value: "web-1"},
+			Field{Key: "metadata.owner", Value: "alice"},
+			Field{Key: "it's", Value: "single"},
+			Field{Key: `say "hi"`, Value: "double"},
+			Field{Key: "a}b", Value: "brace"},
+			Field{Key: "a,b", Value: "comma"},
+			Field{Key: "networks", Value: []any{
+				map[string]any{"name": "net-1", "ips": []any{"10.0.0.1", "10.0.0.2"}},
+				map[string]any{"name": "net-2", "ips": []any{"10.1.0.1"}},
+			}},
+		),
+	})
+
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{"escaped dot", `{.data[0].metadata\.owner}`, "alice\n"},
+		{"quoted key with dot", "{.data[0]['metadata.owner']}", "alice\n"},
+		{"escaped single quote", `{.data[0]['it\'s']}`, "single\n"},
+		{"single quote in double quotes", `{.data[0]["it's"]}`, "single\n"},
+		{"escaped double quote", `{.data[0]["say \"hi\""]}`, "double\n"},
+		{"double quote in single quotes", `{.data[0]['say "hi"']}`, "double\n"},
+		{"brace in key", "{.data[0]['a}b']}", "brace\n"},
+		{"comma in key", "{.data[0]['a,b']}", "comma\n"},
+		{"brace in filter", `{.data[?(@['a}b']=="brace")].name}`, "web-1\n"},
+		{"brace in filter value", `{.data[?(@.name!="}{")].name}`, "web-1\n"},
+		{"brace in literal", `{"{"}{.data[0].name}{"}"}`, "{web-1}\n"},
+		{"recursive descent over arrays", "{..ips[*]}", "10.0.0.1 10.0.0.2 10.1.0.1\n"},
+		{"recursive descent index", "{.data[0].networks..[0]}", `{"ips":["10.0.0.1","10.0.0.2"],"name":"net-1"} 10.0.0.1 10.1.0.1` + "\n"},
+		{"recursive descent filter", `{..networks[?(@.name=="net-2")].ips[0]}`, "10.1.0.1\n"},
+		{"nested filter", `{.data[0].networks[?(@.ips[?(@=="10.0.0.2")])].name}`, "net-1\n"},
+		{"array", "{.data[0].networks[1].ips}", `["10.1.0.1"]` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			f, err := NewJSONPathFormatter(&buf, tt.expr)
+			if err != nil {
+				t.Fatalf("NewJSONPathFormatter(%q) error = %v", tt.expr, err)
+			}
+			if err := f.Format(data); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("%s = %q, want %q", tt.expr, buf.String(), tt.want)
+			}
+		})
+	}
+}
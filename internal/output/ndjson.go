@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// NDJSONFormatter implements the Formatter and RecordStreamer interfaces for
+// newline-delimited JSON: one compact JSON object per resource and line,
+// without headers or metadata
+type NDJSONFormatter struct {
+	BaseFormatter
+	buf *bufio.Writer
+}
+
+// NewNDJSONFormatter creates a new NDJSONFormatter instance
+func NewNDJSONFormatter(w io.Writer) *NDJSONFormatter {
+	return &NDJSONFormatter{
+		BaseFormatter: BaseFormatter{Writer: w},
+		buf:           bufio.NewWriter(w),
+	}
+}
+
+// Format writes each row of data as a line
+func (f *NDJSONFormatter) Format(data *OutputData) error {
+	if data.HasRecords() {
+		for _, r := range data.Records {
+			if err := f.WriteRecord(r); err != nil {
+				return err
+			}
+		}
+		return f.Flush()
+	}
+	v, err := dataValue(data)
+	if err != nil {
+		return err
+	}
+	return f.writeItems(resourceItems(v))
+}
+
+// FormatDocument writes each resource of the document as a line, or uses the
+// document's NDJSON renderer
+func (f *NDJSONFormatter) FormatDocument(doc *Document) error {
+	if render, ok := doc.Renderers[FormatNDJSON]; ok {
+		return render(f.Writer)
+	}
+	// Write the elements of a list one per line, keeping their field order
+	value := reflect.ValueOf(doc.Value)
+	switch {
+	case doc.Value == nil:
+		return nil
+	case value.Kind() == reflect.Slice || value.Kind() == reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := f.writeLine(value.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := f.writeLine(doc.Value); err != nil {
+			return err
+		}
+	}
+	return f.Flush()
+}
+
+// WriteRecord writes a record as a line. Lines are buffered until Flush.
+func (f *NDJSONFormatter) WriteRecord(r Record) error {
+	return f.writeLine(r)
+}
+
+// Flush writes buffered lines
+func (f *NDJSONFormatter) Flush() error {
+	return f.buf.Flush()
+}
+
+// writeItems writes each item as a line
+func (f *NDJSONFormatter) writeItems(items []any) error {
+	for _, item := range items {
+		if err := f.writeLine(item); err != nil {
+			return err
+		}
+	}
+	return f.Flush()
+}
+
+// writeLine writes a value as one line of compact JSON
+func (f *NDJSONFormatter) writeLine(v any) error {
+	data, err := marshalNoEscape(v)
+	if err != nil {
+		return fmt.Errorf("error encoding JSON: %v", err)
+	}
+	f.buf.Write(data)
+	return f.buf.WriteByte('\n')
+}
+
+// WriteLines writes each value as one line of compact JSON, for renderers of
+// documents whose lines are not the resources of their value
+func WriteLines[T any](w io.Writer, values []T) error {
+	f := NewNDJSONFormatter(w)
+	for _, v := range values {
+		if err := f.writeLine(v); err != nil {
+			return err
+		}
+	}
+	return f.Flush()
+}
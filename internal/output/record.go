@@ -0,0 +1,212 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Record is one typed output row, e.g. a server or a security group rule.
+// Field values are strings, numbers, booleans, nil, string slices, string
+// maps, nested records or slices of records; json writes them as such and
+// tables and CSV flatten them through the command's columns.
+type Record struct {
+	// Type is the resource type, written first as "type" by json; nested
+	// records leave it empty
+	Type   string
+	Fields []Field
+}
+
+// Field is a named record value
+type Field struct {
+	Key   string
+	Value any
+}
+
+// NewRecord creates a record of a resource type
+func NewRecord(resourceType string, fields ...Field) Record {
+	return Record{Type: resourceType, Fields: fields}
+}
+
+// Get returns the value of a field, or nil when the record has no such field
+func (r Record) Get(key string) any {
+	for _, f := range r.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return nil
+}
+
+// Select returns the record with only the given fields, in the given order.
+// Fields the record does not have are left out.
+func (r Record) Select(keys []string) Record {
+	selected := Record{Type: r.Type, Fields: make([]Field, 0, len(keys))}
+	for _, key := range keys {
+		for _, f := range r.Fields {
+			if f.Key == key {
+				selected.Fields = append(selected.Fields, f)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// String returns the value of a field flattened to a string
+func (r Record) String(key string) string {
+	return FormatValue(r.Get(key))
+}
+
+// MarshalJSON writes the record as an object with its type first and its
+// fields in order
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	fields := r.Fields
+	if r.Type != "" {
+		fields = append([]Field{{Key: "type", Value: r.Type}}, fields...)
+	}
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalNoEscape(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalNoEscape(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Key, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalNoEscape encodes v as JSON without escaping HTML characters
+func marshalNoEscape(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// Column is a record field shown as a table or CSV column
+type Column struct {
+	// Header is the table and CSV header, e.g. "Server Name"
+	Header string
+	// Key is the record field shown, e.g. "name"
+	Key string
+	// Fields are the record fields the column reads (default: Key), e.g. the
+	// two fields of a port range; empty for columns such as the record type
+	// that read no field
+	Fields []string
+	// Format renders the column when the field is not shown as is, e.g. a port
+	// range built from two fields (default: the field flattened by FormatValue)
+	Format func(r Record) string
+}
+
+// value renders the column for a record
+func (c Column) value(r Record) string {
+	if c.Format != nil {
+		return c.Format(r)
+	}
+	return r.String(c.Key)
+}
+
+// FormatValue flattens a record value for tables and CSV: nil is empty,
+// string slices are joined with ", ", maps are written as JSON and nested
+// records by their name
+func FormatValue(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case int:
+		return strconv.Itoa(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case *int:
+		if value == nil {
+			return ""
+		}
+		return strconv.Itoa(*value)
+	case bool:
+		return strconv.FormatBool(value)
+	case []string:
+		return strings.Join(value, ", ")
+	case map[string]string:
+		if len(value) == 0 {
+			return ""
+		}
+		data, _ := marshalNoEscape(value)
+		return string(data)
+	case Record:
+		return value.String("name")
+	case []Record:
+		names := make([]string, len(value))
+		for i, r := range value {
+			names[i] = r.String("name")
+		}
+		return strings.Join(names, ", ")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// NewRecordData creates OutputData from typed records. Headers and rows are
+// the records flattened through the columns, for tables and CSV; json writes
+// the records themselves.
+func NewRecordData(columns []Column, records []Record) *OutputData {
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.Header
+	}
+	rows := make([][]string, 0, len(records))
+	for _, r := range records {
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			row[i] = c.value(r)
+		}
+		rows = append(rows, row)
+	}
+	data := NewOutputData(headers, rows)
+	data.Columns = columns
+	data.Records = records
+	return data
+}
+
+// HasRecords reports whether the data was created from typed records
+func (d *OutputData) HasRecords() bool {
+	return d.Columns != nil
+}
+
+// Keys returns the record fields the columns read, in column order and
+// without repeats, as used for json headers
+func (d *OutputData) Keys() []string {
+	keys := make([]string, 0, len(d.Columns))
+	seen := make(map[string]bool)
+	for _, c := range d.Columns {
+		fields := c.Fields
+		if fields == nil {
+			fields = []string{c.Key}
+		}
+		for _, key := range fields {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,125 @@
+package output
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// registration describes a registered output format
+type registration struct {
+	format      Format
+	description string
+	// argument names the argument of formats written as "<format>=<argument>",
+	// e.g. "<go-template>"; empty for formats without one
+	argument string
+	newFunc  func(w io.Writer, arg string) (Formatter, error)
+}
+
+// registry holds the registered output formats in registration order
+var registry []registration
+
+// Register adds an output format. Every command that writes through
+// NewFormatter or FormatDocument accepts a registered format; registering a
+// format twice replaces the earlier registration.
+func Register(format Format, description string, newFunc func(w io.Writer) Formatter) {
+	register(registration{
+		format:      format,
+		description: description,
+		newFunc: func(w io.Writer, _ string) (Formatter, error) {
+			return newFunc(w), nil
+		},
+	})
+}
+
+// RegisterWithArgument adds an output format that takes an argument, given
+// as "<format>=<argument>" (e.g. -o template='{{.name}}'). newFunc receives
+// the argument and reports a missing or invalid one.
+func RegisterWithArgument(format Format, argument, description string, newFunc func(w io.Writer, arg string) (Formatter, error)) {
+	register(registration{format: format, description: description, argument: argument, newFunc: newFunc})
+}
+
+// register adds or replaces a registration
+func register(reg registration) {
+	for i, r := range registry {
+		if r.format == reg.format {
+			registry[i] = reg
+			return
+		}
+	}
+	registry = append(registry, reg)
+}
+
+// lookup returns the registration for a format and the format's argument.
+// Only formats registered with an argument accept "<format>=<argument>".
+func lookup(format string) (registration, string, bool) {
+	name, arg, hasArg := strings.Cut(format, "=")
+	for _, r := range registry {
+		if string(r.format) == name {
+			if hasArg && r.argument == "" {
+				break
+			}
+			return r, arg, true
+		}
+	}
+	return registration{}, "", false
+}
+
+// Document is output that is not a single list of rows, such as the details of
+// a resource or a drift report. Formats that have no renderer for a document
+// write its Value (json) or its Data (table, csv).
+type Document struct {
+	// Value is the structured form of the document, encoded as is by json
+	Value any
+	// Data is the document flattened to rows
+	Data *OutputData
+	// Items are the resources of the document for template, name and id, such
+	// as the items of a report; nil uses Value
+	Items any
+	// NameKey and IDKey are the fields of the resources written by -o name and
+	// -o id (default: "name" and "id")
+	NameKey, IDKey string
+	// EscapeHTML escapes <, > and & in JSON strings, as encoding/json does by
+	// default
+	EscapeHTML bool
+	// Renderers write the document in a format of its own, keyed by format name.
+	// A renderer takes precedence over the registered formatter, and a format
+	// with a renderer is valid for the document even when it is not registered.
+	Renderers map[Format]func(w io.Writer) error
+}
+
+// FormatDocument writes a document in the given format: with the document's
+// own renderer for the format when it has one, otherwise with the registered
+// formatter
+func FormatDocument(format string, w io.Writer, doc *Document) error {
+	if render, ok := doc.Renderers[Format(format)]; ok {
+		return render(w)
+	}
+	formatter, err := NewFormatter(format, w)
+	var invalid *ErrInvalidFormat
+	if errors.As(err, &invalid) {
+		valid := GetValidFormats()
+		var own []string
+		for f := range doc.Renderers {
+			if _, _, ok := lookup(string(f)); !ok {
+				own = append(own, string(f))
+			}
+		}
+		sort.Strings(own)
+		return &ErrInvalidFormat{Format: format, Valid: append(valid, own...)}
+	}
+	if err != nil {
+		return err
+	}
+	return formatter.FormatDocument(doc)
+}
+
+// formatHelpLine formats a format name and description for FormatHelp
+func formatHelpLine(r registration) string {
+	name := string(r.format)
+	if r.argument != "" {
+		name += "=" + r.argument
+	}
+	return "  " + name + strings.Repeat(" ", max(1, 9-len(name))) + r.description
+}
@@ -21,21 +21,27 @@ func NewTableFormatter(w io.Writer) *TableFormatter {
 
 // Format writes the data in table format
 func (f *TableFormatter) Format(data *OutputData) error {
-	// Handle filtering info if present
+	// Handle filtering info if present; NoHeaders leaves out the summary
 	if data.HasFiltering {
-		if data.FilteredProjectCount == 0 {
-			fmt.Fprintf(f.Writer, "No projects matched the filter criteria\n")
+		if data.NoMatchingProjects() {
+			if !data.NoHeaders {
+				fmt.Fprintf(f.Writer, "No projects matched the filter criteria\n")
+			}
 			return nil
 		}
-		fmt.Fprintf(f.Writer, "Found %d matching projects: %v\n",
-			data.FilteredProjectCount,
-			data.MatchedProjects)
-		fmt.Fprintln(f.Writer)
+		if !data.NoHeaders {
+			fmt.Fprintf(f.Writer, "Found %d matching projects: %v\n",
+				data.FilteredProjectCount,
+				data.MatchedProjects)
+			fmt.Fprintln(f.Writer)
+		}
 	}
 
 	// Create and configure table writer
 	table := tablewriter.NewWriter(f.Writer)
-	table.SetHeader(data.Headers)
+	if !data.NoHeaders {
+		table.SetHeader(data.Headers)
+	}
 	table.SetAutoWrapText(false)
 	table.SetAutoFormatHeaders(true)
 	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
@@ -52,6 +58,17 @@ func (f *TableFormatter) Format(data *OutputData) error {
 	return nil
 }
 
+// FormatDocument writes the document with its table renderer, or its rows as a table
+func (f *TableFormatter) FormatDocument(doc *Document) error {
+	if render, ok := doc.Renderers[FormatTable]; ok {
+		return render(f.Writer)
+	}
+	if doc.Data == nil {
+		return fmt.Errorf("document has no table form")
+	}
+	return f.Format(doc.Data)
+}
+
 // FormatSecurityGroupRules formats security group rules in a special table format
 func (f *TableFormatter) FormatSecurityGroupRules(groupName, groupID string, rules [][]string) error {
 	fmt.Fprintf(f.Writer, "\n%s (%s):\n", groupName, groupID)
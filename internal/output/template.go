@@ -0,0 +1,193 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+	"text/template"
+)
+
+// TemplateFormatter implements the Formatter interface with a Go template,
+// executed once per resource with the resource's JSON fields, e.g.
+// {{.name}} {{.ip_address}}
+type TemplateFormatter struct {
+	BaseFormatter
+	Template *template.Template
+}
+
+// templateFuncs are the helper functions available to output templates
+var templateFuncs = template.FuncMap{
+	"join":         templateJoin,
+	"default":      templateDefault,
+	"cidrContains": templateCIDRContains,
+}
+
+// NewTemplateFormatter creates a new template formatter from the template text
+func NewTemplateFormatter(w io.Writer, text string) (*TemplateFormatter, error) {
+	if text == "" {
+		return nil, fmt.Errorf("template output needs a template, e.g. -o template='{{.name}}' or --template-file")
+	}
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output template: %w", err)
+	}
+	return &TemplateFormatter{BaseFormatter: BaseFormatter{Writer: w}, Template: tmpl}, nil
+}
+
+// Format executes the template for each row of data
+func (f *TemplateFormatter) Format(data *OutputData) error {
+	v, err := dataValue(data)
+	if err != nil {
+		return err
+	}
+	return f.execute(resourceItems(v))
+}
+
+// FormatDocument executes the template for each resource of the document
+func (f *TemplateFormatter) FormatDocument(doc *Document) error {
+	items, err := documentItems(doc)
+	if err != nil {
+		return err
+	}
+	return f.execute(items)
+}
+
+// execute writes the template output of each item, one item per line.
+// Items the template writes nothing for, e.g. those an {{if}} leaves out,
+// are skipped.
+func (f *TemplateFormatter) execute(items []any) error {
+	for _, item := range items {
+		var buf bytes.Buffer
+		if err := f.Template.Execute(&buf, item); err != nil {
+			return fmt.Errorf("error executing output template: %w", err)
+		}
+		if buf.Len() == 0 {
+			continue
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := f.Writer.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templateJoin joins the elements of a list with a separator:
+// {{join ", " .security_groups}}
+func templateJoin(sep string, list any) string {
+	switch value := list.(type) {
+	case []any:
+		texts := make([]string, len(value))
+		for i, item := range value {
+			texts[i] = genericText(item)
+		}
+		return strings.Join(texts, sep)
+	case []string:
+		return strings.Join(value, sep)
+	}
+	return genericText(list)
+}
+
+// templateDefault returns value, or def when value is nil, empty or false:
+// {{.remote_ip_prefix | default "any"}}
+func templateDefault(def, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return def
+	case string:
+		if v == "" {
+			return def
+		}
+	case bool:
+		if !v {
+			return def
+		}
+	case []any:
+		if len(v) == 0 {
+			return def
+		}
+	case map[string]any:
+		if len(v) == 0 {
+			return def
+		}
+	}
+	return value
+}
+
+// templateCIDRContains reports whether a CIDR contains an address or prefix:
+// {{if cidrContains "10.0.0.0/8" .ip_address}}. Values that are not an
+// address or prefix, such as an empty IP, are not contained.
+func templateCIDRContains(cidr string, value any) (bool, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return false, fmt.Errorf("cidrContains: invalid CIDR %q", cidr)
+	}
+	text := genericText(value)
+	if addr, err := netip.ParseAddr(text); err == nil {
+		return prefix.Contains(addr), nil
+	}
+	if other, err := netip.ParsePrefix(text); err == nil {
+		return other.Bits() >= prefix.Bits() && prefix.Contains(other.Addr()), nil
+	}
+	return false, nil
+}
+
+// FieldFormatter implements the Formatter interface for the name and id
+// formats, writing one field of each resource per line
+type FieldFormatter struct {
+	BaseFormatter
+	Field Format
+}
+
+// NewFieldFormatter creates a new formatter for the name or id format
+func NewFieldFormatter(w io.Writer, field Format) *FieldFormatter {
+	return &FieldFormatter{BaseFormatter: BaseFormatter{Writer: w}, Field: field}
+}
+
+// Format writes the name or id of each row of data
+func (f *FieldFormatter) Format(data *OutputData) error {
+	v, err := dataValue(data)
+	if err != nil {
+		return err
+	}
+	return f.write(resourceItems(v), f.key(data.NameKey, data.IDKey))
+}
+
+// FormatDocument writes the name or id of each resource of the document
+func (f *FieldFormatter) FormatDocument(doc *Document) error {
+	items, err := documentItems(doc)
+	if err != nil {
+		return err
+	}
+	return f.write(items, f.key(doc.NameKey, doc.IDKey))
+}
+
+// key returns the field written: the given name or ID key, or the field's
+// own name when the key is empty
+func (f *FieldFormatter) key(nameKey, idKey string) string {
+	if f.Field == FormatName && nameKey != "" {
+		return nameKey
+	}
+	if f.Field == FormatID && idKey != "" {
+		return idKey
+	}
+	return string(f.Field)
+}
+
+// write writes the key of each item that has it
+func (f *FieldFormatter) write(items []any, key string) error {
+	for _, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok || fields[key] == nil {
+			continue
+		}
+		if _, err := fmt.Fprintln(f.Writer, genericText(fields[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+// YAMLFormatter implements the Formatter interface for YAML output. It writes
+// the same structure and keys as JSON output, in the same order.
+type YAMLFormatter struct {
+	BaseFormatter
+}
+
+// NewYAMLFormatter creates a new YAMLFormatter instance
+func NewYAMLFormatter(w io.Writer) *YAMLFormatter {
+	return &YAMLFormatter{
+		BaseFormatter: BaseFormatter{Writer: w},
+	}
+}
+
+// Format writes the data as YAML
+func (f *YAMLFormatter) Format(data *OutputData) error {
+	var buf bytes.Buffer
+	if err := NewJSONFormatter(&buf).Format(data); err != nil {
+		return err
+	}
+	return f.write(buf.Bytes())
+}
+
+// FormatDocument writes the document's value as YAML, or uses its YAML renderer
+func (f *YAMLFormatter) FormatDocument(doc *Document) error {
+	if render, ok := doc.Renderers[FormatYAML]; ok {
+		return render(f.Writer)
+	}
+	data, err := marshalNoEscape(doc.Value)
+	if err != nil {
+		return err
+	}
+	return f.write(data)
+}
+
+// write converts JSON to YAML, keeping the order of object keys
+func (f *YAMLFormatter) write(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	v, err := decodeOrdered(decoder)
+	if err != nil {
+		return fmt.Errorf("error converting output to YAML: %v", err)
+	}
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding YAML: %v", err)
+	}
+	_, err = f.Writer.Write(out)
+	return err
+}
+
+// decodeOrdered decodes the next JSON value, with objects as yaml.MapSlice so
+// that their keys keep their order
+func decodeOrdered(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			object := yaml.MapSlice{}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, yaml.MapItem{Key: key, Value: value})
+			}
+			_, err := decoder.Token()
+			return object, err
+		}
+		list := []any{}
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := decoder.Token()
+		return list, err
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n, nil
+		}
+		return t.Float64()
+	default:
+		return t, nil
+	}
+}
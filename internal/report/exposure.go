@@ -0,0 +1,231 @@
+package report
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/marcdicarlo/osc/internal/secgrp"
+)
+
+// Server holds the cached address details of a server
+type Server struct {
+	ID          string
+	Name        string
+	ProjectName string
+	IPv4Addr    string
+	FloatingIP  string
+}
+
+// IngressRule holds an ingress rule of a security group attached to a server
+type IngressRule struct {
+	RuleID         string
+	SecGrpID       string
+	SecGrpName     string
+	Ethertype      string
+	Protocol       string
+	PortRangeMin   *int
+	PortRangeMax   *int
+	RemoteIPPrefix string
+	RemoteGroupID  string
+}
+
+// Exposure is a single port on a server that is reachable from a public source
+type Exposure struct {
+	ServerID    string `json:"server_id"`
+	ServerName  string `json:"server_name"`
+	ProjectName string `json:"project_name"`
+	PublicIP    string `json:"public_ip"`
+	Protocol    string `json:"protocol"`
+	PortRange   string `json:"port_range"`
+	SecGrpName  string `json:"security_group"`
+	RuleID      string `json:"rule_id"`
+	Remote      string `json:"remote"`
+}
+
+// ProjectSummary aggregates exposures for a single project
+type ProjectSummary struct {
+	ProjectName    string `json:"project_name"`
+	ExposedServers int    `json:"exposed_servers"`
+	ExposedPorts   int    `json:"exposed_ports"`
+	// OpenToAll counts servers with every protocol and port exposed
+	OpenToAll int `json:"open_to_all"`
+}
+
+// ExposureAnalyzer finds servers reachable from the internet
+type ExposureAnalyzer struct {
+	// PublicCIDRs are additional source ranges treated as public
+	PublicCIDRs []netip.Prefix
+}
+
+// NewExposureAnalyzer creates an analyzer with the given additional public CIDRs.
+// Invalid CIDRs are returned in the error slice and otherwise ignored.
+func NewExposureAnalyzer(publicCIDRs []string) (*ExposureAnalyzer, []string) {
+	a := &ExposureAnalyzer{}
+	var invalid []string
+	for _, c := range publicCIDRs {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(c))
+		if err != nil {
+			invalid = append(invalid, c)
+			continue
+		}
+		a.PublicCIDRs = append(a.PublicCIDRs, prefix.Masked())
+	}
+	return a, invalid
+}
+
+// PublicIP returns the address a server is reachable on from the internet.
+// The floating IP is preferred; otherwise the fixed IPv4 address is used if it
+// is globally routable. An empty string means the server has no public address.
+func PublicIP(srv Server) string {
+	if srv.FloatingIP != "" {
+		return srv.FloatingIP
+	}
+	addr, err := netip.ParseAddr(srv.IPv4Addr)
+	if err != nil {
+		return ""
+	}
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || isSharedAddress(addr) {
+		return ""
+	}
+	return srv.IPv4Addr
+}
+
+// isSharedAddress reports whether addr is in the carrier-grade NAT range (100.64.0.0/10)
+func isSharedAddress(addr netip.Addr) bool {
+	return netip.MustParsePrefix("100.64.0.0/10").Contains(addr)
+}
+
+// IsPublicRemote reports whether a rule's remote admits traffic from the internet.
+// That is the case for rules without any remote, rules open to 0.0.0.0/0 or ::/0,
+// and rules whose remote prefix overlaps one of the configured public CIDRs.
+func (a *ExposureAnalyzer) IsPublicRemote(remoteIPPrefix, remoteGroupID string) bool {
+	if remoteGroupID != "" {
+		return false
+	}
+	if strings.TrimSpace(remoteIPPrefix) == "" {
+		return true
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(remoteIPPrefix))
+	if err != nil {
+		return false
+	}
+	if prefix.Bits() == 0 {
+		return true
+	}
+	for _, public := range a.PublicCIDRs {
+		if public.Overlaps(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindExposures returns one Exposure per server and granting ingress rule.
+// rulesByServer maps server IDs to the ingress rules of their security groups.
+// Results are sorted by project, server name, protocol and port range.
+func (a *ExposureAnalyzer) FindExposures(servers []Server, rulesByServer map[string][]IngressRule) []Exposure {
+	var exposures []Exposure
+	for _, srv := range servers {
+		publicIP := PublicIP(srv)
+		if publicIP == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(publicIP)
+		if err != nil {
+			continue
+		}
+
+		for _, rule := range rulesByServer[srv.ID] {
+			if !ethertypeMatches(rule.Ethertype, addr) || !a.IsPublicRemote(rule.RemoteIPPrefix, rule.RemoteGroupID) {
+				continue
+			}
+			remote := rule.RemoteIPPrefix
+			if remote == "" {
+				remote = "any"
+			}
+			exposures = append(exposures, Exposure{
+				ServerID:    srv.ID,
+				ServerName:  srv.Name,
+				ProjectName: srv.ProjectName,
+				PublicIP:    publicIP,
+				Protocol:    secgrp.NormalizeProtocol(rule.Protocol),
+				PortRange:   secgrp.FormatPortRange(rule.PortRangeMin, rule.PortRangeMax),
+				SecGrpName:  rule.SecGrpName,
+				RuleID:      rule.RuleID,
+				Remote:      remote,
+			})
+		}
+	}
+
+	sort.SliceStable(exposures, func(i, j int) bool {
+		ei, ej := exposures[i], exposures[j]
+		if ei.ProjectName != ej.ProjectName {
+			return ei.ProjectName < ej.ProjectName
+		}
+		if ei.ServerName != ej.ServerName {
+			return ei.ServerName < ej.ServerName
+		}
+		if ei.ServerID != ej.ServerID {
+			return ei.ServerID < ej.ServerID
+		}
+		if ei.Protocol != ej.Protocol {
+			return ei.Protocol < ej.Protocol
+		}
+		if ei.PortRange != ej.PortRange {
+			return ei.PortRange < ej.PortRange
+		}
+		return ei.RuleID < ej.RuleID
+	})
+
+	return exposures
+}
+
+// SummarizeByProject aggregates exposures per project, sorted by project name
+func SummarizeByProject(exposures []Exposure) []ProjectSummary {
+	byProject := make(map[string]*ProjectSummary)
+	servers := make(map[string]map[string]bool)
+	ports := make(map[string]map[string]bool)
+	openToAll := make(map[string]map[string]bool)
+
+	for _, e := range exposures {
+		summary, ok := byProject[e.ProjectName]
+		if !ok {
+			summary = &ProjectSummary{ProjectName: e.ProjectName}
+			byProject[e.ProjectName] = summary
+			servers[e.ProjectName] = make(map[string]bool)
+			ports[e.ProjectName] = make(map[string]bool)
+			openToAll[e.ProjectName] = make(map[string]bool)
+		}
+		servers[e.ProjectName][e.ServerID] = true
+		// Count each server/protocol/port once even if several rules grant it
+		ports[e.ProjectName][e.ServerID+"|"+e.Protocol+"|"+e.PortRange] = true
+		if e.Protocol == "any" && e.PortRange == "any" {
+			openToAll[e.ProjectName][e.ServerID] = true
+		}
+	}
+
+	result := make([]ProjectSummary, 0, len(byProject))
+	for name, summary := range byProject {
+		summary.ExposedServers = len(servers[name])
+		summary.ExposedPorts = len(ports[name])
+		summary.OpenToAll = len(openToAll[name])
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ProjectName < result[j].ProjectName
+	})
+	return result
+}
+
+// ethertypeMatches reports whether a rule ethertype applies to the address family of addr
+func ethertypeMatches(ethertype string, addr netip.Addr) bool {
+	switch strings.ToLower(ethertype) {
+	case "ipv4":
+		return addr.Is4()
+	case "ipv6":
+		return addr.Is6()
+	default:
+		return true
+	}
+}
@@ -0,0 +1,71 @@
+package report
+
+import (
+	"testing"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		name string
+		srv  Server
+		want string
+	}{
+		{"floating ip preferred", Server{IPv4Addr: "10.0.0.5", FloatingIP: "203.0.113.10"}, "203.0.113.10"},
+		{"private fixed ip", Server{IPv4Addr: "192.168.1.10"}, ""},
+		{"public fixed ip", Server{IPv4Addr: "198.51.100.7"}, "198.51.100.7"},
+		{"cgnat fixed ip", Server{IPv4Addr: "100.64.1.1"}, ""},
+		{"no address", Server{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PublicIP(tt.srv); got != tt.want {
+				t.Errorf("PublicIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindExposures(t *testing.T) {
+	analyzer, invalid := NewExposureAnalyzer([]string{"198.51.100.0/24", "not-a-cidr"})
+	if len(invalid) != 1 {
+		t.Fatalf("Expected 1 invalid CIDR, got %v", invalid)
+	}
+
+	servers := []Server{
+		{ID: "srv-1", Name: "web", ProjectName: "prod", IPv4Addr: "10.0.0.5", FloatingIP: "203.0.113.10"},
+		{ID: "srv-2", Name: "db", ProjectName: "prod", IPv4Addr: "10.0.0.6"},
+	}
+	rules := map[string][]IngressRule{
+		"srv-1": {
+			{RuleID: "r-https", SecGrpName: "web", Ethertype: "IPv4", Protocol: "tcp", PortRangeMin: intPtr(443), PortRangeMax: intPtr(443), RemoteIPPrefix: "0.0.0.0/0"},
+			{RuleID: "r-ssh", SecGrpName: "web", Ethertype: "IPv4", Protocol: "tcp", PortRangeMin: intPtr(22), PortRangeMax: intPtr(22), RemoteIPPrefix: "10.0.0.0/8"},
+			{RuleID: "r-partner", SecGrpName: "web", Ethertype: "IPv4", Protocol: "tcp", PortRangeMin: intPtr(8443), PortRangeMax: intPtr(8443), RemoteIPPrefix: "198.51.100.20/32"},
+			{RuleID: "r-lb", SecGrpName: "web", Ethertype: "IPv4", Protocol: "tcp", PortRangeMin: intPtr(80), PortRangeMax: intPtr(80), RemoteGroupID: "sg-lb"},
+			{RuleID: "r-v6", SecGrpName: "web", Ethertype: "IPv6", RemoteIPPrefix: "::/0"},
+		},
+		"srv-2": {
+			{RuleID: "r-any", SecGrpName: "db", Ethertype: "IPv4", RemoteIPPrefix: "0.0.0.0/0"},
+		},
+	}
+
+	exposures := analyzer.FindExposures(servers, rules)
+	if len(exposures) != 2 {
+		t.Fatalf("Expected 2 exposures, got %d: %+v", len(exposures), exposures)
+	}
+	if exposures[0].RuleID != "r-https" || exposures[0].PortRange != "443" || exposures[0].PublicIP != "203.0.113.10" {
+		t.Errorf("Unexpected first exposure: %+v", exposures[0])
+	}
+	if exposures[1].RuleID != "r-partner" {
+		t.Errorf("Expected partner CIDR rule to be exposed, got %+v", exposures[1])
+	}
+
+	summary := SummarizeByProject(exposures)
+	if len(summary) != 1 || summary[0].ExposedServers != 1 || summary[0].ExposedPorts != 2 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
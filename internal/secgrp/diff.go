@@ -0,0 +1,212 @@
+package secgrp
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// Presence describes which side of a comparison a rule was found on
+type Presence string
+
+const (
+	PresenceOnlyInA Presence = "only_in_a"
+	PresenceOnlyInB Presence = "only_in_b"
+	PresenceBoth    Presence = "both"
+)
+
+// SelfReference is the remote group value used for rules that reference their own group
+const SelfReference = "(self)"
+
+// RawRule holds a security group rule as read from the cache
+type RawRule struct {
+	Direction       string
+	EtherType       string
+	Protocol        string
+	PortRangeMin    *int
+	PortRangeMax    *int
+	RemoteIPPrefix  string
+	RemoteGroupID   string
+	RemoteGroupName string
+}
+
+// Rule is a security group rule normalized for comparison.
+// IDs are dropped and remote groups are identified by name so that
+// rules from cloned groups in different projects compare equal.
+type Rule struct {
+	Direction   string `json:"direction"`
+	Ethertype   string `json:"ethertype"`
+	Protocol    string `json:"protocol"`
+	PortRange   string `json:"port_range"`
+	RemoteIP    string `json:"remote_ip"`
+	RemoteGroup string `json:"remote_group"`
+}
+
+// RuleDiff is a single normalized rule and the side(s) it was found on
+type RuleDiff struct {
+	Rule     Rule
+	Presence Presence
+}
+
+// Normalize converts a cached rule into its comparable form.
+// ownGroupID is the ID of the group the rule belongs to and is used to
+// detect self-referencing rules.
+func Normalize(r RawRule, ownGroupID string) Rule {
+	rule := Rule{
+		Direction: strings.ToLower(strings.TrimSpace(r.Direction)),
+		Ethertype: normalizeEthertype(r.EtherType),
+		Protocol:  NormalizeProtocol(r.Protocol),
+		PortRange: FormatPortRange(r.PortRangeMin, r.PortRangeMax),
+		RemoteIP:  "any",
+	}
+
+	switch {
+	case r.RemoteGroupID != "" && r.RemoteGroupID == ownGroupID:
+		rule.RemoteGroup = SelfReference
+	case r.RemoteGroupName != "":
+		rule.RemoteGroup = r.RemoteGroupName
+	default:
+		// Fall back to the ID when the remote group is not in the cache
+		rule.RemoteGroup = r.RemoteGroupID
+	}
+
+	if rule.RemoteGroup == "" {
+		rule.RemoteIP = normalizeCIDR(r.RemoteIPPrefix)
+	}
+
+	return rule
+}
+
+// DiffRules compares two sets of normalized rules.
+// Results are ordered by presence (only in A, only in B, both) and then by rule.
+func DiffRules(a, b []Rule) []RuleDiff {
+	inA := make(map[Rule]bool, len(a))
+	inB := make(map[Rule]bool, len(b))
+	for _, r := range a {
+		inA[r] = true
+	}
+	for _, r := range b {
+		inB[r] = true
+	}
+
+	var results []RuleDiff
+	for r := range inA {
+		if inB[r] {
+			results = append(results, RuleDiff{Rule: r, Presence: PresenceBoth})
+		} else {
+			results = append(results, RuleDiff{Rule: r, Presence: PresenceOnlyInA})
+		}
+	}
+	for r := range inB {
+		if !inA[r] {
+			results = append(results, RuleDiff{Rule: r, Presence: PresenceOnlyInB})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		pi, pj := presenceOrder(results[i].Presence), presenceOrder(results[j].Presence)
+		if pi != pj {
+			return pi < pj
+		}
+		return results[i].Rule.Less(results[j].Rule)
+	})
+
+	return results
+}
+
+// Less orders rules by direction, ethertype, protocol, port range and remote
+func (r Rule) Less(o Rule) bool {
+	a := []string{r.Direction, r.Ethertype, r.Protocol, r.PortRange, r.RemoteIP, r.RemoteGroup}
+	b := []string{o.Direction, o.Ethertype, o.Protocol, o.PortRange, o.RemoteIP, o.RemoteGroup}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// presenceOrder returns the sort position of a presence value
+func presenceOrder(p Presence) int {
+	switch p {
+	case PresenceOnlyInA:
+		return 0
+	case PresenceOnlyInB:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// normalizeEthertype returns the canonical ethertype spelling (IPv4/IPv6)
+func normalizeEthertype(ethertype string) string {
+	switch strings.ToLower(strings.TrimSpace(ethertype)) {
+	case "ipv4":
+		return "IPv4"
+	case "ipv6":
+		return "IPv6"
+	default:
+		return strings.TrimSpace(ethertype)
+	}
+}
+
+// NormalizeProtocol lowercases the protocol and maps well-known protocol numbers to names.
+// Empty protocols are reported as "any".
+func NormalizeProtocol(protocol string) string {
+	p := strings.ToLower(strings.TrimSpace(protocol))
+	switch p {
+	case "", "any":
+		return "any"
+	case "6":
+		return "tcp"
+	case "17":
+		return "udp"
+	case "1":
+		return "icmp"
+	case "58", "icmpv6", "ipv6-icmp":
+		return "ipv6-icmp"
+	}
+	return p
+}
+
+// FormatPortRange formats a port range the same way as osc list secgrps
+func FormatPortRange(min, max *int) string {
+	switch {
+	case min == nil && max == nil:
+		return "any"
+	case min != nil && max != nil && *min == *max:
+		return fmt.Sprintf("%d", *min)
+	case min != nil && max != nil:
+		return fmt.Sprintf("%d-%d", *min, *max)
+	case min != nil:
+		return fmt.Sprintf("%d", *min)
+	default:
+		return fmt.Sprintf("%d", *max)
+	}
+}
+
+// normalizeCIDR returns the canonical form of a remote IP prefix.
+// Empty prefixes and the all-addresses prefixes are reported as "any".
+func normalizeCIDR(cidr string) string {
+	cidr = strings.TrimSpace(cidr)
+	if cidr == "" || cidr == "any" {
+		return "any"
+	}
+
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		// A bare address is a single-host prefix
+		addr, addrErr := netip.ParseAddr(cidr)
+		if addrErr != nil {
+			return cidr
+		}
+		prefix = netip.PrefixFrom(addr, addr.BitLen())
+	}
+
+	prefix = prefix.Masked()
+	if prefix.Bits() == 0 {
+		return "any"
+	}
+	return prefix.String()
+}
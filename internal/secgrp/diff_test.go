@@ -0,0 +1,69 @@
+package secgrp
+
+import (
+	"testing"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  RawRule
+		want Rule
+	}{
+		{
+			name: "single port with cidr",
+			raw:  RawRule{Direction: "ingress", EtherType: "IPv4", Protocol: "tcp", PortRangeMin: intPtr(22), PortRangeMax: intPtr(22), RemoteIPPrefix: "10.1.2.3/8"},
+			want: Rule{Direction: "ingress", Ethertype: "IPv4", Protocol: "tcp", PortRange: "22", RemoteIP: "10.0.0.0/8"},
+		},
+		{
+			name: "any protocol and open cidr",
+			raw:  RawRule{Direction: "egress", EtherType: "ipv4", RemoteIPPrefix: "0.0.0.0/0"},
+			want: Rule{Direction: "egress", Ethertype: "IPv4", Protocol: "any", PortRange: "any", RemoteIP: "any"},
+		},
+		{
+			name: "remote group resolved by name",
+			raw:  RawRule{Direction: "ingress", EtherType: "IPv4", Protocol: "6", PortRangeMin: intPtr(80), PortRangeMax: intPtr(443), RemoteGroupID: "sg-9", RemoteGroupName: "lb"},
+			want: Rule{Direction: "ingress", Ethertype: "IPv4", Protocol: "tcp", PortRange: "80-443", RemoteIP: "any", RemoteGroup: "lb"},
+		},
+		{
+			name: "self reference",
+			raw:  RawRule{Direction: "ingress", EtherType: "IPv6", RemoteGroupID: "sg-1", RemoteGroupName: "web"},
+			want: Rule{Direction: "ingress", Ethertype: "IPv6", Protocol: "any", PortRange: "any", RemoteIP: "any", RemoteGroup: SelfReference},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Normalize(tt.raw, "sg-1")
+			if got != tt.want {
+				t.Errorf("Normalize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffRules(t *testing.T) {
+	ssh := Rule{Direction: "ingress", Ethertype: "IPv4", Protocol: "tcp", PortRange: "22", RemoteIP: "10.0.0.0/8"}
+	http := Rule{Direction: "ingress", Ethertype: "IPv4", Protocol: "tcp", PortRange: "80", RemoteIP: "any"}
+	mysql := Rule{Direction: "ingress", Ethertype: "IPv4", Protocol: "tcp", PortRange: "3306", RemoteIP: "any", RemoteGroup: "web"}
+
+	diffs := DiffRules([]Rule{ssh, http}, []Rule{http, mysql})
+	if len(diffs) != 3 {
+		t.Fatalf("Expected 3 diffs, got %d", len(diffs))
+	}
+
+	want := []RuleDiff{
+		{Rule: ssh, Presence: PresenceOnlyInA},
+		{Rule: mysql, Presence: PresenceOnlyInB},
+		{Rule: http, Presence: PresenceBoth},
+	}
+	for i := range want {
+		if diffs[i] != want[i] {
+			t.Errorf("diff[%d] = %+v, want %+v", i, diffs[i], want[i])
+		}
+	}
+}
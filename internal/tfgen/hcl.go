@@ -0,0 +1,196 @@
+package tfgen
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Expr is a raw HCL expression, such as a reference to another resource's attribute
+type Expr string
+
+// Attr is a single resource attribute. Value may be a string, int, bool,
+// []string, map[string]string or Expr.
+type Attr struct {
+	Name  string
+	Value any
+}
+
+// Resource is a Terraform resource block
+type Resource struct {
+	Type  string
+	Name  string
+	Attrs []Attr
+}
+
+// Address returns the resource address, e.g. openstack_networking_secgroup_v2.web
+func (r Resource) Address() string {
+	return r.Type + "." + r.Name
+}
+
+// Import is a Terraform 1.5+ import block
+type Import struct {
+	To string
+	ID string
+}
+
+// Module is a module call block
+type Module struct {
+	Name   string
+	Source string
+}
+
+// File collects import and resource blocks and writes them as HCL
+type File struct {
+	Header string
+	// RequiredProviders maps provider local names to their source addresses
+	RequiredProviders map[string]string
+	Modules           []Module
+	Imports           []Import
+	Resources         []Resource
+}
+
+// WriteTo writes the file as HCL: the header comment, the terraform block,
+// then all module, import and resource blocks.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	if f.Header != "" {
+		for _, line := range strings.Split(strings.TrimRight(f.Header, "\n"), "\n") {
+			b.WriteString("# " + line + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(f.RequiredProviders) > 0 {
+		names := make([]string, 0, len(f.RequiredProviders))
+		for name := range f.RequiredProviders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteString("terraform {\n  required_providers {\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "    %s = {\n      source = %s\n    }\n", name, quote(f.RequiredProviders[name]))
+		}
+		b.WriteString("  }\n}\n\n")
+	}
+
+	for _, mod := range f.Modules {
+		fmt.Fprintf(&b, "module %s {\n  source = %s\n}\n\n", quote(mod.Name), quote(mod.Source))
+	}
+
+	for _, imp := range f.Imports {
+		fmt.Fprintf(&b, "import {\n  to = %s\n  id = %s\n}\n\n", imp.To, quote(imp.ID))
+	}
+
+	for i, res := range f.Resources {
+		fmt.Fprintf(&b, "resource %s %s {\n", quote(res.Type), quote(res.Name))
+		width := 0
+		for _, attr := range res.Attrs {
+			if len(attr.Name) > width {
+				width = len(attr.Name)
+			}
+		}
+		for _, attr := range res.Attrs {
+			fmt.Fprintf(&b, "  %-*s = %s\n", width, attr.Name, formatValue(attr.Value, "  "))
+		}
+		b.WriteString("}\n")
+		if i < len(f.Resources)-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// formatValue formats an attribute value as HCL
+func formatValue(v any, indent string) string {
+	switch val := v.(type) {
+	case Expr:
+		return string(val)
+	case string:
+		return quote(val)
+	case int:
+		return strconv.Itoa(val)
+	case bool:
+		return strconv.FormatBool(val)
+	case []string:
+		items := make([]string, len(val))
+		for i, s := range val {
+			items[i] = quote(s)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]string:
+		if len(val) == 0 {
+			return "{}"
+		}
+		keys := make([]string, 0, len(val))
+		width := 0
+		for k := range val {
+			keys = append(keys, k)
+			if len(quote(k)) > width {
+				width = len(quote(k))
+			}
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		b.WriteString("{\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s  %-*s = %s\n", indent, width, quote(k), quote(val[k]))
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	default:
+		return quote(fmt.Sprint(val))
+	}
+}
+
+// quote returns s as an HCL string literal, escaping template sequences
+func quote(s string) string {
+	q := strconv.Quote(s)
+	q = strings.ReplaceAll(q, "${", "$${")
+	return strings.ReplaceAll(q, "%{", "%%{")
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// IsIdentifier reports whether name is a valid Terraform identifier, e.g. a
+// module name that can be used unquoted in addresses and as a directory name
+func IsIdentifier(name string) bool {
+	return identifier.MatchString(name)
+}
+
+// Namer produces unique, valid Terraform resource names per resource type
+type Namer struct {
+	used map[string]bool
+}
+
+// NewNamer creates an empty Namer
+func NewNamer() *Namer {
+	return &Namer{used: make(map[string]bool)}
+}
+
+// Name converts base into a valid resource name that is unique for resType.
+// Duplicates get a numeric suffix: web, web_2, web_3.
+func (n *Namer) Name(resType, base string) string {
+	name := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(base), "_"), "_")
+	if name == "" {
+		name = "resource"
+	}
+	if name[0] >= '0' && name[0] <= '9' || name[0] == '-' {
+		name = "r_" + name
+	}
+
+	candidate := name
+	for i := 2; n.used[resType+"."+candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+	n.used[resType+"."+candidate] = true
+	return candidate
+}
@@ -0,0 +1,124 @@
+package tfgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamer(t *testing.T) {
+	n := NewNamer()
+	tests := []struct {
+		resType, base, want string
+	}{
+		{TypeSecGroup, "Web Servers", "web_servers"},
+		{TypeSecGroup, "web servers", "web_servers_2"},
+		{TypeServer, "web servers", "web_servers"},
+		{TypeServer, "01-db", "r_01-db"},
+		{TypeServer, "!!!", "resource"},
+	}
+	for _, tt := range tests {
+		if got := n.Name(tt.resType, tt.base); got != tt.want {
+			t.Errorf("Name(%q, %q) = %q, want %q", tt.resType, tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestIsIdentifier(t *testing.T) {
+	for name, want := range map[string]bool{
+		"secgrps":  true,
+		"_net-v2":  true,
+		"Web_1":    true,
+		"":         false,
+		"1net":     false,
+		"-net":     false,
+		"a b":      false,
+		"../../x":  false,
+		"a/b":      false,
+		"mod.name": false,
+	} {
+		if got := IsIdentifier(name); got != want {
+			t.Errorf("IsIdentifier(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestBuilderWritesImportsAndReferences(t *testing.T) {
+	min, max := 443, 443
+	b := NewBuilder(true)
+	b.AddSecGroup(SecGroup{ID: "sg-1", Name: "web"})
+	b.AddSecGroupRule(SecGroupRule{
+		ID: "rule-1", SecGroupID: "sg-1", SecGroupName: "web",
+		Direction: "ingress", Ethertype: "IPv4", Protocol: "tcp",
+		PortRangeMin: &min, PortRangeMax: &max, RemoteIPPrefix: "0.0.0.0/0",
+	})
+	b.AddSecGroupRule(SecGroupRule{
+		ID: "rule-2", SecGroupID: "sg-9", SecGroupName: "other",
+		Direction: "egress", Ethertype: "IPv6", RemoteGroupID: "sg-1",
+	})
+	b.AddServer(Server{
+		ID: "srv-1", Name: "web-1", FlavorName: "m1.small", ImageID: "img-1",
+		SecurityGroups: []string{"web"},
+		Metadata:       map[string]string{"role": "web", "tmpl": "${var}"},
+	})
+
+	var out strings.Builder
+	if _, err := b.File.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	got := out.String()
+
+	for _, want := range []string{
+		"import {\n  to = openstack_networking_secgroup_v2.web\n  id = \"sg-1\"\n}\n",
+		"to = openstack_networking_secgroup_rule_v2.web_ingress_tcp_443\n",
+		"to = openstack_compute_instance_v2.web-1\n  id = \"srv-1\"",
+		"  security_group_id = openstack_networking_secgroup_v2.web.id\n",
+		"  remote_group_id   = openstack_networking_secgroup_v2.web.id\n",
+		"  security_group_id = \"sg-9\"\n",
+		"  port_range_min    = 443\n",
+		"  security_groups = [\"web\"]\n",
+		"    \"tmpl\" = \"$${var}\"\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, `= ""`) {
+		t.Errorf("empty attributes should be omitted\n%s", got)
+	}
+}
+
+func TestBuilderWithoutImports(t *testing.T) {
+	b := NewBuilder(false)
+	b.AddSecGroup(SecGroup{ID: "sg-1", Name: "web", Description: "Web tier"})
+
+	var out strings.Builder
+	b.File.WriteTo(&out)
+	if strings.Contains(out.String(), "import {") {
+		t.Errorf("unexpected import block\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), `description = "Web tier"`) {
+		t.Errorf("missing description\n%s", out.String())
+	}
+}
+
+func TestFileWritesModulesAndProviders(t *testing.T) {
+	f := File{
+		RequiredProviders: map[string]string{"openstack": ProviderSource},
+		Modules:           []Module{{Name: "secgrps", Source: "./modules/secgrps"}},
+		Imports:           []Import{{To: "module.secgrps.openstack_networking_secgroup_v2.web", ID: "sg-1"}},
+	}
+
+	var out strings.Builder
+	f.WriteTo(&out)
+	got := out.String()
+
+	for _, want := range []string{
+		"terraform {\n  required_providers {\n    openstack = {\n      source = \"terraform-provider-openstack/openstack\"\n    }\n  }\n}\n",
+		"module \"secgrps\" {\n  source = \"./modules/secgrps\"\n}\n",
+		"to = module.secgrps.openstack_networking_secgroup_v2.web\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n%s", want, got)
+		}
+	}
+}
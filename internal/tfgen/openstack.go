@@ -0,0 +1,176 @@
+package tfgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProviderSource is the registry address of the OpenStack provider
+const ProviderSource = "terraform-provider-openstack/openstack"
+
+// OpenStack provider resource types
+const (
+	TypeServer       = "openstack_compute_instance_v2"
+	TypeSecGroup     = "openstack_networking_secgroup_v2"
+	TypeSecGroupRule = "openstack_networking_secgroup_rule_v2"
+)
+
+// Server holds the attributes used to generate a server resource
+type Server struct {
+	ID             string
+	Name           string
+	FlavorID       string
+	FlavorName     string
+	ImageID        string
+	ImageName      string
+	SecurityGroups []string
+	Metadata       map[string]string
+}
+
+// SecGroup holds the attributes used to generate a security group resource
+type SecGroup struct {
+	ID          string
+	Name        string
+	Description string
+}
+
+// SecGroupRule holds the attributes used to generate a security group rule resource
+type SecGroupRule struct {
+	ID             string
+	SecGroupID     string
+	SecGroupName   string
+	Direction      string
+	Ethertype      string
+	Protocol       string
+	PortRangeMin   *int
+	PortRangeMax   *int
+	RemoteIPPrefix string
+	RemoteGroupID  string
+}
+
+// Builder turns OpenStack resources into a File. Security groups added to the
+// builder are referenced by address from rules added after them.
+type Builder struct {
+	File File
+	// Imports controls whether an import block is written for each resource
+	Imports bool
+	// ModulePrefix is prepended to import addresses, e.g. "module.secgrps."
+	ModulePrefix string
+
+	namer     *Namer
+	secGroups map[string]string // security group ID -> resource address
+}
+
+// NewBuilder creates a Builder
+func NewBuilder(imports bool) *Builder {
+	return &Builder{
+		Imports:   imports,
+		namer:     NewNamer(),
+		secGroups: make(map[string]string),
+	}
+}
+
+// add appends a resource and its import block
+func (b *Builder) add(res Resource, id string) {
+	b.File.Resources = append(b.File.Resources, res)
+	if b.Imports && id != "" {
+		b.File.Imports = append(b.File.Imports, Import{To: b.ModulePrefix + res.Address(), ID: id})
+	}
+}
+
+// AddSecGroup adds a security group resource
+func (b *Builder) AddSecGroup(sg SecGroup) {
+	res := Resource{Type: TypeSecGroup, Name: b.namer.Name(TypeSecGroup, sg.Name)}
+	res.Attrs = append(res.Attrs, Attr{"name", sg.Name})
+	if sg.Description != "" {
+		res.Attrs = append(res.Attrs, Attr{"description", sg.Description})
+	}
+
+	b.secGroups[sg.ID] = res.Address()
+	b.add(res, sg.ID)
+}
+
+// AddSecGroupRule adds a security group rule resource. The parent and remote
+// groups are referenced by address when they were added to the builder.
+func (b *Builder) AddSecGroupRule(rule SecGroupRule) {
+	res := Resource{Type: TypeSecGroupRule, Name: b.namer.Name(TypeSecGroupRule, ruleBaseName(rule))}
+
+	res.Attrs = append(res.Attrs,
+		Attr{"direction", rule.Direction},
+		Attr{"ethertype", rule.Ethertype},
+	)
+	if rule.Protocol != "" {
+		res.Attrs = append(res.Attrs, Attr{"protocol", rule.Protocol})
+	}
+	if rule.PortRangeMin != nil {
+		res.Attrs = append(res.Attrs, Attr{"port_range_min", *rule.PortRangeMin})
+	}
+	if rule.PortRangeMax != nil {
+		res.Attrs = append(res.Attrs, Attr{"port_range_max", *rule.PortRangeMax})
+	}
+	if rule.RemoteIPPrefix != "" {
+		res.Attrs = append(res.Attrs, Attr{"remote_ip_prefix", rule.RemoteIPPrefix})
+	}
+	if rule.RemoteGroupID != "" {
+		res.Attrs = append(res.Attrs, Attr{"remote_group_id", b.secGroupRef(rule.RemoteGroupID)})
+	}
+	res.Attrs = append(res.Attrs, Attr{"security_group_id", b.secGroupRef(rule.SecGroupID)})
+
+	b.add(res, rule.ID)
+}
+
+// AddServer adds a server resource
+func (b *Builder) AddServer(srv Server) {
+	res := Resource{Type: TypeServer, Name: b.namer.Name(TypeServer, srv.Name)}
+
+	res.Attrs = append(res.Attrs, Attr{"name", srv.Name})
+	switch {
+	case srv.FlavorName != "":
+		res.Attrs = append(res.Attrs, Attr{"flavor_name", srv.FlavorName})
+	case srv.FlavorID != "":
+		res.Attrs = append(res.Attrs, Attr{"flavor_id", srv.FlavorID})
+	}
+	switch {
+	case srv.ImageID != "":
+		res.Attrs = append(res.Attrs, Attr{"image_id", srv.ImageID})
+	case srv.ImageName != "":
+		res.Attrs = append(res.Attrs, Attr{"image_name", srv.ImageName})
+	}
+	if len(srv.SecurityGroups) > 0 {
+		res.Attrs = append(res.Attrs, Attr{"security_groups", srv.SecurityGroups})
+	}
+	if len(srv.Metadata) > 0 {
+		res.Attrs = append(res.Attrs, Attr{"metadata", srv.Metadata})
+	}
+
+	b.add(res, srv.ID)
+}
+
+// secGroupRef returns a reference to a security group added to the builder, or its literal ID
+func (b *Builder) secGroupRef(id string) any {
+	if addr, ok := b.secGroups[id]; ok {
+		return Expr(addr + ".id")
+	}
+	return id
+}
+
+// ruleBaseName names a rule after its group, direction, protocol and ports, e.g. web_ingress_tcp_443
+func ruleBaseName(rule SecGroupRule) string {
+	parts := []string{rule.SecGroupName}
+	if rule.SecGroupName == "" {
+		parts[0] = "rule"
+	}
+	parts = append(parts, rule.Direction)
+	if rule.Protocol != "" {
+		parts = append(parts, rule.Protocol)
+	} else {
+		parts = append(parts, "any")
+	}
+	switch {
+	case rule.PortRangeMin != nil && rule.PortRangeMax != nil && *rule.PortRangeMin != *rule.PortRangeMax:
+		parts = append(parts, fmt.Sprintf("%d_%d", *rule.PortRangeMin, *rule.PortRangeMax))
+	case rule.PortRangeMin != nil:
+		parts = append(parts, fmt.Sprintf("%d", *rule.PortRangeMin))
+	}
+	return strings.Join(parts, "_")
+}
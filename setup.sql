@@ -28,6 +28,7 @@ CREATE TABLE os_servers (
     flavor_id   TEXT,
     flavor_name TEXT,
     metadata    TEXT,
+    floating_ip TEXT,
     FOREIGN KEY (project_id) REFERENCES os_project_names(project_id) ON DELETE CASCADE
 );
 
@@ -80,6 +81,11 @@ INSERT INTO os_servers (server_id, server_name, project_id, ipv4_addr, status, i
     ('srv-113', 'sa1x-server-p13', 'proj-7', '192.168.7.113', 'SUSPENDED', 'img-003', 'debian-11', 'flv-large', 'm1.large', '{"reason":"maintenance"}'),
     ('srv-114', 'sa1x-server-p14', 'proj-7', '192.168.7.114', 'ACTIVE', 'img-004', 'rocky-9', 'flv-small', 'm1.small', '{"project":"analytics","department":"data-science"}');
 
+-- Assign floating IPs to the internet-facing servers
+UPDATE os_servers SET floating_ip = '203.0.113.101' WHERE server_id = 'srv-101';
+UPDATE os_servers SET floating_ip = '203.0.113.102' WHERE server_id = 'srv-102';
+UPDATE os_servers SET floating_ip = '203.0.113.107' WHERE server_id = 'srv-107';
+
 -- Insert dummy data into security groups (multi-tier architecture)
 INSERT INTO os_security_groups (secgrp_id, secgrp_name, project_id) VALUES
     ('sg-1', 'default', 'proj-1'),